@@ -20,6 +20,7 @@ func init() {
 	config.InitDatabase()
 	config.InitRedis()
 	config.InitSupabase()
+	config.InitStore()
 
 	App = gin.New()
 	App.Use(gin.Recovery())
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	// the serverless runtime has no zoneinfo, embed it
+	_ "time/tzdata"
+)
+
+// StoreLocation is the timezone of the store, opening and daily product hours are read in it
+var StoreLocation = time.UTC
+
+// StoreClosedPolicy is what checkout does while the store is closed,
+// "reject" refuses the order and "schedule" books it on the next free slot of its order method
+var StoreClosedPolicy = "reject"
+
+// StoreLatitude and StoreLongitude are where radius delivery zones are measured from, nil when not set
+var StoreLatitude, StoreLongitude *float64
+
+func InitStore() {
+	name := os.Getenv("STORE_TIMEZONE")
+	if name == "" {
+		name = "Asia/Jakarta"
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatalf("Invalid store timezone: %v", err)
+	}
+	StoreLocation = location
+
+	switch policy := os.Getenv("STORE_CLOSED_POLICY"); policy {
+	case "":
+	case "reject", "schedule":
+		StoreClosedPolicy = policy
+	default:
+		log.Fatalf("Invalid store closed policy: %s", policy)
+	}
+
+	latitude, longitude := os.Getenv("STORE_LATITUDE"), os.Getenv("STORE_LONGITUDE")
+	if latitude != "" || longitude != "" {
+		parsedLatitude, err := strconv.ParseFloat(latitude, 64)
+		if err != nil || parsedLatitude < -90 || parsedLatitude > 90 {
+			log.Fatalf("Invalid store latitude: %s", latitude)
+		}
+		parsedLongitude, err := strconv.ParseFloat(longitude, 64)
+		if err != nil || parsedLongitude < -180 || parsedLongitude > 180 {
+			log.Fatalf("Invalid store longitude: %s", longitude)
+		}
+		StoreLatitude, StoreLongitude = &parsedLatitude, &parsedLongitude
+	}
+}
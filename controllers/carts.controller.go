@@ -51,7 +51,7 @@ func ListCarts(ctx *gin.Context) {
 
 // AddCart       godoc
 // @Summary      Add new cart
-// @Description  Add a new cart to list carts of user
+// @Description  Add a new cart to list carts of user. modifierOptionIds must satisfy the min and max selections of every modifier group of the product, the same product with the same size, variant and modifiers is merged into one line. Bundles are added without sizeId and variantId, their components come with their own
 // @Tags         carts
 // @Accept       application/json
 // @Produce      json
@@ -59,8 +59,9 @@ func ListCarts(ctx *gin.Context) {
 // @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
 // @Param        dataCart       body    models.CartRequest  true  "Data request add cart"
 // @Success      201            {object}  lib.ResponseSuccess{data=models.Cart}  "Cart added successfully"
-// @Failure      400            {object}  lib.ResponseError  "Invalid request body"
+// @Failure      400            {object}  lib.ResponseError  "Invalid request body, amount, modifier selection or product not available at this time"
 // @Failure      401            {object}  lib.ResponseError  "User unauthorized"
+// @Failure      404            {object}  lib.ResponseError  "Product not found"
 // @Failure      500            {object}  lib.ResponseError  "Internal server error while adding, updating, or get data from cart"
 // @Router       /carts [post]
 func AddCart(ctx *gin.Context) {
@@ -90,7 +91,10 @@ func AddCart(ctx *gin.Context) {
 	responseCart, message, err := models.AddToCart(bodyAdd)
 	if err != nil {
 		if message == "invalid amount, must be greater than 0" ||
-			message == "amount exceeds available stock" {
+			message == "amount exceeds available stock" ||
+			message == "Bundle sizes and variants are set per component" ||
+			message == "Selected size and variant is not available" ||
+			message == "Product is not available at this time" {
 			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
 				Success: false,
 				Message: message,
@@ -98,6 +102,26 @@ func AddCart(ctx *gin.Context) {
 			return
 		}
 
+		if message == "Modifier option is selected more than once" ||
+			message == "Modifier option is not available for this product" ||
+			message == "Required modifier is not selected" ||
+			message == "Too many modifier options selected" {
+			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if message == "Product not found" {
+			ctx.JSON(http.StatusNotFound, lib.ResponseError{
+				Success: false,
+				Message: message,
+			})
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: false,
 			Message: message,
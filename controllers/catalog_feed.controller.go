@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedCurrency is the ISO 4217 code of every price in the store
+const feedCurrency = "IDR"
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type productFeedRss struct {
+	XMLName xml.Name           `xml:"rss"`
+	Version string             `xml:"version,attr"`
+	XmlnsG  string             `xml:"xmlns:g,attr"`
+	Channel productFeedChannel `xml:"channel"`
+}
+
+type productFeedChannel struct {
+	Title       string               `xml:"title"`
+	Link        string               `xml:"link"`
+	Description string               `xml:"description"`
+	Items       []productFeedRssItem `xml:"item"`
+}
+
+type productFeedRssItem struct {
+	Id           int    `xml:"g:id"`
+	Title        string `xml:"title"`
+	Description  string `xml:"description"`
+	Link         string `xml:"link"`
+	ImageLink    string `xml:"g:image_link,omitempty"`
+	Availability string `xml:"g:availability"`
+	Condition    string `xml:"g:condition"`
+	Price        string `xml:"g:price"`
+	SalePrice    string `xml:"g:sale_price,omitempty"`
+	ProductType  string `xml:"g:product_type,omitempty"`
+}
+
+// storefrontUrl is the shop frontend that sitemap and feed links point to
+func storefrontUrl(ctx *gin.Context) (string, bool) {
+	appUrl := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	if appUrl == "" {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "APP_URL is not configured",
+		})
+		return "", false
+	}
+	return appUrl, true
+}
+
+// writeXml renders value as an xml document
+func writeXml(ctx *gin.Context, value any) {
+	body, err := xml.MarshalIndent(value, "", "  ")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to serialize xml",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// Sitemap        godoc
+// @Summary        Get sitemap
+// @Description    Sitemap of the storefront with every active product and category, lastmod from updated_at
+// @Tags           seo
+// @Produce        xml
+// @Success        200  {string}  string  "Sitemap xml"
+// @Failure        500  {object}  lib.ResponseError  "Internal server error while building sitemap"
+// @Router         /sitemap.xml [get]
+func Sitemap(ctx *gin.Context) {
+	appUrl, ok := storefrontUrl(ctx)
+	if !ok {
+		return
+	}
+
+	entries, message, err := getWithCache("catalog:sitemap", models.GetSitemapEntries)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	urlSet := sitemapUrlSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Urls: []sitemapUrl{
+			{Loc: appUrl + "/"},
+			{Loc: appUrl + "/products"},
+		},
+	}
+	for _, entry := range entries {
+		// categories are product listings filtered by slug
+		loc := appUrl + "/products/" + entry.Slug
+		if entry.Kind == "category" {
+			loc = appUrl + "/products?cat=" + entry.Slug
+		}
+		urlSet.Urls = append(urlSet.Urls, sitemapUrl{
+			Loc:     loc,
+			LastMod: entry.UpdatedAt.Format("2006-01-02"),
+		})
+	}
+
+	writeXml(ctx, urlSet)
+}
+
+// ProductFeed    godoc
+// @Summary        Get product feed
+// @Description    Marketplace feed of every active product with price, discount price, availability from stock and primary image. Default format is a Google Merchant RSS feed
+// @Tags           seo
+// @Produce        xml
+// @Produce        json
+// @Param          format  query  string  false  "Feed format"  Enums(xml, json)  default(xml)
+// @Success        200  {object}  lib.ResponseSuccess{data=[]models.ProductFeedItem}  "Product feed"
+// @Failure        400  {object}  lib.ResponseError  "Invalid format"
+// @Failure        500  {object}  lib.ResponseError  "Internal server error while building product feed"
+// @Router         /product-feed [get]
+func ProductFeed(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", "xml")
+	if format != "xml" && format != "json" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid format. Allowed values: xml, json",
+		})
+		return
+	}
+
+	appUrl, ok := storefrontUrl(ctx)
+	if !ok {
+		return
+	}
+
+	syncFlashSaleCache()
+
+	items, message, err := getWithCache("catalog:feed", models.GetProductFeed)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	for i := range items {
+		items[i].Link = appUrl + "/products/" + items[i].Slug
+		items[i].Availability = "out_of_stock"
+		if items[i].Stock > 0 {
+			items[i].Availability = "in_stock"
+		}
+	}
+
+	if format == "json" {
+		ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+			Success: true,
+			Message: "Success get product feed",
+			Data:    items,
+		})
+		return
+	}
+
+	feed := productFeedRss{
+		Version: "2.0",
+		XmlnsG:  "http://base.google.com/ns/1.0",
+		Channel: productFeedChannel{
+			Title:       "Daily Greens",
+			Link:        appUrl,
+			Description: "Daily Greens product catalog",
+			Items:       make([]productFeedRssItem, 0, len(items)),
+		},
+	}
+	for _, item := range items {
+		rssItem := productFeedRssItem{
+			Id:           item.Id,
+			Title:        item.Name,
+			Description:  item.Description,
+			Link:         item.Link,
+			ImageLink:    item.ImageLink,
+			Availability: item.Availability,
+			Condition:    "new",
+			Price:        fmt.Sprintf("%.2f %s", item.Price, feedCurrency),
+			ProductType:  item.ProductType,
+		}
+		if item.DiscountPrice > 0 {
+			rssItem.SalePrice = fmt.Sprintf("%.2f %s", item.DiscountPrice, feedCurrency)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rssItem)
+	}
+
+	writeXml(ctx, feed)
+}
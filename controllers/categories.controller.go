@@ -4,8 +4,14 @@ import (
 	"backend-daily-greens/lib"
 	"backend-daily-greens/models"
 	"backend-daily-greens/utils"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -13,17 +19,39 @@ import (
 
 // ListCategores     godoc
 // @Summary      	 Get list categories
-// @Description  	 Retrieving list categories data with pagination support
+// @Description  	 Retrieving list categories data with pagination support, deleted categories are hidden
+// @Tags         	 categories
+// @Produce      	 json
+// @Param        	 page           query     int     false  "Page number"               default(1)   minimum(1)
+// @Param        	 limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(100)
+// @Param        	 search         query     string  false  "Search value"
+// @Success      	 200  {object}  object{success=bool,message=string,data=[]models.Category,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved category list"
+// @Failure      	 400  {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
+// @Failure      	 500  {object}  lib.ResponseError  "Internal server error while fetching or processing category data"
+// @Router       	 /categories [get]
+func ListCategories(ctx *gin.Context) {
+	listCategories(ctx, false)
+}
+
+// ListCategoriesAdmin godoc
+// @Summary      	 Get list categories for admin
+// @Description  	 Retrieving list categories data with pagination support, soft deleted categories included with their deleted_at
 // @Tags         	 admin/categories
 // @Produce      	 json
+// @Security         BearerAuth
+// @Param            Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
 // @Param        	 page           query     int     false  "Page number"               default(1)   minimum(1)
 // @Param        	 limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(100)
 // @Param        	 search         query     string  false  "Search value"
-// @Success      	 200  {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved category list"
+// @Success      	 200  {object}  object{success=bool,message=string,data=[]models.Category,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved category list"
 // @Failure      	 400  {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
 // @Failure      	 500  {object}  lib.ResponseError  "Internal server error while fetching or processing category data"
 // @Router       	 /admin/categories [get]
-func ListCategories(ctx *gin.Context) {
+func ListCategoriesAdmin(ctx *gin.Context) {
+	listCategories(ctx, true)
+}
+
+func listCategories(ctx *gin.Context, includeDeleted bool) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 	search := ctx.Query("search")
@@ -53,7 +81,7 @@ func ListCategories(ctx *gin.Context) {
 	}
 
 	// get total data categories
-	totalData, err := models.GetTotalDataCategories(search)
+	totalData, err := models.GetTotalDataCategories(search, includeDeleted)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: false,
@@ -64,7 +92,7 @@ func ListCategories(ctx *gin.Context) {
 	}
 
 	// get list all categories
-	categories, message, err := models.GetListAllCategories(page, limit, search)
+	categories, message, err := models.GetListAllCategories(page, limit, search, includeDeleted)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: false,
@@ -101,6 +129,32 @@ func ListCategories(ctx *gin.Context) {
 	})
 }
 
+// CategoryTree      godoc
+// @Summary          Get category tree
+// @Description      Retrieving categories nested under their parent in display order, product_count includes the products of every subcategory
+// @Tags             categories
+// @Produce          json
+// @Success          200  {object}  lib.ResponseSuccess{data=[]models.CategoryNode}  "Successfully retrieved category tree"
+// @Failure          500  {object}  lib.ResponseError  "Internal server error while fetching category tree"
+// @Router           /categories/tree [get]
+func CategoryTree(ctx *gin.Context) {
+	tree, message, err := models.GetCategoryTree()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    tree,
+	})
+}
+
 // DetailCategory    godoc
 // @Summary          Get detail category
 // @Description      Retrieving detail category data based on Id
@@ -148,18 +202,148 @@ func DetailCategory(ctx *gin.Context) {
 	})
 }
 
+// uploadCategoryImage validates and uploads the optional fileImage, an empty url means no image was sent
+func uploadCategoryImage(ctx *gin.Context) (string, bool) {
+	file, err := ctx.FormFile("fileImage")
+	if err != nil {
+		return "", true
+	}
+
+	// check file size
+	if file.Size > 1<<20 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: fmt.Sprintf("Image size must be less than 1MB (got %.2f MB)", float64(file.Size)/(1<<20)),
+		})
+		return "", false
+	}
+
+	allowedTypes := map[string]bool{
+		"image/jpg":  true,
+		"image/jpeg": true,
+		"image/png":  true,
+	}
+	allowedExt := map[string]bool{
+		".jpg":  true,
+		".jpeg": true,
+		".png":  true,
+	}
+
+	// check content type
+	contentType := file.Header.Get("Content-Type")
+	if !allowedTypes[contentType] {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Image has invalid type. Only JPEG and PNG are allowed",
+		})
+		return "", false
+	}
+
+	// check file extension
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !allowedExt[ext] {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Image has invalid extension. Only JPG and PNG are allowed",
+		})
+		return "", false
+	}
+
+	fileName := fmt.Sprintf("category_%d", time.Now().UnixNano())
+	imageUrl, err := utils.UploadToSupabase(file, fileName, "categories")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to upload category image",
+			Error:   err.Error(),
+		})
+		return "", false
+	}
+
+	return imageUrl, true
+}
+
+// checkCategorySlug normalizes slug (or the name when slug is empty) and checks it is free
+func checkCategorySlug(ctx *gin.Context, slug string, categoryId int) (string, bool) {
+	slug = utils.Slugify(slug)
+	if slug == "" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Slug must contain letters or digits",
+		})
+		return "", false
+	}
+
+	exists, err := models.CheckCategorySlugExcludingId(slug, categoryId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking category slug uniqueness",
+			Error:   err.Error(),
+		})
+		return "", false
+	}
+
+	if exists {
+		ctx.JSON(http.StatusConflict, lib.ResponseError{
+			Success: false,
+			Message: "Category slug already exists",
+		})
+		return "", false
+	}
+
+	return slug, true
+}
+
+// checkCategoryParent rejects a parent that does not exist or sits below the category itself
+func checkCategoryParent(ctx *gin.Context, categoryId int, parentId int) bool {
+	message, err := models.CheckCategoryParent(categoryId, parentId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if message == "Parent category not found" {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return false
+	}
+
+	if message != "" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return false
+	}
+
+	return true
+}
+
 // CreateCategory    godoc
 // @Summary      Create new category
-// @Description  Create a new category with a unique name
+// @Description  Create a new category with a unique name and slug, optionally under a parent category
 // @Tags         admin/categories
-// @Accept       x-www-form-urlencoded
+// @Accept       multipart/form-data
 // @Produce      json
 // @Security     BearerAuth
-// @Param        Authorization  header    string  true  "Bearer token"  default(Bearer <token>)
-// @Param        name           formData  string  true  "Category name"
+// @Param        Authorization  header    string  true   "Bearer token"  default(Bearer <token>)
+// @Param        name           formData  string  true   "Category name"
+// @Param        slug           formData  string  false  "Category slug, generated from the name when empty"
+// @Param        description    formData  string  false  "Category description"
+// @Param        parentId       formData  int     false  "Parent category Id, empty or 0 for a top level category"
+// @Param        sortOrder      formData  int     false  "Display order among its siblings, lowest first"  default(0)
+// @Param        fileImage      formData  file    false  "Category image (JPG/PNG, max 1MB)"
 // @Success      201  {object}  lib.ResponseSuccess{data=models.Category}  "Category created successfully"
-// @Failure      400  {object}  lib.ResponseError  "Invalid request body"
-// @Failure      409  {object}  lib.ResponseError  "Category name already exists"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body, invalid slug or invalid image"
+// @Failure      404  {object}  lib.ResponseError  "Parent category not found"
+// @Failure      409  {object}  lib.ResponseError  "Category name or slug already exists"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while creating category"
 // @Router       /admin/categories [post]
 func CreateCategory(ctx *gin.Context) {
@@ -174,6 +358,15 @@ func CreateCategory(ctx *gin.Context) {
 		return
 	}
 
+	bodyCreateCategory.Name = strings.TrimSpace(bodyCreateCategory.Name)
+	if bodyCreateCategory.Name == "" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Name is required",
+		})
+		return
+	}
+
 	// check category name
 	exists, err := models.CheckCategoryName(bodyCreateCategory.Name)
 	if err != nil {
@@ -193,6 +386,25 @@ func CreateCategory(ctx *gin.Context) {
 		return
 	}
 
+	// slug defaults to the name
+	slug := bodyCreateCategory.Slug
+	if strings.TrimSpace(slug) == "" {
+		slug = bodyCreateCategory.Name
+	}
+	slug, ok := checkCategorySlug(ctx, slug, 0)
+	if !ok {
+		return
+	}
+	bodyCreateCategory.Slug = slug
+
+	// parentId 0 is a top level category
+	if bodyCreateCategory.ParentId != nil && *bodyCreateCategory.ParentId == 0 {
+		bodyCreateCategory.ParentId = nil
+	}
+	if bodyCreateCategory.ParentId != nil && !checkCategoryParent(ctx, 0, *bodyCreateCategory.ParentId) {
+		return
+	}
+
 	// get user id from token
 	userId, exists := ctx.Get("userId")
 	if !exists {
@@ -203,9 +415,18 @@ func CreateCategory(ctx *gin.Context) {
 		return
 	}
 
+	imageUrl, ok := uploadCategoryImage(ctx)
+	if !ok {
+		return
+	}
+	bodyCreateCategory.Image = imageUrl
+
 	// insert data category
 	isSuccess, message, err := models.InsertDataCategory(userId.(int), &bodyCreateCategory)
 	if err != nil {
+		if imageUrl != "" {
+			utils.DeleteFromSupabase(imageUrl, "categories")
+		}
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: isSuccess,
 			Message: message,
@@ -214,30 +435,43 @@ func CreateCategory(ctx *gin.Context) {
 		return
 	}
 
+	// category names are part of product search and suggestions
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	category, _, err := models.GetCategoryById(bodyCreateCategory.Id)
+	if err != nil {
+		log.Printf("Failed to read category %d after insert: %v", bodyCreateCategory.Id, err)
+		category = bodyCreateCategory
+	}
+
 	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
 		Success: true,
 		Message: message,
-		Data: models.Category{
-			Id:   bodyCreateCategory.Id,
-			Name: bodyCreateCategory.Name,
-		},
+		Data:    category,
 	})
 }
 
 // UpdateCategory    godoc
 // @Summary      Update category
-// @Description  Updating category data based on Id
+// @Description  Updating category data based on Id, only the fields that are sent change. A new image replaces the old one
 // @Tags         admin/categories
-// @Accept       x-www-form-urlencoded
+// @Accept       multipart/form-data
 // @Produce      json
 // @Security     BearerAuth
-// @Param        Authorization  header    string  true  "Bearer token"  default(Bearer <token>)
-// @Param        id             path      int     true  "Category Id"
-// @Param        name           formData  string  true  "Category name"
+// @Param        Authorization  header    string  true   "Bearer token"  default(Bearer <token>)
+// @Param        id             path      int     true   "Category Id"
+// @Param        name           formData  string  false  "Category name"
+// @Param        slug           formData  string  false  "Category slug"
+// @Param        description    formData  string  false  "Category description, empty clears it"
+// @Param        parentId       formData  int     false  "Parent category Id, 0 moves the category to the top level"
+// @Param        sortOrder      formData  int     false  "Display order among its siblings, lowest first"
+// @Param        fileImage      formData  file    false  "Category image (JPG/PNG, max 1MB)"
 // @Success      200  {object}  lib.ResponseSuccess  "Category updated successfully"
-// @Failure      400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
-// @Failure      404  {object}  lib.ResponseError  "Category not found"
-// @Failure      409  {object}  lib.ResponseError  "Category name already exists"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format, invalid request body or parent inside the category"
+// @Failure      404  {object}  lib.ResponseError  "Category or parent category not found"
+// @Failure      409  {object}  lib.ResponseError  "Category name or slug already exists"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while updating category data"
 // @Router       /admin/categories/{id} [patch]
 func UpdateCategory(ctx *gin.Context) {
@@ -251,31 +485,71 @@ func UpdateCategory(ctx *gin.Context) {
 		return
 	}
 
-	bodyUpdate := ctx.PostForm("name")
-	if bodyUpdate == "" {
+	var bodyUpdate models.CategoryUpdateRequest
+	err = ctx.ShouldBindWith(&bodyUpdate, binding.Form)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
 			Success: false,
-			Message: "Name is required",
+			Message: "Invalid form data",
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	// check category name
-	exists, err := models.CheckCategoryNameExcludingId(bodyUpdate, id)
+	category, message, err := models.GetCategoryById(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+		statusCode := http.StatusInternalServerError
+		if message == "Category not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
 			Success: false,
-			Message: "Internal server error while checking category name uniqueness",
+			Message: message,
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	if exists {
-		ctx.JSON(http.StatusConflict, lib.ResponseError{
-			Success: false,
-			Message: "Category name already exists",
-		})
+	if bodyUpdate.Name != nil {
+		name := strings.TrimSpace(*bodyUpdate.Name)
+		if name == "" {
+			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+				Success: false,
+				Message: "Name cannot be empty",
+			})
+			return
+		}
+		bodyUpdate.Name = &name
+
+		// check category name
+		exists, err := models.CheckCategoryNameExcludingId(name, id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: "Internal server error while checking category name uniqueness",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if exists {
+			ctx.JSON(http.StatusConflict, lib.ResponseError{
+				Success: false,
+				Message: "Category name already exists",
+			})
+			return
+		}
+	}
+
+	if bodyUpdate.Slug != nil {
+		slug, ok := checkCategorySlug(ctx, *bodyUpdate.Slug, id)
+		if !ok {
+			return
+		}
+		bodyUpdate.Slug = &slug
+	}
+
+	if bodyUpdate.ParentId != nil && *bodyUpdate.ParentId != 0 && !checkCategoryParent(ctx, id, *bodyUpdate.ParentId) {
 		return
 	}
 
@@ -289,9 +563,18 @@ func UpdateCategory(ctx *gin.Context) {
 		return
 	}
 
+	imageUrl, ok := uploadCategoryImage(ctx)
+	if !ok {
+		return
+	}
+	bodyUpdate.Image = imageUrl
+
 	// update data category
 	isSuccess, message, err := models.UpdateDataCategory(id, userId.(int), bodyUpdate)
 	if err != nil {
+		if imageUrl != "" {
+			utils.DeleteFromSupabase(imageUrl, "categories")
+		}
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: isSuccess,
 			Message: message,
@@ -308,6 +591,18 @@ func UpdateCategory(ctx *gin.Context) {
 		return
 	}
 
+	// the new image is saved, the old file is no longer used
+	if imageUrl != "" && category.Image != "" {
+		if err := utils.DeleteFromSupabase(category.Image, "categories"); err != nil {
+			log.Printf("Failed to delete old image of category %d: %v", id, err)
+		}
+	}
+
+	// category names are part of product search and suggestions
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
 	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
 		Success: true,
 		Message: message,
@@ -316,7 +611,7 @@ func UpdateCategory(ctx *gin.Context) {
 
 // DeleteCategory    godoc
 // @Summary      Delete category
-// @Description  Delete category by Id
+// @Description  Soft delete category by Id, it can be restored until it is purged
 // @Tags         admin/categories
 // @Accept       x-www-form-urlencoded
 // @Produce      json
@@ -325,7 +620,9 @@ func UpdateCategory(ctx *gin.Context) {
 // @Param        id             path    int     true  "Category Id"
 // @Success      200  {object}  lib.ResponseSuccess  "Category deleted successfully"
 // @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
 // @Failure      404  {object}  lib.ResponseError  "Category not found"
+// @Failure      409  {object}  lib.ResponseError  "Category still has subcategories"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while deleting category data"
 // @Router       /admin/categories/{id} [delete]
 func DeleteCategory(ctx *gin.Context) {
@@ -339,7 +636,36 @@ func DeleteCategory(ctx *gin.Context) {
 		return
 	}
 
-	commandTag, err := models.DeleteDataCategory(id)
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	// subcategories would be hidden with their parent, they have to be moved or deleted first
+	hasChildren, err := models.CheckCategoryHasChildren(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking subcategories",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if hasChildren {
+		ctx.JSON(http.StatusConflict, lib.ResponseError{
+			Success: false,
+			Message: "Category still has subcategories, move or delete them first",
+		})
+		return
+	}
+
+	commandTag, err := models.DeleteDataCategory(id, userIdFromToken.(int))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: false,
@@ -357,8 +683,148 @@ func DeleteCategory(ctx *gin.Context) {
 		return
 	}
 
+	// category names are part of product search and suggestions
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
 	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
 		Success: true,
 		Message: "Category deleted successfully",
 	})
 }
+
+// RestoreCategory   godoc
+// @Summary      Restore category
+// @Description  Restore a soft deleted category
+// @Tags         admin/categories
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Category Id"
+// @Success      200  {object}  lib.ResponseSuccess  "Category restored successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Deleted category not found"
+// @Failure      409  {object}  lib.ResponseError  "Parent category is deleted"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while restoring category data"
+// @Router       /admin/categories/{id}/restore [post]
+func RestoreCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	// a subcategory can only come back under a visible parent
+	category, message, err := models.GetCategoryById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if message == "Category not found" {
+			statusCode = http.StatusNotFound
+			message = "Deleted category not found"
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if category.ParentId != nil {
+		parent, message, err := models.GetCategoryById(*category.ParentId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if parent.DeletedAt != nil {
+			ctx.JSON(http.StatusConflict, lib.ResponseError{
+				Success: false,
+				Message: "Parent category is deleted, restore it first",
+			})
+			return
+		}
+	}
+
+	commandTag, err := models.RestoreDataCategory(id, userIdFromToken.(int))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while restoring category data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Deleted category not found",
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: "Category restored successfully",
+	})
+}
+
+// PurgeCategories   godoc
+// @Summary      Purge deleted categories
+// @Description  Permanently remove soft deleted categories. Categories still linked to a product or a subcategory are skipped
+// @Tags         admin/categories
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        olderThanDays  query   int     false  "Only purge categories deleted at least this many days ago"  default(0)  minimum(0)
+// @Success      200  {object}  lib.ResponseSuccess{data=models.PurgeResult}  "Deleted categories purged successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid olderThanDays"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while purging categories"
+// @Router       /admin/categories/purge [post]
+func PurgeCategories(ctx *gin.Context) {
+	olderThanDays, ok := purgeOlderThanDays(ctx)
+	if !ok {
+		return
+	}
+
+	result, message, err := models.PurgeDeletedCategories(olderThanDays)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    result,
+	})
+}
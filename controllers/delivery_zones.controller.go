@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// deliveryZoneStatusCode maps validation messages of the delivery zone model to 400 and 404
+func deliveryZoneStatusCode(message string) int {
+	switch message {
+	case "Name is required",
+		"Delivery fee cannot be negative",
+		"Minimum order cannot be negative",
+		"Store coordinate is not configured",
+		"Max radius must be greater than min radius",
+		"Polygon needs at least 3 points",
+		"Polygon edges cannot cross each other",
+		"Invalid coordinate",
+		"Kind must be radius or polygon":
+		return http.StatusBadRequest
+	case "Order method not found", "Delivery zone not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ListDeliveryZones godoc
+// @Summary      Get list delivery zones
+// @Description  Retrieving delivery zones in the order checkout matches them, the first active zone a customer coordinate falls in sets the delivery fee
+// @Tags         admin/delivery-zones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        orderMethodId  query   int     false  "Only zones of this order method"
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.DeliveryZone}  "Successfully retrieved delivery zones"
+// @Failure      400  {object}  lib.ResponseError  "Invalid order method Id format"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching delivery zones"
+// @Router       /admin/delivery-zones [get]
+func ListDeliveryZones(ctx *gin.Context) {
+	orderMethodId, err := strconv.Atoi(ctx.DefaultQuery("orderMethodId", "0"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid order method Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	deliveryZones, message, err := models.GetListDeliveryZones(orderMethodId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    deliveryZones,
+	})
+}
+
+// DetailDeliveryZone godoc
+// @Summary      Get delivery zone by Id
+// @Description  Retrieving a delivery zone with its polygon points
+// @Tags         admin/delivery-zones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Delivery zone Id"
+// @Success      200  {object}  lib.ResponseSuccess{data=models.DeliveryZone}  "Successfully retrieved delivery zone"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      404  {object}  lib.ResponseError  "Delivery zone not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching delivery zone"
+// @Router       /admin/delivery-zones/{id} [get]
+func DetailDeliveryZone(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	deliveryZone, message, err := models.GetDeliveryZoneById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    deliveryZone,
+	})
+}
+
+// CreateDeliveryZone godoc
+// @Summary      Create delivery zone
+// @Description  Add a delivery zone to an order method. A radius zone is a ring between minRadiusKm and maxRadiusKm around the store coordinate, a polygon zone is outlined by at least 3 points. Once an order method has zones, checkout needs the customer coordinate and rejects addresses outside every zone
+// @Tags         admin/delivery-zones
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                      true  "Bearer token"  default(Bearer <token>)
+// @Param        dataZone       body    models.DeliveryZoneRequest  true  "Data delivery zone"
+// @Success      201  {object}  lib.ResponseSuccess{data=models.DeliveryZone}  "Delivery zone created successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Order method not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while creating delivery zone"
+// @Router       /admin/delivery-zones [post]
+func CreateDeliveryZone(ctx *gin.Context) {
+	var bodyCreate models.DeliveryZoneRequest
+	err := ctx.ShouldBindJSON(&bodyCreate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	deliveryZoneId, message, err := models.InsertDeliveryZone(userId.(int), bodyCreate)
+	if err != nil {
+		ctx.JSON(deliveryZoneStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	deliveryZone, _, err := models.GetDeliveryZoneById(deliveryZoneId)
+	if err != nil {
+		log.Printf("Failed to read delivery zone %d after insert: %v", deliveryZoneId, err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    deliveryZone,
+	})
+}
+
+// UpdateDeliveryZone godoc
+// @Summary      Update delivery zone
+// @Description  Replace a delivery zone with the request, polygon points are replaced as well
+// @Tags         admin/delivery-zones
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                      true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int                         true  "Delivery zone Id"
+// @Param        dataZone       body    models.DeliveryZoneRequest  true  "Data delivery zone"
+// @Success      200  {object}  lib.ResponseSuccess{data=models.DeliveryZone}  "Delivery zone updated successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Delivery zone or order method not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while updating delivery zone"
+// @Router       /admin/delivery-zones/{id} [put]
+func UpdateDeliveryZone(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.DeliveryZoneRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateDeliveryZone(id, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(deliveryZoneStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	deliveryZone, _, err := models.GetDeliveryZoneById(id)
+	if err != nil {
+		log.Printf("Failed to read delivery zone %d after update: %v", id, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    deliveryZone,
+	})
+}
+
+// DeleteDeliveryZone godoc
+// @Summary      Delete delivery zone
+// @Description  Delete a delivery zone, an order method without zones goes back to its flat delivery fee
+// @Tags         admin/delivery-zones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Delivery zone Id"
+// @Success      200  {object}  lib.ResponseSuccess  "Delivery zone deleted successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      404  {object}  lib.ResponseError  "Delivery zone not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while deleting delivery zone"
+// @Router       /admin/delivery-zones/{id} [delete]
+func DeleteDeliveryZone(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	isSuccess, message, err := models.DeleteDeliveryZone(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
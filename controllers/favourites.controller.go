@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"backend-daily-greens/utils"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// markFavourites flags the products favourited by the logged in user.
+// Product lists are cached for everyone, so this runs on every request.
+func markFavourites(ctx *gin.Context, products []models.PublicProductResponse) {
+	userId, exists := ctx.Get("userId")
+	if !exists || len(products) == 0 {
+		return
+	}
+
+	productIds := make([]int, len(products))
+	for i, product := range products {
+		productIds[i] = product.Id
+	}
+
+	favourited, err := models.GetFavouritedProductIds(userId.(int), productIds)
+	if err != nil {
+		log.Printf("Failed to get favourites of user %v: %v", userId, err)
+		return
+	}
+
+	for i := range products {
+		products[i].IsFavourite = favourited[products[i].Id]
+	}
+}
+
+// ListFavourites   godoc
+// @Summary         Get list favourites
+// @Description     Retrieving products favourited by the logged in user, newest first
+// @Tags            favourites
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header    string  true   "Bearer token" default(Bearer <token>)
+// @Param           page           query     int     false  "Page number"  default(1)  minimum(1)
+// @Param           limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(50)
+// @Success         200            {object}  object{success=bool,message=string,data=[]models.PublicProductResponse,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved favourites"
+// @Failure         400            {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
+// @Failure         401            {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         500            {object}  lib.ResponseError  "Internal server error while fetching favourites"
+// @Router          /favourites [get]
+func ListFavourites(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'page' must be greater than 0",
+		})
+		return
+	}
+
+	if limit < 1 || limit > 50 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'limit' must be between 1 and 50",
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	totalData, err := models.GetTotalDataUserFavourites(userId.(int))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to count total favourites in database",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPage := (totalData + limit - 1) / limit
+	if page > totalPage && totalPage > 0 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Page is out of range",
+		})
+		return
+	}
+
+	products, message, err := models.GetListUserFavourites(userId.(int), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// hateoas
+	links := utils.BuildHateoasPagination(ctx, page, limit, totalData)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    products,
+		"_links":  links,
+		"meta": gin.H{
+			"currentPage": page,
+			"perPage":     limit,
+			"totalData":   totalData,
+			"totalPages":  totalPage,
+		},
+	})
+}
+
+// AddFavourite     godoc
+// @Summary         Add favourite
+// @Description     Save a product to the favourites of the logged in user
+// @Tags            favourites
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           productId      path    int     true  "Product Id"
+// @Success         201  {object}  lib.ResponseSuccess  "Product added to favourites"
+// @Success         200  {object}  lib.ResponseSuccess  "Product is already in favourites"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Product not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while adding favourite"
+// @Router          /favourites/{productId} [post]
+func AddFavourite(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("productId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	created, message, err := models.AddFavourite(userId.(int), productId)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if message == "Product not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !created {
+		ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+			Success: true,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateFavouriteCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate favourite cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
+
+// RemoveFavourite  godoc
+// @Summary         Remove favourite
+// @Description     Remove a product from the favourites of the logged in user
+// @Tags            favourites
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           productId      path    int     true  "Product Id"
+// @Success         200  {object}  lib.ResponseSuccess  "Product removed from favourites"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Favourite not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while removing favourite"
+// @Router          /favourites/{productId} [delete]
+func RemoveFavourite(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("productId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.RemoveFavourite(userId.(int), productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateFavouriteCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate favourite cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"backend-daily-greens/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// flashSaleStatusCode maps validation messages of the flash sale model to 400
+func flashSaleStatusCode(message string) int {
+	switch message {
+	case "Name, startAt and endAt are required",
+		"endAt must be after startAt",
+		"Flash sale needs at least one product",
+		"Product is listed more than once",
+		"Discount percent must be greater than 0 and at most 100",
+		"Quantity cap must be greater than 0":
+		return http.StatusBadRequest
+	case "Product not found", "Flash sale not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// syncFlashSaleCache drops cached product prices when a campaign started or ended since the last request
+func syncFlashSaleCache() {
+	if err := models.SyncFlashSaleCache(context.Background()); err != nil {
+		log.Printf("Failed to sync flash sale cache: %v", err)
+	}
+}
+
+// ListFlashSales godoc
+// @Summary        Get list flash sales
+// @Description    Retrieving flash sale campaigns with their products, latest start first
+// @Tags           admin/flash-sales
+// @Produce        json
+// @Security       BearerAuth
+// @Param          Authorization  header    string  true   "Bearer token" default(Bearer <token>)
+// @Param          page           query     int     false  "Page number"  default(1)  minimum(1)
+// @Param          limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(50)
+// @Success        200            {object}  object{success=bool,message=string,data=[]models.FlashSale,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved flash sales"
+// @Failure        400            {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
+// @Failure        500            {object}  lib.ResponseError  "Internal server error while fetching flash sales"
+// @Router         /admin/flash-sales [get]
+func ListFlashSales(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'page' must be greater than 0",
+		})
+		return
+	}
+
+	if limit < 1 || limit > 50 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'limit' must be between 1 and 50",
+		})
+		return
+	}
+
+	totalData, err := models.GetTotalDataFlashSales()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to count total flash sales in database",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPage := (totalData + limit - 1) / limit
+	if page > totalPage && totalPage > 0 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Page is out of range",
+		})
+		return
+	}
+
+	flashSales, message, err := models.GetListFlashSales(page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// hateoas
+	links := utils.BuildHateoasPagination(ctx, page, limit, totalData)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    flashSales,
+		"_links":  links,
+		"meta": gin.H{
+			"currentPage": page,
+			"perPage":     limit,
+			"totalData":   totalData,
+			"totalPages":  totalPage,
+		},
+	})
+}
+
+// DetailFlashSale godoc
+// @Summary         Get flash sale by Id
+// @Description     Retrieving a flash sale campaign with its products and sold quantities
+// @Tags            admin/flash-sales
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           id             path    int     true  "Flash sale Id"
+// @Success         200  {object}  lib.ResponseSuccess{data=models.FlashSale}  "Successfully retrieved flash sale"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         404  {object}  lib.ResponseError  "Flash sale not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while fetching flash sale"
+// @Router          /admin/flash-sales/{id} [get]
+func DetailFlashSale(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	flashSale, message, err := models.GetFlashSaleById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    flashSale,
+	})
+}
+
+// CreateFlashSale godoc
+// @Summary         Create flash sale
+// @Description     Schedule a flash sale campaign. Listed products get the campaign discount between startAt and endAt until the optional quantity cap is sold
+// @Tags            admin/flash-sales
+// @Accept          application/json
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string                   true  "Bearer token"  default(Bearer <token>)
+// @Param           dataFlashSale  body    models.FlashSaleRequest  true  "Data flash sale"
+// @Success         201  {object}  lib.ResponseSuccess{data=models.FlashSale}  "Flash sale created successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Product not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while creating flash sale"
+// @Router          /admin/flash-sales [post]
+func CreateFlashSale(ctx *gin.Context) {
+	var bodyCreate models.FlashSaleRequest
+	err := ctx.ShouldBindJSON(&bodyCreate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	flashSaleId, message, err := models.InsertFlashSale(userId.(int), bodyCreate)
+	if err != nil {
+		ctx.JSON(flashSaleStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := models.InvalidateFlashSaleCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	flashSale, _, err := models.GetFlashSaleById(flashSaleId)
+	if err != nil {
+		log.Printf("Failed to read flash sale %d after insert: %v", flashSaleId, err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    flashSale,
+	})
+}
+
+// UpdateFlashSale godoc
+// @Summary         Update flash sale
+// @Description     Update a flash sale campaign. Sending products replaces the product list, sold quantities of products that stay are kept
+// @Tags            admin/flash-sales
+// @Accept          application/json
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string                   true  "Bearer token"  default(Bearer <token>)
+// @Param           id             path    int                      true  "Flash sale Id"
+// @Param           dataFlashSale  body    models.FlashSaleRequest  true  "Data flash sale"
+// @Success         200  {object}  lib.ResponseSuccess  "Flash sale updated successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Flash sale or product not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while updating flash sale"
+// @Router          /admin/flash-sales/{id} [patch]
+func UpdateFlashSale(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.FlashSaleRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateFlashSale(id, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(flashSaleStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateFlashSaleCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
+
+// DeleteFlashSale godoc
+// @Summary         Delete flash sale
+// @Description     Delete a flash sale campaign, its products return to their regular price
+// @Tags            admin/flash-sales
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           id             path    int     true  "Flash sale Id"
+// @Success         200  {object}  lib.ResponseSuccess  "Flash sale deleted successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         404  {object}  lib.ResponseError  "Flash sale not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while deleting flash sale"
+// @Router          /admin/flash-sales/{id} [delete]
+func DeleteFlashSale(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	isSuccess, message, err := models.DeleteFlashSale(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateFlashSaleCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
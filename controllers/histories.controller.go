@@ -22,7 +22,9 @@ import (
 // @Param          limit          query     int     false  "Number of items per page"  default(5)  minimum(1)  maximum(10)
 // @Param          date           query     string  false  "Date filter (format: YYYY-MM-DD)"  example(2024-01-15)
 // @Param          statusid       query     int     false  "Id of status"  default(1)
-// @Success        200            {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved histories list"
+// @Param          cursor         query     string  false  "Cursor from meta.nextCursor or meta.prevCursor, send empty to start keyset pagination"
+// @Param          count          query     bool    false  "Count total data in keyset pagination"  default(true)
+// @Success        200            {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int,nextCursor=string,prevCursor=string},_links=lib.HateoasLink}  "Successfully retrieved histories list"
 // @Failure        400            {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
 // @Failure        401            {object}  lib.ResponseError  "User Id not found in token"
 // @Failure        500            {object}  lib.ResponseError  "Internal server error while fetching or processing history data"
@@ -78,6 +80,48 @@ func ListHistories(ctx *gin.Context) {
 		return
 	}
 
+	// keyset pagination, opt in with ?cursor= (empty for the first page)
+	if encodedCursor, isCursor := ctx.GetQuery("cursor"); isCursor {
+		withCount, _ := strconv.ParseBool(ctx.DefaultQuery("count", "true"))
+
+		var totalData *int
+		if withCount {
+			total, err := models.GetTotalDataHistories(userId.(int), date, statusId)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Failed to count total transactions in database",
+					Error:   err.Error(),
+				})
+				return
+			}
+			totalData = &total
+		}
+
+		histories, cursors, message, err := models.GetListHistoriesCursor(userId.(int), encodedCursor, limit, date, statusId)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if message == "Invalid cursor" {
+				statusCode = http.StatusBadRequest
+			}
+			ctx.JSON(statusCode, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": message,
+			"data":    histories,
+			"_links":  utils.BuildHateoasCursorPagination(ctx, limit, cursors),
+			"meta":    cursorMeta(limit, cursors, totalData),
+		})
+		return
+	}
+
 	// get list histories
 	histories, totalData, message, err := models.GetListHistories(userId.(int), page, limit, date, statusId)
 	if err != nil {
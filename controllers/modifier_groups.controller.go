@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"backend-daily-greens/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// modifierGroupStatusCode maps validation messages of the modifier group model to 400
+func modifierGroupStatusCode(message string) int {
+	switch message {
+	case "Name is required",
+		"Modifier group needs at least one option",
+		"minSelect must be at least 0 and maxSelect at least 1 and not below minSelect",
+		"maxSelect cannot be greater than the number of options",
+		"Option name is required",
+		"Option name is listed more than once",
+		"Option price cannot be negative":
+		return http.StatusBadRequest
+	case "Modifier option not found", "Modifier group not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ListModifierGroups godoc
+// @Summary        Get list modifier groups
+// @Description    Retrieving modifier groups such as sugar level or toppings with their options, sorted by name
+// @Tags           admin/modifier-groups
+// @Produce        json
+// @Security       BearerAuth
+// @Param          Authorization  header    string  true   "Bearer token" default(Bearer <token>)
+// @Param          page           query     int     false  "Page number"  default(1)  minimum(1)
+// @Param          limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(50)
+// @Success        200            {object}  object{success=bool,message=string,data=[]models.ModifierGroup,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved modifier groups"
+// @Failure        400            {object}  lib.ResponseError  "Invalid pagination parameters or page out of range"
+// @Failure        500            {object}  lib.ResponseError  "Internal server error while fetching modifier groups"
+// @Router         /admin/modifier-groups [get]
+func ListModifierGroups(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'page' must be greater than 0",
+		})
+		return
+	}
+
+	if limit < 1 || limit > 50 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'limit' must be between 1 and 50",
+		})
+		return
+	}
+
+	totalData, err := models.GetTotalDataModifierGroups()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to count total modifier groups in database",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPage := (totalData + limit - 1) / limit
+	if page > totalPage && totalPage > 0 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Page is out of range",
+		})
+		return
+	}
+
+	modifierGroups, message, err := models.GetListModifierGroups(page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// hateoas
+	links := utils.BuildHateoasPagination(ctx, page, limit, totalData)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    modifierGroups,
+		"_links":  links,
+		"meta": gin.H{
+			"currentPage": page,
+			"perPage":     limit,
+			"totalData":   totalData,
+			"totalPages":  totalPage,
+		},
+	})
+}
+
+// DetailModifierGroup godoc
+// @Summary         Get modifier group by Id
+// @Description     Retrieving a modifier group with its options
+// @Tags            admin/modifier-groups
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           id             path    int     true  "Modifier group Id"
+// @Success         200  {object}  lib.ResponseSuccess{data=models.ModifierGroup}  "Successfully retrieved modifier group"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         404  {object}  lib.ResponseError  "Modifier group not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while fetching modifier group"
+// @Router          /admin/modifier-groups/{id} [get]
+func DetailModifierGroup(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	modifierGroup, message, err := models.GetModifierGroupById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    modifierGroup,
+	})
+}
+
+// CreateModifierGroup godoc
+// @Summary         Create modifier group
+// @Description     Create a group of options a customer picks between minSelect and maxSelect of, each option adds its price to the cart line. Attach it to products with modifierGroups on the product form
+// @Tags            admin/modifier-groups
+// @Accept          application/json
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization      header  string                       true  "Bearer token"  default(Bearer <token>)
+// @Param           dataModifierGroup  body    models.ModifierGroupRequest  true  "Data modifier group"
+// @Success         201  {object}  lib.ResponseSuccess{data=models.ModifierGroup}  "Modifier group created successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Modifier option not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while creating modifier group"
+// @Router          /admin/modifier-groups [post]
+func CreateModifierGroup(ctx *gin.Context) {
+	var bodyCreate models.ModifierGroupRequest
+	err := ctx.ShouldBindJSON(&bodyCreate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	modifierGroupId, message, err := models.InsertModifierGroup(userId.(int), bodyCreate)
+	if err != nil {
+		ctx.JSON(modifierGroupStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	modifierGroup, _, err := models.GetModifierGroupById(modifierGroupId)
+	if err != nil {
+		log.Printf("Failed to read modifier group %d after insert: %v", modifierGroupId, err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    modifierGroup,
+	})
+}
+
+// UpdateModifierGroup godoc
+// @Summary         Update modifier group
+// @Description     Update a modifier group. Sending options replaces the option list, options sent with their id are updated in place so cart lines keep them
+// @Tags            admin/modifier-groups
+// @Accept          application/json
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization      header  string                       true  "Bearer token"  default(Bearer <token>)
+// @Param           id                 path    int                          true  "Modifier group Id"
+// @Param           dataModifierGroup  body    models.ModifierGroupRequest  true  "Data modifier group"
+// @Success         200  {object}  lib.ResponseSuccess  "Modifier group updated successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure         401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure         404  {object}  lib.ResponseError  "Modifier group or option not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while updating modifier group"
+// @Router          /admin/modifier-groups/{id} [patch]
+func UpdateModifierGroup(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.ModifierGroupRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateModifierGroup(id, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(modifierGroupStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
+
+// DeleteModifierGroup godoc
+// @Summary         Delete modifier group
+// @Description     Delete a modifier group with its options, it is detached from every product
+// @Tags            admin/modifier-groups
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param           id             path    int     true  "Modifier group Id"
+// @Success         200  {object}  lib.ResponseSuccess  "Modifier group deleted successfully"
+// @Failure         400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure         404  {object}  lib.ResponseError  "Modifier group not found"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while deleting modifier group"
+// @Router          /admin/modifier-groups/{id} [delete]
+func DeleteModifierGroup(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	isSuccess, message, err := models.DeleteModifierGroup(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"backend-daily-greens/config"
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// orderSlotStatusCode maps validation messages of the order slot model to 400 and 404
+func orderSlotStatusCode(message string) int {
+	switch message {
+	case "Slot minutes must be between 5 and 240",
+		"Slot capacity must be greater than 0",
+		"Slot lead minutes cannot be negative",
+		"Slot days ahead must be between 0 and 30",
+		"Date is outside the booking window":
+		return http.StatusBadRequest
+	case "Order method not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ListOrderSlots godoc
+// @Summary      Get order slots
+// @Description  Retrieving the slots an order of the order method can be scheduled for on a date, from the store opening until it closes. Slots inside the lead time are left out, a full slot is not available
+// @Tags         fees
+// @Produce      json
+// @Param        id    path   int     true   "Order method Id"
+// @Param        date  query  string  false  "Date in the store timezone, YYYY-MM-DD, defaults to today"
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.OrderSlot}  "Successfully retrieved order slots"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id or date format, or date outside the booking window"
+// @Failure      404  {object}  lib.ResponseError  "Order method not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error"
+// @Router       /order-methods/{id}/slots [get]
+func ListOrderSlots(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	date := time.Now().In(config.StoreLocation)
+	if ctx.Query("date") != "" {
+		date, err = time.Parse("2006-01-02", ctx.Query("date"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+				Success: false,
+				Message: "Invalid date format. Expected format: YYYY-MM-DD",
+			})
+			return
+		}
+	}
+
+	slots, message, err := models.GetOrderSlots(id, date)
+	if err != nil {
+		ctx.JSON(orderSlotStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    slots,
+	})
+}
+
+// UpdateOrderMethodSlots godoc
+// @Summary      Set order method slots
+// @Description  Set the slot length, the orders each slot takes, the lead time before the first bookable slot and how many days ahead slots can be booked
+// @Tags         admin/order-methods
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                          true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int                             true  "Order method Id"
+// @Param        dataSlots      body    models.OrderMethodSlotsRequest  true  "Slot settings"
+// @Success      200  {object}  lib.ResponseSuccess  "Order method slots updated successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Order method not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while updating order method slots"
+// @Router       /admin/order-methods/{id}/slots [put]
+func UpdateOrderMethodSlots(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.OrderMethodSlotsRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateOrderMethodSlots(id, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(orderSlotStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
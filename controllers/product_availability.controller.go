@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// productAvailabilityStatusCode maps validation messages of the availability model to 400
+func productAvailabilityStatusCode(message string) int {
+	switch message {
+	case "Unpublish date must be after publish date",
+		"Available from and until must be set together",
+		"Available hours must be in HH:MM format",
+		"Available hours cannot start and end at the same time":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// UpdateProductAvailability godoc
+// @Summary                  Set availability of product
+// @Description              Replace the publish window and daily hours of a product, null clears a value. Outside them the product is left out of public listings and cannot be added to cart or checked out. Daily hours are HH:MM in the store timezone, a window ending before it starts runs past midnight
+// @Tags                     admin/products
+// @Accept                   application/json
+// @Produce                  json
+// @Security                 BearerAuth
+// @Param                    Authorization     header  string                             true  "Bearer token"  default(Bearer <token>)
+// @Param                    id                path    int                                true  "Product Id"
+// @Param                    dataAvailability  body    models.ProductAvailabilityRequest  true  "Product availability"
+// @Success                  200  {object}  lib.ResponseSuccess{data=models.AdminProductResponse}  "Product availability updated successfully"
+// @Failure                  400  {object}  lib.ResponseError  "Invalid Id format, invalid request body, publish window or hours"
+// @Failure                  401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure                  404  {object}  lib.ResponseError  "Product not found"
+// @Failure                  500  {object}  lib.ResponseError  "Internal server error while updating product availability"
+// @Router                   /admin/products/{id}/availability [put]
+func UpdateProductAvailability(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.ProductAvailabilityRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateProductAvailability(productId, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(productAvailabilityStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	// the next schedule change is looked up again with the product cache
+	if err := models.InvalidateFlashSaleCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	product, _, err := models.GetDetailProductAdmin(productId)
+	if err != nil {
+		log.Printf("Failed to read product %d after availability update: %v", productId, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    product,
+	})
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// productBundleStatusCode maps validation messages of the bundle model to 400, 404 and 409
+func productBundleStatusCode(message string) int {
+	switch message {
+	case "Quantity must be greater than 0",
+		"A bundle cannot contain itself",
+		"A bundle cannot contain another bundle",
+		"Size is not available for the component product",
+		"Variant is not available for the component product":
+		return http.StatusBadRequest
+	case "Component product not found":
+		return http.StatusNotFound
+	case "Product is a component of another bundle":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ListProductBundleItems godoc
+// @Summary                Get bundle items of product
+// @Description            Retrieving the component products of a bundle with their size, variant and quantity per bundle
+// @Tags                   admin/products
+// @Produce                json
+// @Security               BearerAuth
+// @Param                  Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param                  id             path    int     true  "Product Id"
+// @Success                200  {object}  lib.ResponseSuccess{data=[]models.BundleItem}  "Successfully retrieved bundle items"
+// @Failure                400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure                404  {object}  lib.ResponseError  "Product not found"
+// @Failure                500  {object}  lib.ResponseError  "Internal server error while fetching bundle items"
+// @Router                 /admin/products/{id}/bundle-items [get]
+func ListProductBundleItems(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Check if product exists
+	exists, err := models.CheckProductExists(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking product existence",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !exists {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Product not found",
+		})
+		return
+	}
+
+	bundleItems, message, err := models.GetProductBundleItems(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    bundleItems,
+	})
+}
+
+// UpdateProductBundleItems godoc
+// @Summary                  Set bundle items of product
+// @Description              Replace the component products of a bundle. The bundle keeps its own price, its stock is what the components allow and checkout takes stock from the components. An empty list makes it a regular product again
+// @Tags                     admin/products
+// @Accept                   application/json
+// @Produce                  json
+// @Security                 BearerAuth
+// @Param                    Authorization  header  string                     true  "Bearer token"  default(Bearer <token>)
+// @Param                    id             path    int                        true  "Product Id"
+// @Param                    dataBundle     body    models.BundleItemsRequest  true  "Bundle items"
+// @Success                  200  {object}  lib.ResponseSuccess{data=[]models.BundleItem}  "Bundle items updated successfully"
+// @Failure                  400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure                  401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure                  404  {object}  lib.ResponseError  "Product or component product not found"
+// @Failure                  409  {object}  lib.ResponseError  "Product is a component of another bundle"
+// @Failure                  500  {object}  lib.ResponseError  "Internal server error while updating bundle items"
+// @Router                   /admin/products/{id}/bundle-items [put]
+func UpdateProductBundleItems(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.BundleItemsRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.ReplaceProductBundleItems(productId, userId.(int), bodyUpdate.Items)
+	if err != nil {
+		ctx.JSON(productBundleStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	bundleItems, _, err := models.GetProductBundleItems(productId)
+	if err != nil {
+		log.Printf("Failed to read bundle items of product %d after update: %v", productId, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    bundleItems,
+	})
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"backend-daily-greens/utils"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportProducts godoc
+// @Summary      Import products from CSV
+// @Description  Upsert products by name from a CSV file. Columns: name, description, price, discountPercent, stock, isActive, categories, sizes, variants, images. Multiple values in one cell are separated by "|". A row named like a deleted product is rejected until the product is restored
+// @Tags         admin/products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header    string  true   "Bearer token"  default(Bearer <token>)
+// @Param        file           formData  file    true   "CSV file (max 2MB)"
+// @Param        dryRun         formData  bool    false  "Only validate and return the report"  default(false)
+// @Success      200  {object}  lib.ResponseSuccess{data=models.ProductImportReport}  "Dry run report or products imported successfully"
+// @Failure      400  {object}  object{success=bool,message=string,data=models.ProductImportReport}  "Invalid CSV file or rows contain errors"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while importing products"
+// @Router       /admin/products/import [post]
+func ImportProducts(ctx *gin.Context) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "CSV file is required",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if file.Size > 2<<20 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "File size must be less than 2MB",
+		})
+		return
+	}
+
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".csv" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "File has invalid extension. Only CSV is allowed",
+		})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(ctx.DefaultPostForm("dryRun", "false"))
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Failed to open CSV file",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer src.Close()
+
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Failed to parse CSV file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// validate all rows before writing anything
+	report, message, err := models.ValidateProductImport(records)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.HasPrefix(message, "CSV") {
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+	report.DryRun = dryRun
+
+	if report.Failed > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("%d of %d rows contain errors, nothing was imported", report.Failed, report.TotalRows),
+			"data":    report,
+		})
+		return
+	}
+
+	if dryRun {
+		ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+			Success: true,
+			Message: "Dry run completed, no products were changed",
+			Data:    report,
+		})
+		return
+	}
+
+	message, err = models.ImportProducts(&report, userId.(int))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    report,
+	})
+}
+
+// ExportProducts godoc
+// @Summary      Export products to CSV
+// @Description  Export the current catalog in the same CSV format accepted by import
+// @Tags         admin/products
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Success      200  {file}  file  "Products CSV file"
+// @Failure      500  {object}  lib.ResponseError  "Failed to export products"
+// @Router       /admin/products/export [get]
+func ExportProducts(ctx *gin.Context) {
+	fileName := fmt.Sprintf("products_%s.csv", time.Now().Format("20060102"))
+	writer := csv.NewWriter(ctx.Writer)
+
+	err := models.StreamProductExport(func() error {
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+		ctx.Header("Content-Type", "text/csv")
+		return writer.Write(models.ProductCsvHeader)
+	}, func(row models.ProductExportRow) error {
+		return writer.Write([]string{
+			utils.EscapeCsvFormula(row.Name),
+			utils.EscapeCsvFormula(row.Description),
+			strconv.FormatFloat(row.Price, 'f', -1, 64),
+			strconv.FormatFloat(row.DiscountPercent, 'f', -1, 64),
+			strconv.Itoa(row.Stock),
+			strconv.FormatBool(row.IsActive),
+			utils.EscapeCsvFormula(row.Categories),
+			utils.EscapeCsvFormula(row.Sizes),
+			utils.EscapeCsvFormula(row.Variants),
+			utils.EscapeCsvFormula(row.Images),
+		})
+	})
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+
+	if err != nil {
+		failExport(ctx, "Failed to export products", err)
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// productNutritionStatusCode maps validation messages of the nutrition model to 400
+func productNutritionStatusCode(message string) int {
+	switch message {
+	case "Nutrition values cannot be negative",
+		"Size is listed more than once",
+		"Size is not available for the product":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ListProductNutrition godoc
+// @Summary                Get nutrition facts of product
+// @Description            Retrieving the nutrition facts of a product as stored, the row without size is the base of the other sizes
+// @Tags                   admin/products
+// @Produce                json
+// @Security               BearerAuth
+// @Param                  Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param                  id             path    int     true  "Product Id"
+// @Success                200  {object}  lib.ResponseSuccess{data=[]models.ProductNutrition}  "Successfully retrieved nutrition facts"
+// @Failure                400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure                404  {object}  lib.ResponseError  "Product not found"
+// @Failure                500  {object}  lib.ResponseError  "Internal server error while fetching nutrition facts"
+// @Router                 /admin/products/{id}/nutrition [get]
+func ListProductNutrition(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Check if product exists
+	exists, err := models.CheckProductExists(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking product existence",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !exists {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Product not found",
+		})
+		return
+	}
+
+	nutrition, message, err := models.GetProductNutrition(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    nutrition,
+	})
+}
+
+// UpdateProductNutrition godoc
+// @Summary                  Set nutrition facts of product
+// @Description              Replace the nutrition facts of a product. A row without sizeId is the base, a row with sizeId adjusts the facts of that size and its missing values are taken from the base. Sodium and caffeine are in mg, calories in kcal and the rest in grams. An empty list removes the facts
+// @Tags                     admin/products
+// @Accept                   application/json
+// @Produce                  json
+// @Security                 BearerAuth
+// @Param                    Authorization  header  string                              true  "Bearer token"  default(Bearer <token>)
+// @Param                    id             path    int                                 true  "Product Id"
+// @Param                    dataNutrition  body    models.ProductNutritionListRequest  true  "Nutrition facts"
+// @Success                  200  {object}  lib.ResponseSuccess{data=[]models.ProductNutrition}  "Nutrition facts updated successfully"
+// @Failure                  400  {object}  lib.ResponseError  "Invalid Id format, invalid request body or size not offered"
+// @Failure                  401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure                  404  {object}  lib.ResponseError  "Product not found"
+// @Failure                  500  {object}  lib.ResponseError  "Internal server error while updating nutrition facts"
+// @Router                   /admin/products/{id}/nutrition [put]
+func UpdateProductNutrition(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.ProductNutritionListRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.ReplaceProductNutrition(productId, userId.(int), bodyUpdate.Nutrition)
+	if err != nil {
+		ctx.JSON(productNutritionStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	nutrition, _, err := models.GetProductNutrition(productId)
+	if err != nil {
+		log.Printf("Failed to read nutrition facts of product %d after update: %v", productId, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    nutrition,
+	})
+}
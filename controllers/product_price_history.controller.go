@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"backend-daily-greens/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListProductPriceHistory godoc
+// @Summary                 Get product price history
+// @Description             Retrieving every price or discount change of a product with the admin who made it, newest first
+// @Tags                    admin/products
+// @Produce                 json
+// @Security                BearerAuth
+// @Param                   Authorization  header    string  true   "Bearer token" default(Bearer <token>)
+// @Param                   id             path      int     true   "Product Id"
+// @Param                   page           query     int     false  "Page number"  default(1)  minimum(1)
+// @Param                   limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(50)
+// @Success                 200            {object}  object{success=bool,message=string,data=[]models.ProductPriceChange,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved price history"
+// @Failure                 400            {object}  lib.ResponseError  "Invalid Id format, invalid pagination parameters or page out of range"
+// @Failure                 404            {object}  lib.ResponseError  "Product not found"
+// @Failure                 500            {object}  lib.ResponseError  "Internal server error while fetching price history"
+// @Router                  /admin/products/{id}/price-history [get]
+func ListProductPriceHistory(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'page' must be greater than 0",
+		})
+		return
+	}
+
+	if limit < 1 || limit > 50 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid pagination parameter: 'limit' must be between 1 and 50",
+		})
+		return
+	}
+
+	// Check if product exists
+	exists, err := models.CheckProductExists(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking product existence",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !exists {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Product not found",
+		})
+		return
+	}
+
+	totalData, err := models.GetTotalDataProductPriceHistory(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to count total price changes in database",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	totalPage := (totalData + limit - 1) / limit
+	if page > totalPage && totalPage > 0 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Page is out of range",
+		})
+		return
+	}
+
+	history, message, err := models.GetListProductPriceHistory(productId, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// hateoas
+	links := utils.BuildHateoasPagination(ctx, page, limit, totalData)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    history,
+		"_links":  links,
+		"meta": gin.H{
+			"currentPage": page,
+			"perPage":     limit,
+			"totalData":   totalData,
+			"totalPages":  totalPage,
+		},
+	})
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// productSkuStatusCode maps validation messages of the SKU model to 400 and 409
+func productSkuStatusCode(message string) int {
+	switch message {
+	case "Stock cannot be negative",
+		"Price must be greater than 0",
+		"Size and variant combination is listed more than once",
+		"Size is not available for the product",
+		"Variant is not available for the product":
+		return http.StatusBadRequest
+	case "SKU code already exists",
+		"Bundle stock follows its components":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ListProductSkus godoc
+// @Summary                Get SKUs of product
+// @Description            Retrieving the size and variant combinations of a product with their own stock, price and availability
+// @Tags                   admin/products
+// @Produce                json
+// @Security               BearerAuth
+// @Param                  Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param                  id             path    int     true  "Product Id"
+// @Success                200  {object}  lib.ResponseSuccess{data=[]models.ProductSku}  "Successfully retrieved SKUs"
+// @Failure                400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure                404  {object}  lib.ResponseError  "Product not found"
+// @Failure                500  {object}  lib.ResponseError  "Internal server error while fetching SKUs"
+// @Router                 /admin/products/{id}/skus [get]
+func ListProductSkus(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Check if product exists
+	exists, err := models.CheckProductExists(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking product existence",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !exists {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Product not found",
+		})
+		return
+	}
+
+	skus, message, err := models.GetProductSkus(productId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    skus,
+	})
+}
+
+// UpdateProductSkus godoc
+// @Summary                  Set SKUs of product
+// @Description              Replace the size and variant combinations of a product. Each SKU has its own stock, an optional price that overrides the product price and an availability flag. The product stock becomes the sum of its available SKUs and cart and checkout take stock from the SKU. An empty list goes back to product stock
+// @Tags                     admin/products
+// @Accept                   application/json
+// @Produce                  json
+// @Security                 BearerAuth
+// @Param                    Authorization  header  string                     true  "Bearer token"  default(Bearer <token>)
+// @Param                    id             path    int                        true  "Product Id"
+// @Param                    dataSkus       body    models.ProductSkusRequest  true  "Product SKUs"
+// @Success                  200  {object}  lib.ResponseSuccess{data=[]models.ProductSku}  "SKUs updated successfully"
+// @Failure                  400  {object}  lib.ResponseError  "Invalid Id format, invalid request body or size and variant not offered"
+// @Failure                  401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure                  404  {object}  lib.ResponseError  "Product not found"
+// @Failure                  409  {object}  lib.ResponseError  "SKU code already exists or product is a bundle"
+// @Failure                  500  {object}  lib.ResponseError  "Internal server error while updating SKUs"
+// @Router                   /admin/products/{id}/skus [put]
+func UpdateProductSkus(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.ProductSkusRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.ReplaceProductSkus(productId, userId.(int), bodyUpdate.Skus)
+	if err != nil {
+		ctx.JSON(productSkuStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	skus, _, err := models.GetProductSkus(productId)
+	if err != nil {
+		log.Printf("Failed to read SKUs of product %d after update: %v", productId, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    skus,
+	})
+}
@@ -331,6 +331,8 @@ func DetailProductAdmin(ctx *gin.Context) {
 // @Param        sizeProducts       formData  string    true   "Size Id (comma-separated, e.g., 1,2,3)"
 // @Param        productCategories  formData  string    true   "Category Id (comma-separated, e.g., 1,2,3)"
 // @Param        productVariants  	formData  string    true   "Variant Id (comma-separated, e.g., 1,2,3)"
+// @Param        modifierGroups     formData  string    false  "Modifier group Id in display order (comma-separated, e.g., 1,2,3)"
+// @Param        allergens          formData  string    false  "Allergen Id (comma-separated, e.g., 1,2,3)"
 // @Success      201  {object}  lib.ResponseSuccess{data=models.AdminProductResponse}  "Product created successfully"
 // @Failure      400  {object}  lib.ResponseError  "Invalid request body"
 // @Failure      409  {object}  lib.ResponseError  "Product name already exists"
@@ -589,6 +591,64 @@ func CreateProduct(ctx *gin.Context) {
 		}
 	}
 
+	// insert modifier groups
+	if strings.TrimSpace(bodyCreate.ModifierGroups) != "" {
+		modifierGroups := strings.Split(bodyCreate.ModifierGroups, ",")
+		var modifierGroupIds []int
+		for _, modifierGroupIdStr := range modifierGroups {
+			modifierGroupIdStr = strings.TrimSpace(modifierGroupIdStr)
+			if modifierGroupIdStr == "" {
+				continue
+			}
+			modifierGroupId, err := strconv.Atoi(modifierGroupIdStr)
+			if err != nil {
+				continue
+			}
+			modifierGroupIds = append(modifierGroupIds, modifierGroupId)
+		}
+
+		if len(modifierGroupIds) > 0 {
+			err = models.InsertProductModifierGroups(tx, bodyCreate.Id, modifierGroupIds, userIdFromToken.(int))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Internal server error while inserting product modifier groups",
+					Error:   err.Error(),
+				})
+				return
+			}
+		}
+	}
+
+	// insert allergens
+	if strings.TrimSpace(bodyCreate.Allergens) != "" {
+		allergens := strings.Split(bodyCreate.Allergens, ",")
+		var allergenIds []int
+		for _, allergenIdStr := range allergens {
+			allergenIdStr = strings.TrimSpace(allergenIdStr)
+			if allergenIdStr == "" {
+				continue
+			}
+			allergenId, err := strconv.Atoi(allergenIdStr)
+			if err != nil {
+				continue
+			}
+			allergenIds = append(allergenIds, allergenId)
+		}
+
+		if len(allergenIds) > 0 {
+			err = models.InsertProductAllergens(tx, bodyCreate.Id, allergenIds, userIdFromToken.(int))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Internal server error while inserting product allergens",
+					Error:   err.Error(),
+				})
+				return
+			}
+		}
+	}
+
 	// commit transaction
 	err = tx.Commit(context.Background())
 	if err != nil {
@@ -632,6 +692,8 @@ func CreateProduct(ctx *gin.Context) {
 // @Param        sizeProducts       formData  string    false  "Size Id (comma-separated, e.g., 1,2,3)"
 // @Param        productCategories  formData  string    false  "Category Id (comma-separated, e.g., 1,2,3)"
 // @Param        productVariants    formData  string    false  "Variant Id (comma-separated, e.g., 1,2,3)"
+// @Param        modifierGroups     formData  string    false  "Modifier group Id in display order (comma-separated, e.g., 1,2,3)"
+// @Param        allergens          formData  string    false  "Allergen Id (comma-separated, e.g., 1,2,3)"
 // @Success      200  {object}  lib.ResponseSuccess  "Product updated successfully"
 // @Failure      400  {object}  lib.ResponseError   "Invalid Id format or invalid request body"
 // @Failure      404  {object}  lib.ResponseError   "Product not found"
@@ -929,6 +991,84 @@ func UpdateProduct(ctx *gin.Context) {
 		}
 	}
 
+	// update modifier groups
+	if strings.TrimSpace(bodyUpdate.ModifierGroups) != "" {
+		err = models.DeleteProductModifierGroups(tx, id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: "Internal server error while deleting old product modifier groups",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		modifierGroups := strings.Split(bodyUpdate.ModifierGroups, ",")
+		var modifierGroupIds []int
+		for _, modifierGroupIdStr := range modifierGroups {
+			modifierGroupIdStr = strings.TrimSpace(modifierGroupIdStr)
+			if modifierGroupIdStr == "" {
+				continue
+			}
+			modifierGroupId, err := strconv.Atoi(modifierGroupIdStr)
+			if err != nil {
+				continue
+			}
+			modifierGroupIds = append(modifierGroupIds, modifierGroupId)
+		}
+
+		if len(modifierGroupIds) > 0 {
+			err = models.InsertProductModifierGroups(tx, id, modifierGroupIds, userIdFromToken.(int))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Internal server error while inserting product modifier group",
+					Error:   err.Error(),
+				})
+				return
+			}
+		}
+	}
+
+	// update allergens
+	if strings.TrimSpace(bodyUpdate.Allergens) != "" {
+		err = models.DeleteProductAllergens(tx, id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: "Internal server error while deleting old product allergens",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		allergens := strings.Split(bodyUpdate.Allergens, ",")
+		var allergenIds []int
+		for _, allergenIdStr := range allergens {
+			allergenIdStr = strings.TrimSpace(allergenIdStr)
+			if allergenIdStr == "" {
+				continue
+			}
+			allergenId, err := strconv.Atoi(allergenIdStr)
+			if err != nil {
+				continue
+			}
+			allergenIds = append(allergenIds, allergenId)
+		}
+
+		if len(allergenIds) > 0 {
+			err = models.InsertProductAllergens(tx, id, allergenIds, userIdFromToken.(int))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Internal server error while inserting product allergens",
+					Error:   err.Error(),
+				})
+				return
+			}
+		}
+	}
+
 	// commit transaction
 	err = tx.Commit(context.Background())
 	if err != nil {
@@ -952,7 +1092,7 @@ func UpdateProduct(ctx *gin.Context) {
 
 // DeleteProduct    godoc
 // @Summary      Delete product
-// @Description  Delete product by Id
+// @Description  Soft delete product by Id. The product is hidden from the shop and removed from carts, admins can still see and restore it
 // @Tags         admin/products
 // @Accept       x-www-form-urlencoded
 // @Produce      json
@@ -961,6 +1101,7 @@ func UpdateProduct(ctx *gin.Context) {
 // @Param        id             path    int     true  "Product Id"
 // @Success      200  {object}  lib.ResponseSuccess  "Product deleted successfully"
 // @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
 // @Failure      404  {object}  lib.ResponseError  "Product not found"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while deleting product data."
 // @Router       /admin/products/{id} [delete]
@@ -975,8 +1116,18 @@ func DeleteProduct(ctx *gin.Context) {
 		return
 	}
 
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
 	// delete data product by id
-	isSuccess, message, err := models.DeleteDataProduct(id)
+	isSuccess, message, err := models.DeleteDataProduct(id, userIdFromToken.(int))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: isSuccess,
@@ -1005,9 +1156,126 @@ func DeleteProduct(ctx *gin.Context) {
 	})
 }
 
+// RestoreProduct   godoc
+// @Summary          Restore product
+// @Description      Restore a soft deleted product
+// @Tags             admin/products
+// @Produce          json
+// @Security         BearerAuth
+// @Param            Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param            id             path    int     true  "Product Id"
+// @Success          200  {object}  lib.ResponseSuccess  "Product restored successfully"
+// @Failure          400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure          401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure          404  {object}  lib.ResponseError  "Deleted product not found"
+// @Failure          500  {object}  lib.ResponseError  "Internal server error while restoring product data"
+// @Router           /admin/products/{id}/restore [post]
+func RestoreProduct(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.RestoreDataProduct(id, userIdFromToken.(int))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
+
+// purgeOlderThanDays reads how long rows must have been soft deleted before a purge removes them
+func purgeOlderThanDays(ctx *gin.Context) (int, bool) {
+	olderThanDays, err := strconv.Atoi(ctx.DefaultQuery("olderThanDays", "0"))
+	if err != nil || olderThanDays < 0 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid olderThanDays: must be 0 or greater",
+		})
+		return 0, false
+	}
+	return olderThanDays, true
+}
+
+// PurgeProducts    godoc
+// @Summary          Purge deleted products
+// @Description      Permanently remove soft deleted products and their images. Products still referenced by orders are skipped
+// @Tags             admin/products
+// @Produce          json
+// @Security         BearerAuth
+// @Param            Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param            olderThanDays  query   int     false  "Only purge products deleted at least this many days ago"  default(0)  minimum(0)
+// @Success          200  {object}  lib.ResponseSuccess{data=models.PurgeResult}  "Deleted products purged successfully"
+// @Failure          400  {object}  lib.ResponseError  "Invalid olderThanDays"
+// @Failure          500  {object}  lib.ResponseError  "Internal server error while purging products"
+// @Router           /admin/products/purge [post]
+func PurgeProducts(ctx *gin.Context) {
+	olderThanDays, ok := purgeOlderThanDays(ctx)
+	if !ok {
+		return
+	}
+
+	result, message, err := models.PurgeDeletedProducts(olderThanDays)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if len(result.Purged) > 0 {
+		if err := models.InvalidateProductCache(context.Background()); err != nil {
+			fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    result,
+	})
+}
+
 // ListFavouriteProduct godoc
-// @Summary             Get all favourite products
-// @Description         Retrieving all favourite products
+// @Summary             Get most favourited products
+// @Description         Retrieving products favourited by the most customers, isFavourite is set for the logged in user
 // @Tags                products
 // @Produce             json
 // @Param               limit          query     int     false  "Limit of list favourite products"  default(4)  minimum(1)  maximum(20)
@@ -1034,6 +1302,8 @@ func ListFavouriteProducts(ctx *gin.Context) {
 		return
 	}
 
+	syncFlashSaleCache()
+
 	var err error
 	var products []models.PublicProductResponse
 
@@ -1095,6 +1365,8 @@ func ListFavouriteProducts(ctx *gin.Context) {
 		}
 	}
 
+	markFavourites(ctx, products)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Success get all product",
@@ -1108,16 +1380,25 @@ func ListFavouriteProducts(ctx *gin.Context) {
 // @Description  	   Retrieving list products with filter
 // @Tags         	   products
 // @Produce      	   json
-// @Param        	   q   		    query     string   false  "Search name product"
-// @Param        	   cat   		query     []string false  "Category of product"
+// @Param        	   q   		    query     string   false  "Search name, description or category of product, sorted by relevance unless sort is set"
+// @Param        	   cat   		query     []string false  "Category name or slug of product, subcategories included"
 // @Param        	   sort[name]   query     string   false  "Sort by name" Enums(asc, desc)
 // @Param        	   sort[price]  query     string   false  "Sort by price" Enums(asc, desc)
+// @Param        	   size   		query     []string false  "Size of product"
+// @Param        	   variant   	query     []string false  "Variant of product"
 // @Param        	   maxPrice   	query     number   false  "Maximum price product"
 // @Param        	   minPrice   	query     number   false  "Minimum price product"
+// @Param        	   minRating   	query     number   false  "Minimum rating product"  minimum(0)  maximum(5)
+// @Param        	   flashSale   	query     bool     false  "Only flash sale products"
+// @Param        	   inStock   	query     bool     false  "Only products in stock"
+// @Param        	   excludeAllergens query   string   false  "Allergen names or slugs to leave out (comma-separated, e.g., dairy,nuts)"
+// @Param        	   facets   	query     bool     false  "Include facet counts under the current filters"
+// @Param        	   cursor   	query     string   false  "Cursor from meta.nextCursor or meta.prevCursor, send empty to start keyset pagination"
+// @Param        	   count   		query     bool     false  "Count total data in keyset pagination"  default(true)
 // @Param        	   page   		query     int      false  "Page number"  default(1)  minimum(1)
 // @Param        	   limit        query     int      false  "Number of items per page"  default(10)  minimum(1)  maximum(50)
-// @Success      	   200          {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved product list"
-// @Failure      	   400          {object}  lib.ResponseError  "Invalid pagination parameters or page out of range."
+// @Success      	   200          {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int,nextCursor=string,prevCursor=string},_links=lib.HateoasLink,facets=models.ProductFacets}  "Successfully retrieved product list"
+// @Failure      	   400          {object}  lib.ResponseError  "Invalid pagination parameters, page out of range or unknown allergen."
 // @Failure      	   500          {object}  lib.ResponseError  "Internal server error while fetching or processing product data."
 // @Router       	   /products [get]
 func ListProductsPublic(ctx *gin.Context) {
@@ -1146,6 +1427,57 @@ func ListProductsPublic(ctx *gin.Context) {
 
 	maxPrice, _ := strconv.ParseFloat(ctx.Query("maxprice"), 64)
 	minPrice, _ := strconv.ParseFloat(ctx.Query("minprice"), 64)
+	flashSale, _ := strconv.ParseBool(ctx.DefaultQuery("flashSale", "false"))
+	inStock, _ := strconv.ParseBool(ctx.DefaultQuery("inStock", "false"))
+	withFacets, _ := strconv.ParseBool(ctx.DefaultQuery("facets", "false"))
+	minRating, err := strconv.ParseFloat(ctx.DefaultQuery("minRating", "0"), 64)
+	if err != nil || minRating < 0 || minRating > 5 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid minRating: must be between 0 and 5",
+		})
+		return
+	}
+
+	var excludeAllergens []string
+	for _, allergen := range strings.Split(ctx.Query("excludeAllergens"), ",") {
+		allergen = strings.TrimSpace(allergen)
+		if allergen != "" {
+			excludeAllergens = append(excludeAllergens, allergen)
+		}
+	}
+	if len(excludeAllergens) > 0 {
+		unknownAllergens, message, err := models.GetUnknownAllergens(excludeAllergens)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+		if len(unknownAllergens) > 0 {
+			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+				Success: false,
+				Message: "Unknown allergen: " + strings.Join(unknownAllergens, ", "),
+			})
+			return
+		}
+	}
+
+	filter := models.PublicProductFilter{
+		Q:                search,
+		Categories:       cat,
+		Sizes:            ctx.QueryArray("size"),
+		Variants:         ctx.QueryArray("variant"),
+		MinPrice:         minPrice,
+		MaxPrice:         maxPrice,
+		MinRating:        minRating,
+		FlashSale:        flashSale,
+		InStock:          inStock,
+		ExcludeAllergens: excludeAllergens,
+	}
+
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
@@ -1173,16 +1505,85 @@ func ListProductsPublic(ctx *gin.Context) {
 		return
 	}
 
-	var totalData int
-	var err error
+	syncFlashSaleCache()
 
 	// Ubah cache key untuk include semua filter
-	totalCacheKey := fmt.Sprintf("productsPublic:total:q:%s:cat:%v:maxPrice:%f:minPrice:%f",
-		search, cat, maxPrice, minPrice)
+	totalCacheKey := fmt.Sprintf("productsPublic:total:%+v", filter)
+
+	// keyset pagination, opt in with ?cursor= (empty for the first page)
+	if encodedCursor, isCursor := ctx.GetQuery("cursor"); isCursor {
+		withCount, _ := strconv.ParseBool(ctx.DefaultQuery("count", "true"))
+
+		var totalData *int
+		if withCount {
+			total, _, err := getWithCache(totalCacheKey, func() (int, string, error) {
+				total, err := models.TotalDataProductsPublic(filter)
+				return total, "", err
+			})
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Failed to count total products in database",
+					Error:   err.Error(),
+				})
+				return
+			}
+			totalData = &total
+		}
+
+		type cursorPage struct {
+			Products []models.PublicProductResponse `json:"products"`
+			Cursors  utils.CursorPage               `json:"cursors"`
+		}
+		result, message, err := getWithCache(ctx.Request.URL.RequestURI(), func() (cursorPage, string, error) {
+			products, cursors, message, err := models.GetListProductsPublicCursor(filter, sortField, limit, encodedCursor)
+			return cursorPage{Products: products, Cursors: cursors}, message, err
+		})
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if message == "Invalid cursor" {
+				statusCode = http.StatusBadRequest
+			}
+			ctx.JSON(statusCode, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		markFavourites(ctx, result.Products)
+
+		response := gin.H{
+			"success": true,
+			"message": "Success get all product",
+			"data":    result.Products,
+			"_links":  utils.BuildHateoasCursorPagination(ctx, limit, result.Cursors),
+			"meta":    cursorMeta(limit, result.Cursors, totalData),
+		}
+
+		if withFacets {
+			facets, message, err := getProductFacetsWithCache(filter)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: message,
+					Error:   err.Error(),
+				})
+				return
+			}
+			response["facets"] = facets
+		}
+
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	var totalData int
 	cacheTotalDataProducts, err := config.Rdb.Get(context.Background(), totalCacheKey).Result()
 	if err == redis.Nil || cacheTotalDataProducts == "" {
 		// cache miss - ambil dari DB dengan filter lengkap
-		totalData, err = models.TotalDataProductsPublic(search, cat, maxPrice, minPrice)
+		totalData, err = models.TotalDataProductsPublic(filter)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 				Success: false,
@@ -1201,7 +1602,7 @@ func ListProductsPublic(ctx *gin.Context) {
 		// redis error - fallback ke DB
 		log.Printf("Redis error for key %s: %v", totalCacheKey, err)
 
-		totalData, err = models.TotalDataProductsPublic(search, cat, maxPrice, minPrice)
+		totalData, err = models.TotalDataProductsPublic(filter)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 				Success: false,
@@ -1218,7 +1619,7 @@ func ListProductsPublic(ctx *gin.Context) {
 			log.Printf("Failed to unmarshal total cache for key %s: %v", totalCacheKey, err)
 			config.Rdb.Del(context.Background(), totalCacheKey)
 
-			totalData, err = models.TotalDataProductsPublic(search, cat, maxPrice, minPrice)
+			totalData, err = models.TotalDataProductsPublic(filter)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 					Success: false,
@@ -1244,7 +1645,7 @@ func ListProductsPublic(ctx *gin.Context) {
 	cacheListAllProducts, err := config.Rdb.Get(context.Background(), listCacheKey).Result()
 	if err == redis.Nil || cacheListAllProducts == "" {
 		// cache miss - ambil dari DB
-		products, err = models.GetListProductsPublic(search, cat, sortField, maxPrice, minPrice, limit, page)
+		products, err = models.GetListProductsPublic(filter, sortField, limit, page)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 				Success: false,
@@ -1268,7 +1669,7 @@ func ListProductsPublic(ctx *gin.Context) {
 		// redis error - fallback ke DB
 		log.Printf("Redis error for key %s: %v", listCacheKey, err)
 
-		products, err = models.GetListProductsPublic(search, cat, sortField, maxPrice, minPrice, limit, page)
+		products, err = models.GetListProductsPublic(filter, sortField, limit, page)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 				Success: false,
@@ -1285,7 +1686,7 @@ func ListProductsPublic(ctx *gin.Context) {
 			log.Printf("Failed to unmarshal list cache for key %s: %v", listCacheKey, err)
 			config.Rdb.Del(context.Background(), listCacheKey)
 
-			products, err = models.GetListProductsPublic(search, cat, sortField, maxPrice, minPrice, limit, page)
+			products, err = models.GetListProductsPublic(filter, sortField, limit, page)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 					Success: false,
@@ -1297,10 +1698,12 @@ func ListProductsPublic(ctx *gin.Context) {
 		}
 	}
 
+	markFavourites(ctx, products)
+
 	// hateoas
 	links := utils.BuildHateoasPagination(ctx, page, limit, totalData)
 
-	ctx.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success": true,
 		"message": "Success get all product",
 		"data":    products,
@@ -1311,32 +1714,76 @@ func ListProductsPublic(ctx *gin.Context) {
 			"totalData":   totalData,
 			"totalPages":  totalPage,
 		},
+	}
+
+	if withFacets {
+		facets, message, err := getProductFacetsWithCache(filter)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+		response["facets"] = facets
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// getProductFacetsWithCache caches facets per filter, they do not depend on page, cursor or sort
+func getProductFacetsWithCache(filter models.PublicProductFilter) (models.ProductFacets, string, error) {
+	facetsCacheKey := fmt.Sprintf("productsPublic:facets:%+v", filter)
+	return getWithCache(facetsCacheKey, func() (models.ProductFacets, string, error) {
+		return models.GetProductFacets(filter)
 	})
 }
 
 // DetailProduct   godoc
-// @Summary        Get product by Id
-// @Description    Retrieving product data based on Id for public
+// @Summary        Get product by Id or slug
+// @Description    Retrieving product data based on Id or slug for public. An earlier slug of a renamed product redirects to the current one
 // @Tags           products
 // @Accept 		   x-www-form-urlencoded
 // @Produce        json
-// @Param          id   			path    int     true  "product Id"
+// @Param          idOrSlug   		path    string  true  "product Id or slug"
 // @Success        200  {object}  lib.ResponseSuccess{data=models.PublicProductDetailResponse}  "Successfully retrieved product"
-// @Failure        400  {object}  lib.ResponseError  "Invalid Id format"
+// @Success        301  "Moved permanently to the current slug"
 // @Failure        404  {object}  lib.ResponseError  "Product not found"
 // @Failure        500  {object}  lib.ResponseError  "Internal server error while fetching products from database"
-// @Router         /products/{id} [get]
+// @Router         /products/{idOrSlug} [get]
 func DetailProductPublic(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Param("id"))
+	idOrSlug := ctx.Param("idOrSlug")
+	id, err := strconv.Atoi(idOrSlug)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
-			Success: false,
-			Message: "Invalid Id format",
-			Error:   err.Error(),
-		})
-		return
+		productId, slug, message, err := models.GetProductIdBySlug(idOrSlug)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if message == "Product not found" {
+				statusCode = http.StatusNotFound
+			}
+			ctx.JSON(statusCode, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		// old slug of a renamed product, relative location keeps any path prefix
+		if slug != idOrSlug {
+			location := slug
+			if ctx.Request.URL.RawQuery != "" {
+				location += "?" + ctx.Request.URL.RawQuery
+			}
+			ctx.Redirect(http.StatusMovedPermanently, location)
+			return
+		}
+		id = productId
 	}
 
+	syncFlashSaleCache()
+
 	// redis for detail product
 	var product models.PublicProductDetailResponse
 	var message string
@@ -1410,9 +1857,62 @@ func DetailProductPublic(ctx *gin.Context) {
 		}
 	}
 
+	// favourite flags are per user, set them after the shared cache
+	favourites := append([]models.PublicProductResponse{{Id: product.Id}}, product.Recomendations...)
+	markFavourites(ctx, favourites)
+	product.IsFavourite = favourites[0].IsFavourite
+	product.Recomendations = favourites[1:]
+
 	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
 		Success: true,
 		Message: "Success get product",
 		Data:    product,
 	})
 }
+
+// SuggestProducts godoc
+// @Summary        Get search suggestions
+// @Description    Suggest product and category names by prefix, falling back to similar names for misspellings
+// @Tags           products
+// @Produce        json
+// @Param          q      query  string  true   "Search text"
+// @Param          limit  query  int     false  "Number of suggestions"  default(8)  minimum(1)  maximum(20)
+// @Success        200  {object}  lib.ResponseSuccess{data=[]models.ProductSuggestion}  "Successfully retrieved suggestions"
+// @Failure        400  {object}  lib.ResponseError  "Invalid query parameters"
+// @Failure        500  {object}  lib.ResponseError  "Internal server error while reading suggestion index"
+// @Router         /products/suggest [get]
+func SuggestProducts(ctx *gin.Context) {
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Query q is required",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "8"))
+	if err != nil || limit < 1 || limit > 20 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid limit: must be between 1 and 20",
+		})
+		return
+	}
+
+	suggestions, message, err := models.GetProductSuggestions(q, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    suggestions,
+	})
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listRankedProducts(ctx *gin.Context, kind string) {
+	window := ctx.DefaultQuery("window", "7d")
+	if _, ok := models.RankingWindows[window]; !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid window. Allowed values: 24h, 7d, 30d",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid limit: must be between 1 and 50",
+		})
+		return
+	}
+
+	ranked, message, err := models.GetRankedProducts(kind, window, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	products := make([]models.PublicProductResponse, len(ranked))
+	for i := range ranked {
+		products[i] = ranked[i].PublicProductResponse
+	}
+	markFavourites(ctx, products)
+	for i := range ranked {
+		ranked[i].IsFavourite = products[i].IsFavourite
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    ranked,
+	})
+}
+
+// TrendingProducts godoc
+// @Summary          Get trending products
+// @Description      Products ranked by units sold in the window by finished orders, recent orders weigh more (half life 6h for 24h, 48h for 7d, 168h for 30d)
+// @Tags             products
+// @Produce          json
+// @Param            window  query  string  false  "Rolling window"  Enums(24h, 7d, 30d)  default(7d)
+// @Param            limit   query  int     false  "Number of products"  default(10)  minimum(1)  maximum(50)
+// @Success          200  {object}  lib.ResponseSuccess{data=[]models.RankedProduct}  "Successfully retrieved trending products"
+// @Failure          400  {object}  lib.ResponseError  "Invalid query parameters"
+// @Failure          500  {object}  lib.ResponseError  "Internal server error while computing rankings"
+// @Router           /products/trending [get]
+func TrendingProducts(ctx *gin.Context) {
+	listRankedProducts(ctx, models.RankingTrending)
+}
+
+// BestSellerProducts godoc
+// @Summary            Get best-selling products
+// @Description        Products ranked by units sold in the window by finished orders, net of refunds
+// @Tags               products
+// @Produce            json
+// @Param              window  query  string  false  "Rolling window"  Enums(24h, 7d, 30d)  default(7d)
+// @Param              limit   query  int     false  "Number of products"  default(10)  minimum(1)  maximum(50)
+// @Success            200  {object}  lib.ResponseSuccess{data=[]models.RankedProduct}  "Successfully retrieved best-selling products"
+// @Failure            400  {object}  lib.ResponseError  "Invalid query parameters"
+// @Failure            500  {object}  lib.ResponseError  "Internal server error while computing rankings"
+// @Router             /products/best-sellers [get]
+func BestSellerProducts(ctx *gin.Context) {
+	listRankedProducts(ctx, models.RankingBestSellers)
+}
+
+// RebuildRankings godoc
+// @Summary         Rebuild sales rankings
+// @Description     Reload the hourly sales buckets behind trending and best sellers from the finished orders of the last 30 days
+// @Tags            admin/products
+// @Produce         json
+// @Security        BearerAuth
+// @Param           Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Success         200  {object}  lib.ResponseSuccess{data=object{buckets=int}}  "Sales rankings rebuilt successfully"
+// @Failure         500  {object}  lib.ResponseError  "Internal server error while rebuilding rankings"
+// @Router          /admin/products/rankings/rebuild [post]
+func RebuildRankings(ctx *gin.Context) {
+	buckets, message, err := models.RebuildSalesRankings(context.Background())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    gin.H{"buckets": buckets},
+	})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RebuildRecommendations godoc
+// @Summary                Rebuild product recommendations
+// @Description            Recompute "frequently bought together" scores from transaction items. Also runs periodically when RECOMMENDATION_JOB_INTERVAL is set
+// @Tags                   admin/products
+// @Produce                json
+// @Security               BearerAuth
+// @Param                  Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Success                200  {object}  lib.ResponseSuccess{data=models.RecommendationJobResult}  "Product recommendations rebuilt successfully"
+// @Failure                401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure                500  {object}  lib.ResponseError  "Internal server error while computing recommendations"
+// @Router                 /admin/products/recommendations/rebuild [post]
+func RebuildRecommendations(ctx *gin.Context) {
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	id := userId.(int)
+	result, message, err := models.RebuildProductRecommendations(&id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// product detail caches hold the old recommendations
+	if err := models.InvalidateProductCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    result,
+	})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRefunds   godoc
+// @Summary      Get list refunds of transaction
+// @Description  Retrieving all refunds recorded for a transaction including refunded items
+// @Tags         admin/transactions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Transaction Id"
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.Refund}  "Successfully retrieved refunds"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      404  {object}  lib.ResponseError  "Transaction not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching refunds from database"
+// @Router       /admin/transactions/{id}/refunds [get]
+func ListRefunds(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// check transaction exists
+	isExists, err := models.CheckTransactionExists(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while checking transaction existence",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isExists {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Transaction not found",
+		})
+		return
+	}
+
+	refunds, message, err := models.GetListRefunds(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    refunds,
+	})
+}
+
+// CreateRefund  godoc
+// @Summary      Refund transaction
+// @Description  Refund a whole transaction (empty items) or specific transaction items, with optional restock
+// @Tags         admin/transactions
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int                   true  "Transaction Id"
+// @Param        dataRefund     body    models.RefundRequest  true  "Data refund"
+// @Success      201  {object}  lib.ResponseSuccess{data=models.Refund}  "Refund created successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body or refund exceeds refundable quantity"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Transaction not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while creating refund"
+// @Router       /admin/transactions/{id}/refunds [post]
+func CreateRefund(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyRefund models.RefundRequest
+	err = ctx.ShouldBindJSON(&bodyRefund)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	refund, message, err := models.MakeRefund(id, userId.(int), bodyRefund)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch message {
+		case "Transaction not found":
+			statusCode = http.StatusNotFound
+		case "Transaction has already been fully refunded",
+			"Transaction item does not belong to this transaction",
+			"Transaction item is listed more than once",
+			"Refund amount must be greater than 0",
+			"Refund amount exceeds refundable quantity":
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// stock changed, product cache is stale
+	if bodyRefund.Restock {
+		if err := models.InvalidateProductCache(context.Background()); err != nil {
+			fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+		}
+	}
+
+	if err := models.InvalidateReportCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate report cache: %v\n", err)
+	}
+
+	// refunded units no longer count towards trending and best sellers
+	if err := models.InvalidateSalesRankings(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate sales rankings: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    refund,
+	})
+}
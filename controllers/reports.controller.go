@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"backend-daily-greens/config"
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// parseReportFilter reads startDate, endDate, groupBy and limit from query.
+// Date range defaults to the last 30 days in the store timezone, endDate is inclusive.
+func parseReportFilter(ctx *gin.Context) (models.ReportFilter, string, bool) {
+	filter := models.ReportFilter{}
+	now := time.Now().In(config.StoreLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.StoreLocation)
+
+	endDate := today
+	if ctx.Query("endDate") != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", ctx.Query("endDate"), config.StoreLocation)
+		if err != nil {
+			return filter, "Invalid endDate format. Expected format: YYYY-MM-DD", false
+		}
+		endDate = parsed
+	}
+
+	startDate := endDate.AddDate(0, 0, -29)
+	if ctx.Query("startDate") != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", ctx.Query("startDate"), config.StoreLocation)
+		if err != nil {
+			return filter, "Invalid startDate format. Expected format: YYYY-MM-DD", false
+		}
+		startDate = parsed
+	}
+
+	if startDate.After(endDate) {
+		return filter, "startDate cannot be after endDate", false
+	}
+
+	groupBy := ctx.DefaultQuery("groupBy", "day")
+	if groupBy != "day" && groupBy != "week" && groupBy != "month" {
+		return filter, "Invalid groupBy. Allowed values: day, week, month", false
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		return filter, "Invalid limit: must be between 1 and 50", false
+	}
+
+	filter.StartDate = startDate
+	filter.EndDate = endDate.AddDate(0, 0, 1)
+	filter.GroupBy = groupBy
+	filter.Limit = limit
+	return filter, "", true
+}
+
+// getWithCache serves a value from redis under cacheKey, falling back to fetch on miss or redis error.
+// The message is empty on a cache hit.
+func getWithCache[T any](cacheKey string, fetch func() (T, string, error)) (T, string, error) {
+	var value T
+
+	cache, err := config.Rdb.Get(context.Background(), cacheKey).Result()
+	if err == nil && cache != "" {
+		err = json.Unmarshal([]byte(cache), &value)
+		if err == nil {
+			return value, "", nil
+		}
+		log.Printf("Failed to unmarshal cache for key %s: %v", cacheKey, err)
+		config.Rdb.Del(context.Background(), cacheKey)
+	} else if err != nil && err != redis.Nil {
+		log.Printf("Redis error for key %s: %v", cacheKey, err)
+	}
+
+	value, message, err := fetch()
+	if err != nil {
+		return value, message, err
+	}
+
+	valueStr, marshalErr := json.Marshal(value)
+	if marshalErr != nil {
+		log.Printf("Failed to marshal cache value: %v", marshalErr)
+	} else {
+		cacheErr := config.Rdb.Set(context.Background(), cacheKey, valueStr, 15*time.Minute).Err()
+		if cacheErr != nil {
+			log.Printf("Failed to set cache for key %s: %v", cacheKey, cacheErr)
+		}
+	}
+
+	return value, message, nil
+}
+
+// getReportWithCache caches a report under the request uri
+func getReportWithCache[T any](ctx *gin.Context, fetch func() (T, string, error)) (T, string, error) {
+	report, message, err := getWithCache(ctx.Request.URL.RequestURI(), fetch)
+	if err == nil && message == "" {
+		message = "Success get report"
+	}
+	return report, message, err
+}
+
+// SalesSummary  godoc
+// @Summary      Get sales summary
+// @Description  Retrieving revenue, order count, average order value and refunds in a date range
+// @Tags         admin/reports
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        startDate      query   string  false  "Start date (format: YYYY-MM-DD), default 30 days before endDate"
+// @Param        endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD), default today"
+// @Success      200  {object}  lib.ResponseSuccess{data=models.SalesSummary}  "Successfully retrieved sales summary"
+// @Failure      400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching report"
+// @Router       /admin/reports/summary [get]
+func SalesSummary(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	summary, message, err := getReportWithCache(ctx, func() (models.SalesSummary, string, error) {
+		return models.GetSalesSummary(filter)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    summary,
+	})
+}
+
+// SalesByPeriod godoc
+// @Summary      Get sales per period
+// @Description  Retrieving revenue, refunds, net revenue and order count grouped by day, week or month
+// @Tags         admin/reports
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        startDate      query   string  false  "Start date (format: YYYY-MM-DD)"
+// @Param        endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD)"
+// @Param        groupBy        query   string  false  "Group by period"  Enums(day, week, month)  default(day)
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.SalesPeriod}  "Successfully retrieved sales per period"
+// @Failure      400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching report"
+// @Router       /admin/reports/revenue [get]
+func SalesByPeriod(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	periods, message, err := getReportWithCache(ctx, func() ([]models.SalesPeriod, string, error) {
+		return models.GetSalesByPeriod(filter)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    periods,
+	})
+}
+
+// TopProducts   godoc
+// @Summary      Get top products
+// @Description  Retrieving best selling products from transaction items, revenue is gross with the refunded items taken off in netRevenue
+// @Tags         admin/reports
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        startDate      query   string  false  "Start date (format: YYYY-MM-DD)"
+// @Param        endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD)"
+// @Param        limit          query   int     false  "Number of products"  default(10)  minimum(1)  maximum(50)
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.TopProduct}  "Successfully retrieved top products"
+// @Failure      400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching report"
+// @Router       /admin/reports/top-products [get]
+func TopProducts(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	products, message, err := getReportWithCache(ctx, func() ([]models.TopProduct, string, error) {
+		return models.GetTopProducts(filter)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    products,
+	})
+}
+
+// TopCategories godoc
+// @Summary      Get top categories
+// @Description  Retrieving best selling categories from transaction items, revenue is gross with the refunded items taken off in netRevenue
+// @Tags         admin/reports
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        startDate      query   string  false  "Start date (format: YYYY-MM-DD)"
+// @Param        endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD)"
+// @Param        limit          query   int     false  "Number of categories"  default(10)  minimum(1)  maximum(50)
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.TopCategory}  "Successfully retrieved top categories"
+// @Failure      400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching report"
+// @Router       /admin/reports/top-categories [get]
+func TopCategories(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	categories, message, err := getReportWithCache(ctx, func() ([]models.TopCategory, string, error) {
+		return models.GetTopCategories(filter)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    categories,
+	})
+}
+
+// SalesBreakdown godoc
+// @Summary       Get sales breakdown
+// @Description   Retrieving order count, revenue, refunds and net revenue per order method and payment method
+// @Tags          admin/reports
+// @Produce       json
+// @Security      BearerAuth
+// @Param         Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param         startDate      query   string  false  "Start date (format: YYYY-MM-DD)"
+// @Param         endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD)"
+// @Success       200  {object}  lib.ResponseSuccess{data=models.SalesBreakdown}  "Successfully retrieved sales breakdown"
+// @Failure       400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure       500  {object}  lib.ResponseError  "Internal server error while fetching report"
+// @Router        /admin/reports/breakdown [get]
+func SalesBreakdown(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	breakdown, message, err := getReportWithCache(ctx, func() (models.SalesBreakdown, string, error) {
+		return models.GetSalesBreakdown(filter)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    breakdown,
+	})
+}
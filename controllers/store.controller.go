@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"backend-daily-greens/lib"
+	"backend-daily-greens/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// storeStatusCode maps validation messages of the store hours model to 400 and 409
+func storeStatusCode(message string) int {
+	switch message {
+	case "Weekday must be between 0 and 6",
+		"Weekday is listed more than once",
+		"Open and close time are required unless closed",
+		"Opening hours must be in HH:MM format",
+		"Open and close time cannot be the same",
+		"Date and name are required",
+		"Date must be in YYYY-MM-DD format":
+		return http.StatusBadRequest
+	case "Store holiday already exists for this date":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// GetStoreStatus godoc
+// @Summary      Get store status
+// @Description  Tells whether the store is open right now with the time it closes, or the next time it opens. Holidays replace the weekly hours of their date. closedPolicy is what checkout does while the store is closed, reject or schedule for the next opening
+// @Tags         store
+// @Produce      json
+// @Success      200  {object}  lib.ResponseSuccess{data=models.StoreStatus}  "Successfully retrieved store status"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error"
+// @Router       /store/status [get]
+func GetStoreStatus(ctx *gin.Context) {
+	status, message, err := models.GetStoreStatus()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    status,
+	})
+}
+
+// ListStoreHours godoc
+// @Summary      Get store hours
+// @Description  Retrieving the weekly opening hours in the store timezone, weekday 0 is Sunday. A weekday without hours is open all day
+// @Tags         admin/store
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.StoreHour}  "Successfully retrieved store hours"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching store hours"
+// @Router       /admin/store/hours [get]
+func ListStoreHours(ctx *gin.Context) {
+	hours, message, err := models.GetStoreHours()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    hours,
+	})
+}
+
+// UpdateStoreHours godoc
+// @Summary      Set store hours
+// @Description  Replace the weekly opening hours. Times are HH:MM in the store timezone, a close time before the open time runs past midnight. A weekday left out is open all day
+// @Tags         admin/store
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                    true  "Bearer token"  default(Bearer <token>)
+// @Param        dataHours      body    models.StoreHoursRequest  true  "Weekly hours"
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.StoreHour}  "Store hours updated successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while updating store hours"
+// @Router       /admin/store/hours [put]
+func UpdateStoreHours(ctx *gin.Context) {
+	var bodyUpdate models.StoreHoursRequest
+	err := ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	message, err := models.ReplaceStoreHours(userId.(int), bodyUpdate.Hours)
+	if err != nil {
+		ctx.JSON(storeStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	hours, _, err := models.GetStoreHours()
+	if err != nil {
+		log.Printf("Failed to read store hours after update: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    hours,
+	})
+}
+
+// ListStoreHolidays godoc
+// @Summary      Get store holidays
+// @Description  Retrieving the holidays that replace the weekly hours of their date, earliest first
+// @Tags         admin/store
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Success      200  {object}  lib.ResponseSuccess{data=[]models.StoreHoliday}  "Successfully retrieved store holidays"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching store holidays"
+// @Router       /admin/store/holidays [get]
+func ListStoreHolidays(ctx *gin.Context) {
+	holidays, message, err := models.GetListStoreHolidays()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    holidays,
+	})
+}
+
+// DetailStoreHoliday godoc
+// @Summary      Get store holiday by Id
+// @Description  Retrieving a store holiday
+// @Tags         admin/store
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Store holiday Id"
+// @Success      200  {object}  lib.ResponseSuccess{data=models.StoreHoliday}  "Successfully retrieved store holiday"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      404  {object}  lib.ResponseError  "Store holiday not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while fetching store holiday"
+// @Router       /admin/store/holidays/{id} [get]
+func DetailStoreHoliday(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	holiday, message, err := models.GetStoreHolidayById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    holiday,
+	})
+}
+
+// CreateStoreHoliday godoc
+// @Summary      Create store holiday
+// @Description  Add a holiday that replaces the weekly hours of its date. It is closed all day unless isClosed is false with its own open and close time
+// @Tags         admin/store
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                      true  "Bearer token"  default(Bearer <token>)
+// @Param        dataHoliday    body    models.StoreHolidayRequest  true  "Data store holiday"
+// @Success      201  {object}  lib.ResponseSuccess{data=models.StoreHoliday}  "Store holiday created successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      409  {object}  lib.ResponseError  "Store holiday already exists for this date"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while creating store holiday"
+// @Router       /admin/store/holidays [post]
+func CreateStoreHoliday(ctx *gin.Context) {
+	var bodyCreate models.StoreHolidayRequest
+	err := ctx.ShouldBindJSON(&bodyCreate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	holidayId, message, err := models.InsertStoreHoliday(userId.(int), bodyCreate)
+	if err != nil {
+		ctx.JSON(storeStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	holiday, _, err := models.GetStoreHolidayById(holidayId)
+	if err != nil {
+		log.Printf("Failed to read store holiday %d after insert: %v", holidayId, err)
+	}
+
+	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    holiday,
+	})
+}
+
+// UpdateStoreHoliday godoc
+// @Summary      Update store holiday
+// @Description  Update a store holiday, fields that are not sent keep their value
+// @Tags         admin/store
+// @Accept       application/json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string                      true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int                         true  "Store holiday Id"
+// @Param        dataHoliday    body    models.StoreHolidayRequest  true  "Data store holiday"
+// @Success      200  {object}  lib.ResponseSuccess{data=models.StoreHoliday}  "Store holiday updated successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format or invalid request body"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Store holiday not found"
+// @Failure      409  {object}  lib.ResponseError  "Store holiday already exists for this date"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while updating store holiday"
+// @Router       /admin/store/holidays/{id} [patch]
+func UpdateStoreHoliday(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var bodyUpdate models.StoreHolidayRequest
+	err = ctx.ShouldBindJSON(&bodyUpdate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid JSON body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	isSuccess, message, err := models.UpdateStoreHoliday(id, userId.(int), bodyUpdate)
+	if err != nil {
+		ctx.JSON(storeStatusCode(message), lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	holiday, _, err := models.GetStoreHolidayById(id)
+	if err != nil {
+		log.Printf("Failed to read store holiday %d after update: %v", id, err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    holiday,
+	})
+}
+
+// DeleteStoreHoliday godoc
+// @Summary      Delete store holiday
+// @Description  Delete a store holiday, its date goes back to the weekly hours
+// @Tags         admin/store
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "Store holiday Id"
+// @Success      200  {object}  lib.ResponseSuccess  "Store holiday deleted successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      404  {object}  lib.ResponseError  "Store holiday not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while deleting store holiday"
+// @Router       /admin/store/holidays/{id} [delete]
+func DeleteStoreHoliday(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	isSuccess, message, err := models.DeleteStoreHoliday(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isSuccess {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
@@ -4,7 +4,10 @@ import (
 	"backend-daily-greens/lib"
 	"backend-daily-greens/models"
 	"backend-daily-greens/utils"
+	"context"
+	"encoding/csv"
 	"fmt"
+	"log"
 	"math/rand"
 	"net/http"
 	"strconv"
@@ -24,7 +27,9 @@ import (
 // @Param        	 page           query     int     false  "Page number"  default(1)  minimum(1)
 // @Param        	 limit          query     int     false  "Number of items per page"  default(10)  minimum(1)  maximum(100)
 // @Param        	 search         query     string  false  "Search value"
-// @Success      	 200            {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int},_links=lib.HateoasLink}  "Successfully retrieved transaction list"
+// @Param        	 cursor         query     string  false  "Cursor from meta.nextCursor or meta.prevCursor, send empty to start keyset pagination"
+// @Param        	 count          query     bool    false  "Count total data in keyset pagination"  default(true)
+// @Success      	 200            {object}  object{success=bool,message=string,data=[]models.History,meta=object{currentPage=int,perPage=int,totalData=int,totalPages=int,nextCursor=string,prevCursor=string},_links=lib.HateoasLink}  "Successfully retrieved transaction list"
 // @Failure      	 400            {object}  lib.ResponseError  "Invalid pagination parameters or page out of range."
 // @Failure      	 500            {object}  lib.ResponseError  "Internal server error while fetching or processing transaction data."
 // @Router       	 /admin/transactions [get]
@@ -57,6 +62,48 @@ func ListTransactions(ctx *gin.Context) {
 		return
 	}
 
+	// keyset pagination, opt in with ?cursor= (empty for the first page)
+	if encodedCursor, isCursor := ctx.GetQuery("cursor"); isCursor {
+		withCount, _ := strconv.ParseBool(ctx.DefaultQuery("count", "true"))
+
+		var totalData *int
+		if withCount {
+			total, err := models.GetTotalDataTransactions(search)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+					Success: false,
+					Message: "Failed to count total transactions in database",
+					Error:   err.Error(),
+				})
+				return
+			}
+			totalData = &total
+		}
+
+		transactions, cursors, message, err := models.GetListTransactionsCursor(encodedCursor, limit, search)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if message == "Invalid cursor" {
+				statusCode = http.StatusBadRequest
+			}
+			ctx.JSON(statusCode, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": message,
+			"data":    transactions,
+			"_links":  utils.BuildHateoasCursorPagination(ctx, limit, cursors),
+			"meta":    cursorMeta(limit, cursors, totalData),
+		})
+		return
+	}
+
 	// get total data transactions
 	totalData, err := models.GetTotalDataTransactions(search)
 	if err != nil {
@@ -106,6 +153,165 @@ func ListTransactions(ctx *gin.Context) {
 	})
 }
 
+// cursorMeta builds the meta of a keyset paginated list, totalData is omitted when the count was skipped
+func cursorMeta(limit int, cursors utils.CursorPage, totalData *int) gin.H {
+	meta := gin.H{
+		"perPage":    limit,
+		"nextCursor": cursors.Next,
+		"prevCursor": cursors.Prev,
+	}
+	if totalData != nil {
+		meta["totalData"] = *totalData
+	}
+	return meta
+}
+
+// ExportTransactions godoc
+// @Summary           Export transactions
+// @Description       Streaming transactions with one row per transaction item as CSV or XLSX
+// @Tags              admin/transactions
+// @Produce           text/csv
+// @Produce           application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security          BearerAuth
+// @Param             Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param             format         query   string  false  "Export format"  Enums(csv, xlsx)  default(csv)
+// @Param             startDate      query   string  false  "Start date (format: YYYY-MM-DD), default 30 days before endDate"
+// @Param             endDate        query   string  false  "End date inclusive (format: YYYY-MM-DD), default today"
+// @Param             statusId       query   int     false  "Id of status"
+// @Success           200  {file}    file  "Exported transactions file"
+// @Failure           400  {object}  lib.ResponseError  "Invalid filter parameters"
+// @Failure           500  {object}  lib.ResponseError  "Failed to export transactions"
+// @Router            /admin/transactions/export [get]
+func ExportTransactions(ctx *gin.Context) {
+	filter, message, ok := parseReportFilter(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid format. Allowed values: csv, xlsx",
+		})
+		return
+	}
+
+	statusId, _ := strconv.Atoi(ctx.Query("statusId"))
+	exportFilter := models.TransactionExportFilter{
+		StartDate: filter.StartDate,
+		EndDate:   filter.EndDate,
+		StatusId:  statusId,
+	}
+
+	header := []string{
+		"No Invoice", "Date Transaction", "Full Name", "Email", "Phone", "Address",
+		"Payment Method", "Order Method", "Status", "Delivery Fee", "Admin Fee", "Tax", "Total Transaction",
+		"Product Name", "Size", "Variant", "Amount", "Product Price", "Discount Price", "Subtotal",
+	}
+	toCells := func(row models.TransactionExportRow) []any {
+		return []any{
+			row.NoInvoice, row.DateTransaction, row.FullName, row.Email, row.Phone, row.Address,
+			row.PaymentMethod, row.OrderMethod, row.Status, row.DeliveryFee, row.AdminFee, row.Tax, row.TotalTransaction,
+			row.ProductName, row.Size, row.Variant, row.Amount, row.ProductPrice, row.DiscountPrice, row.Subtotal,
+		}
+	}
+
+	fileName := fmt.Sprintf("transactions_%s_%s.%s",
+		filter.StartDate.Format("20060102"),
+		filter.EndDate.AddDate(0, 0, -1).Format("20060102"),
+		format,
+	)
+	setFileHeaders := func(contentType string) {
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+		ctx.Header("Content-Type", contentType)
+	}
+
+	var err error
+	if format == "csv" {
+		writer := csv.NewWriter(ctx.Writer)
+		count := 0
+		err = models.StreamTransactionExport(exportFilter, func() error {
+			setFileHeaders("text/csv")
+			return writer.Write(header)
+		}, func(row models.TransactionExportRow) error {
+			cells := toCells(row)
+			record := make([]string, len(cells))
+			for i, cell := range cells {
+				record[i] = utils.FormatCsvCell(cell)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+
+			// flush periodically so rows reach the client as they are read
+			count++
+			if count%100 == 0 {
+				writer.Flush()
+				ctx.Writer.Flush()
+			}
+			return writer.Error()
+		})
+		if err == nil {
+			writer.Flush()
+			err = writer.Error()
+		}
+	} else {
+		var writer *utils.XlsxWriter
+		err = models.StreamTransactionExport(exportFilter, func() error {
+			setFileHeaders("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			var err error
+			writer, err = utils.NewXlsxWriter(ctx.Writer, "Transactions")
+			if err != nil {
+				return err
+			}
+			headerCells := make([]any, len(header))
+			for i, h := range header {
+				headerCells[i] = h
+			}
+			return writer.WriteRow(headerCells)
+		}, func(row models.TransactionExportRow) error {
+			return writer.WriteRow(toCells(row))
+		})
+		// the closing entries are only written for a complete file
+		if err == nil {
+			err = writer.Close()
+		}
+	}
+
+	if err != nil {
+		failExport(ctx, "Failed to export transactions", err)
+	}
+}
+
+// failExport answers 500 while nothing of the file has reached the client. Once it has, the connection
+// is dropped so the client sees a broken download instead of a short file that looks complete.
+func failExport(ctx *gin.Context, message string, err error) {
+	log.Printf("%s: %v", message, err)
+
+	if !ctx.Writer.Written() {
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.Error(err)
+	if conn, _, hijackErr := ctx.Writer.Hijack(); hijackErr == nil {
+		conn.Close()
+		ctx.Abort()
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
 // TransactionDetail godoc
 // @Summary          Get transaction by Id
 // @Description      Retrieving transaction detail data based on Id including transaction items
@@ -231,16 +437,29 @@ func UpdateTransactionStatus(ctx *gin.Context) {
 	}
 
 	// update transaction status
-	isSuccess, message, err := models.UpdateTransactionStatusById(id, statusId, userId.(int))
+	statusChange, message, err := models.UpdateTransactionStatusById(id, statusId, userId.(int))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
-			Success: isSuccess,
+			Success: false,
 			Message: message,
 			Error:   err.Error(),
 		})
 		return
 	}
 
+	// rankings count an order once it is finished, an order moved back out of it makes them rebuild
+	isFinished := statusChange.Status == models.FinishedOrderStatus
+	wasFinished := statusChange.PreviousStatus == models.FinishedOrderStatus
+	if isFinished && !wasFinished {
+		if err := models.RecordFinishedOrderSales(context.Background(), id, statusChange.DateTransaction); err != nil {
+			fmt.Printf("Warning: Failed to record sales rankings: %v\n", err)
+		}
+	} else if wasFinished && !isFinished {
+		if err := models.InvalidateSalesRankings(context.Background()); err != nil {
+			fmt.Printf("Warning: Failed to invalidate sales rankings: %v\n", err)
+		}
+	}
+
 	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
 		Success: true,
 		Message: message,
@@ -249,7 +468,7 @@ func UpdateTransactionStatus(ctx *gin.Context) {
 
 // Checkout      godoc
 // @Summary      Checkout carts
-// @Description  Checkout products on the cart
+// @Description  Checkout products on the cart. For an order method with delivery zones the delivery fee is the fee of the zone latitude and longitude fall in. scheduledAt books a slot of the order method, see /order-methods/{id}/slots. An order without scheduledAt is refused while the store is closed, or booked on the next free slot when the store closed policy is schedule. scheduledAt of the response is the slot the order is made for, null for an order made now
 // @Tags         transactions
 // @Accept       application/json
 // @Produce      json
@@ -257,8 +476,9 @@ func UpdateTransactionStatus(ctx *gin.Context) {
 // @Param        Authorization  header    string  true  "Bearer token"  default(Bearer <token>)
 // @Param        DataCheckout   body      models.TransactionRequest  true  "Data Checkout"
 // @Success      201  {object}  lib.ResponseSuccess{data=models.TransactionDetail}  "Transaction created successfully"
-// @Failure      400  {object}  lib.ResponseError  "Invalid request body"
+// @Failure      400  {object}  lib.ResponseError  "Invalid request body, time slot is not available, address outside the delivery area or order below the minimum order of its delivery zone"
 // @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      409  {object}  lib.ResponseError  "Store is closed, time slot is full, product not available at this time, flash sale quota exceeded or not enough stock left"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while acces database"
 // @Router       /transactions [post]
 func Checkout(ctx *gin.Context) {
@@ -348,11 +568,113 @@ func Checkout(ctx *gin.Context) {
 		return
 	}
 
+	// an order for now is refused outside opening hours or moved to the next free slot, see STORE_CLOSED_POLICY
+	if bodyCheckout.ScheduledAt == nil {
+		storeStatus, message, err := models.GetStoreStatus()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+		if !storeStatus.IsOpen {
+			if storeStatus.ClosedPolicy == "schedule" {
+				bodyCheckout.ScheduledAt, message, err = models.GetNextOrderSlot(bodyCheckout.OrderMethodId)
+				if err != nil {
+					ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+						Success: false,
+						Message: message,
+						Error:   err.Error(),
+					})
+					return
+				}
+			}
+			if bodyCheckout.ScheduledAt == nil {
+				ctx.JSON(http.StatusConflict, lib.ResponseError{
+					Success: false,
+					Message: "Store is closed",
+					Error:   "orders are not taken outside opening hours",
+				})
+				return
+			}
+		}
+	}
+
+	// products can go out of schedule or daily hours while they sit in the cart,
+	// a scheduled order needs them at its slot rather than now
+	if bodyCheckout.ScheduledAt != nil {
+		productIds := []int{}
+		for _, c := range carts {
+			productIds = append(productIds, c.ProductId)
+		}
+		unavailable, message, err := models.GetProductsUnavailableAt(productIds, *bodyCheckout.ScheduledAt)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+				Success: false,
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+		if len(unavailable) > 0 {
+			ctx.JSON(http.StatusConflict, lib.ResponseError{
+				Success: false,
+				Message: "Product is not available at this time",
+				Error:   fmt.Sprintf("%s is not available at the scheduled time", strings.Join(unavailable, ", ")),
+			})
+			return
+		}
+	} else {
+		for _, c := range carts {
+			if !c.IsAvailableNow {
+				ctx.JSON(http.StatusConflict, lib.ResponseError{
+					Success: false,
+					Message: "Product is not available at this time",
+					Error:   fmt.Sprintf("%s is not available at this time", c.ProductName),
+				})
+				return
+			}
+		}
+	}
+
 	// calculate total transaction
 	var total float64
 	for _, c := range carts {
 		total += c.Subtotal
 	}
+
+	// an order method with delivery zones charges the fee of the zone the customer coordinate falls in
+	var deliveryPoint *models.GeoPoint
+	if bodyCheckout.Latitude != nil && bodyCheckout.Longitude != nil {
+		deliveryPoint = &models.GeoPoint{Latitude: *bodyCheckout.Latitude, Longitude: *bodyCheckout.Longitude}
+	}
+	deliveryZone, message, err := models.FindDeliveryZone(bodyCheckout.OrderMethodId, deliveryPoint)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if message == "Latitude and longitude are required for this order method" || message == "Invalid coordinate" || message == "Address is outside the delivery area" {
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if deliveryZone != nil {
+		if total < deliveryZone.MinOrder {
+			ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+				Success: false,
+				Message: "Order is below the minimum order of the delivery zone",
+				Error:   fmt.Sprintf("minimum order of %s is %.0f", deliveryZone.Name, deliveryZone.MinOrder),
+			})
+			return
+		}
+		bodyCheckout.DeliveryFee = deliveryZone.DeliveryFee
+		bodyCheckout.DeliveryZoneId = &deliveryZone.Id
+	}
 	bodyCheckout.Tax = total * 0.10
 	bodyCheckout.TotalTransaction = total + bodyCheckout.Tax + bodyCheckout.DeliveryFee + bodyCheckout.AdminFee
 
@@ -367,7 +689,14 @@ func Checkout(ctx *gin.Context) {
 	// insert data to transactions
 	transactionId, message, err := models.MakeTransaction(userId.(int), bodyCheckout, carts)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+		statusCode := http.StatusInternalServerError
+		switch message {
+		case "Time slot is not available":
+			statusCode = http.StatusBadRequest
+		case "Flash sale quota exceeded", "Not enough stock for bundle components", "Not enough stock left", "Time slot is full":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, lib.ResponseError{
 			Success: false,
 			Message: message,
 			Error:   err.Error(),
@@ -375,6 +704,24 @@ func Checkout(ctx *gin.Context) {
 		return
 	}
 
+	// new order changes sales numbers
+	if err := models.InvalidateReportCache(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to invalidate report cache: %v\n", err)
+	}
+
+	// sales rankings are recorded when the order is finished, see UpdateTransactionStatus
+	flashSaleSold := false
+	for _, c := range carts {
+		flashSaleSold = flashSaleSold || c.FlashSaleProductId != nil
+	}
+
+	// a flash sale that hit its quantity cap goes back to the regular price
+	if flashSaleSold {
+		if err := models.InvalidateProductCache(context.Background()); err != nil {
+			fmt.Printf("Warning: Failed to invalidate cache: %v\n", err)
+		}
+	}
+
 	ctx.JSON(http.StatusCreated, lib.ResponseSuccess{
 		Success: true,
 		Message: message,
@@ -386,6 +733,7 @@ func Checkout(ctx *gin.Context) {
 			"adminFee":         bodyCheckout.AdminFee,
 			"tax":              bodyCheckout.Tax,
 			"totalTransaction": bodyCheckout.TotalTransaction,
+			"scheduledAt":      bodyCheckout.ScheduledAt,
 		},
 	})
 }
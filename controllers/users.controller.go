@@ -4,6 +4,7 @@ import (
 	"backend-daily-greens/lib"
 	"backend-daily-greens/models"
 	"backend-daily-greens/utils"
+	"context"
 	"fmt"
 	"net/http"
 	"path/filepath"
@@ -434,7 +435,7 @@ func UpdateUser(ctx *gin.Context) {
 
 // DeleteUser    godoc
 // @Summary      Delete user
-// @Description  Delete user by Id
+// @Description  Soft delete user by Id, the user can no longer log in until restored
 // @Tags         admin/users
 // @Accept       x-www-form-urlencoded
 // @Produce      json
@@ -443,6 +444,7 @@ func UpdateUser(ctx *gin.Context) {
 // @Param        id             path    int     true  "User Id"
 // @Success      200  {object}  lib.ResponseSuccess  "User deleted successfully"
 // @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
 // @Failure      404  {object}  lib.ResponseError  "User not found"
 // @Failure      500  {object}  lib.ResponseError  "Internal server error while deleting user data"
 // @Router       /admin/users/{id} [delete]
@@ -457,7 +459,17 @@ func DeleteUser(ctx *gin.Context) {
 		return
 	}
 
-	commandTag, err := models.DeleteDataUser(id)
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	commandTag, err := models.DeleteDataUser(id, userIdFromToken.(int))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
 			Success: false,
@@ -475,8 +487,110 @@ func DeleteUser(ctx *gin.Context) {
 		return
 	}
 
+	if err := models.RevokeUserTokens(context.Background(), id); err != nil {
+		fmt.Printf("Warning: Failed to revoke user tokens: %v\n", err)
+	}
+
 	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
 		Success: true,
 		Message: "User deleted successfully",
 	})
 }
+
+// RestoreUser   godoc
+// @Summary      Restore user
+// @Description  Restore a soft deleted user so they can log in again
+// @Tags         admin/users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true  "Bearer token"  default(Bearer <token>)
+// @Param        id             path    int     true  "User Id"
+// @Success      200  {object}  lib.ResponseSuccess  "User restored successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid Id format"
+// @Failure      401  {object}  lib.ResponseError  "User Id not found in token"
+// @Failure      404  {object}  lib.ResponseError  "Deleted user not found"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while restoring user data"
+// @Router       /admin/users/{id}/restore [post]
+func RestoreUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, lib.ResponseError{
+			Success: false,
+			Message: "Invalid Id format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user id from token
+	userIdFromToken, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "User Id not found in token",
+		})
+		return
+	}
+
+	commandTag, err := models.RestoreDataUser(id, userIdFromToken.(int))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Internal server error while restoring user data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		ctx.JSON(http.StatusNotFound, lib.ResponseError{
+			Success: false,
+			Message: "Deleted user not found",
+		})
+		return
+	}
+
+	if err := models.ClearUserRevocation(context.Background(), id); err != nil {
+		fmt.Printf("Warning: Failed to clear user revocation: %v\n", err)
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: "User restored successfully",
+	})
+}
+
+// PurgeUsers    godoc
+// @Summary      Purge deleted users
+// @Description  Permanently remove soft deleted users. Users still referenced by orders or other records are skipped
+// @Tags         admin/users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Authorization  header  string  true   "Bearer token"  default(Bearer <token>)
+// @Param        olderThanDays  query   int     false  "Only purge users deleted at least this many days ago"  default(0)  minimum(0)
+// @Success      200  {object}  lib.ResponseSuccess{data=models.PurgeResult}  "Deleted users purged successfully"
+// @Failure      400  {object}  lib.ResponseError  "Invalid olderThanDays"
+// @Failure      500  {object}  lib.ResponseError  "Internal server error while purging users"
+// @Router       /admin/users/purge [post]
+func PurgeUsers(ctx *gin.Context) {
+	olderThanDays, ok := purgeOlderThanDays(ctx)
+	if !ok {
+		return
+	}
+
+	result, message, err := models.PurgeDeletedUsers(olderThanDays)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lib.ResponseSuccess{
+		Success: true,
+		Message: message,
+		Data:    result,
+	})
+}
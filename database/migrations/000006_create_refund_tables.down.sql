@@ -0,0 +1,24 @@
+DROP INDEX IF EXISTS idx_refund_items_transaction_item;
+
+DROP INDEX IF EXISTS idx_refunds_transaction;
+
+ALTER TABLE "refund_items"
+DROP CONSTRAINT "fk_refund_items_updated_by";
+
+ALTER TABLE "refund_items"
+DROP CONSTRAINT "fk_refund_items_created_by";
+
+ALTER TABLE "refund_items"
+DROP CONSTRAINT "fk_refund_items_transaction_item_id";
+
+ALTER TABLE "refund_items" DROP CONSTRAINT "fk_refund_items_refund_id";
+
+ALTER TABLE "refunds" DROP CONSTRAINT "fk_refunds_updated_by";
+
+ALTER TABLE "refunds" DROP CONSTRAINT "fk_refunds_created_by";
+
+ALTER TABLE "refunds" DROP CONSTRAINT "fk_refunds_transaction_id";
+
+DROP TABLE "refund_items";
+
+DROP TABLE "refunds";
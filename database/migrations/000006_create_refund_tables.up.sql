@@ -0,0 +1,49 @@
+CREATE TABLE "refunds" (
+    "id" serial PRIMARY KEY,
+    "transaction_id" int NOT NULL,
+    "reason" text,
+    "refund_amount" numeric(10, 2) NOT NULL DEFAULT 0 CHECK ("refund_amount" >= 0),
+    "is_full_refund" bool DEFAULT false,
+    "is_restock" bool DEFAULT false,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+CREATE TABLE "refund_items" (
+    "id" serial PRIMARY KEY,
+    "refund_id" int NOT NULL,
+    "transaction_item_id" int NOT NULL,
+    "amount" int NOT NULL CHECK ("amount" > 0),
+    "refund_amount" numeric(10, 2) NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "refunds"
+ADD CONSTRAINT "fk_refunds_transaction_id" FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id");
+
+ALTER TABLE "refunds"
+ADD CONSTRAINT "fk_refunds_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "refunds"
+ADD CONSTRAINT "fk_refunds_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "refund_items"
+ADD CONSTRAINT "fk_refund_items_refund_id" FOREIGN KEY ("refund_id") REFERENCES "refunds" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "refund_items"
+ADD CONSTRAINT "fk_refund_items_transaction_item_id" FOREIGN KEY ("transaction_item_id") REFERENCES "transaction_items" ("id");
+
+ALTER TABLE "refund_items"
+ADD CONSTRAINT "fk_refund_items_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "refund_items"
+ADD CONSTRAINT "fk_refund_items_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_refunds_transaction ON refunds (transaction_id);
+
+CREATE INDEX idx_refund_items_transaction_item ON refund_items (transaction_item_id);
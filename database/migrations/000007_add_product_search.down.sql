@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_products_name_trgm;
+
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+DROP TRIGGER IF EXISTS "trg_categories_search_vector" ON "categories";
+
+DROP TRIGGER IF EXISTS "trg_product_categories_search_vector" ON "product_categories";
+
+DROP TRIGGER IF EXISTS "trg_products_search_vector" ON "products";
+
+DROP FUNCTION IF EXISTS categories_search_vector_trigger();
+
+DROP FUNCTION IF EXISTS product_categories_search_vector_trigger();
+
+DROP FUNCTION IF EXISTS products_search_vector_trigger();
+
+DROP FUNCTION IF EXISTS products_search_vector(int);
+
+ALTER TABLE "products" DROP COLUMN "search_vector";
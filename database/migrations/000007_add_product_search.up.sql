@@ -0,0 +1,70 @@
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+ALTER TABLE "products" ADD COLUMN "search_vector" tsvector;
+
+-- name weighs most, then category names, then description
+CREATE OR REPLACE FUNCTION products_search_vector(p_id int) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('simple', COALESCE(p.name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(STRING_AGG(c.name, ' '), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(p.description, '')), 'C')
+    FROM products p
+    LEFT JOIN product_categories pc ON pc.product_id = p.id
+    LEFT JOIN categories c ON c.id = pc.category_id
+    WHERE p.id = p_id
+    GROUP BY p.id;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', COALESCE(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE((
+            SELECT STRING_AGG(c.name, ' ')
+            FROM product_categories pc
+            JOIN categories c ON c.id = pc.category_id
+            WHERE pc.product_id = NEW.id
+        ), '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.description, '')), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_products_search_vector"
+BEFORE INSERT OR UPDATE OF "name", "description" ON "products"
+FOR EACH ROW EXECUTE FUNCTION products_search_vector_trigger();
+
+CREATE OR REPLACE FUNCTION product_categories_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE products SET search_vector = products_search_vector(NEW.product_id) WHERE id = NEW.product_id;
+    END IF;
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        UPDATE products SET search_vector = products_search_vector(OLD.product_id) WHERE id = OLD.product_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_categories_search_vector"
+AFTER INSERT OR UPDATE OR DELETE ON "product_categories"
+FOR EACH ROW EXECUTE FUNCTION product_categories_search_vector_trigger();
+
+CREATE OR REPLACE FUNCTION categories_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE products p SET search_vector = products_search_vector(p.id)
+    FROM product_categories pc
+    WHERE pc.product_id = p.id AND pc.category_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_categories_search_vector"
+AFTER UPDATE OF "name" ON "categories"
+FOR EACH ROW EXECUTE FUNCTION categories_search_vector_trigger();
+
+UPDATE "products" SET "search_vector" = products_search_vector("id");
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+
+CREATE INDEX idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
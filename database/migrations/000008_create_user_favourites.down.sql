@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_user_favourites_product;
+
+ALTER TABLE "user_favourites"
+DROP CONSTRAINT "fk_user_favourites_updated_by";
+
+ALTER TABLE "user_favourites"
+DROP CONSTRAINT "fk_user_favourites_created_by";
+
+ALTER TABLE "user_favourites"
+DROP CONSTRAINT "fk_user_favourites_product_id";
+
+ALTER TABLE "user_favourites" DROP CONSTRAINT "fk_user_favourites_user_id";
+
+DROP TABLE "user_favourites";
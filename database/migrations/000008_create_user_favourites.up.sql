@@ -0,0 +1,24 @@
+CREATE TABLE "user_favourites" (
+    "id" serial PRIMARY KEY,
+    "user_id" int NOT NULL,
+    "product_id" int NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("user_id", "product_id")
+);
+
+ALTER TABLE "user_favourites"
+ADD CONSTRAINT "fk_user_favourites_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "user_favourites"
+ADD CONSTRAINT "fk_user_favourites_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "user_favourites"
+ADD CONSTRAINT "fk_user_favourites_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "user_favourites"
+ADD CONSTRAINT "fk_user_favourites_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_user_favourites_product ON user_favourites (product_id);
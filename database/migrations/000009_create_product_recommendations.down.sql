@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_transaction_items_product;
+
+DROP INDEX IF EXISTS idx_product_recommendations_product_score;
+
+ALTER TABLE "product_recommendations"
+DROP CONSTRAINT "fk_product_recommendations_updated_by";
+
+ALTER TABLE "product_recommendations"
+DROP CONSTRAINT "fk_product_recommendations_created_by";
+
+ALTER TABLE "product_recommendations"
+DROP CONSTRAINT "fk_product_recommendations_recommended_product_id";
+
+ALTER TABLE "product_recommendations"
+DROP CONSTRAINT "fk_product_recommendations_product_id";
+
+DROP TABLE "product_recommendations";
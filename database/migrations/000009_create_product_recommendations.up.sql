@@ -0,0 +1,28 @@
+CREATE TABLE "product_recommendations" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "recommended_product_id" int NOT NULL,
+    "co_purchase_count" int NOT NULL DEFAULT 0,
+    "score" numeric(8, 6) NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("product_id", "recommended_product_id")
+);
+
+ALTER TABLE "product_recommendations"
+ADD CONSTRAINT "fk_product_recommendations_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_recommendations"
+ADD CONSTRAINT "fk_product_recommendations_recommended_product_id" FOREIGN KEY ("recommended_product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_recommendations"
+ADD CONSTRAINT "fk_product_recommendations_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_recommendations"
+ADD CONSTRAINT "fk_product_recommendations_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_product_recommendations_product_score ON product_recommendations (product_id, score DESC);
+
+CREATE INDEX idx_transaction_items_product ON transaction_items (product_id);
@@ -0,0 +1,27 @@
+DROP VIEW IF EXISTS "active_flash_sale_products";
+
+DROP INDEX IF EXISTS idx_flash_sale_products_product;
+
+DROP INDEX IF EXISTS idx_flash_sales_window;
+
+ALTER TABLE "flash_sale_products"
+DROP CONSTRAINT "fk_flash_sale_products_updated_by";
+
+ALTER TABLE "flash_sale_products"
+DROP CONSTRAINT "fk_flash_sale_products_created_by";
+
+ALTER TABLE "flash_sale_products"
+DROP CONSTRAINT "fk_flash_sale_products_product_id";
+
+ALTER TABLE "flash_sale_products"
+DROP CONSTRAINT "fk_flash_sale_products_flash_sale_id";
+
+ALTER TABLE "flash_sales"
+DROP CONSTRAINT "fk_flash_sales_updated_by";
+
+ALTER TABLE "flash_sales"
+DROP CONSTRAINT "fk_flash_sales_created_by";
+
+DROP TABLE "flash_sale_products";
+
+DROP TABLE "flash_sales";
@@ -0,0 +1,66 @@
+CREATE TABLE "flash_sales" (
+    "id" serial PRIMARY KEY,
+    "name" varchar(100) NOT NULL,
+    "start_at" timestamptz NOT NULL,
+    "end_at" timestamptz NOT NULL,
+    "is_active" bool NOT NULL DEFAULT true,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK ("end_at" > "start_at")
+);
+
+CREATE TABLE "flash_sale_products" (
+    "id" serial PRIMARY KEY,
+    "flash_sale_id" int NOT NULL,
+    "product_id" int NOT NULL,
+    "discount_percent" numeric(5, 2) NOT NULL CHECK ("discount_percent" > 0 AND "discount_percent" <= 100),
+    "quantity_cap" int CHECK ("quantity_cap" > 0),
+    "sold_quantity" int NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("flash_sale_id", "product_id")
+);
+
+ALTER TABLE "flash_sales"
+ADD CONSTRAINT "fk_flash_sales_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "flash_sales"
+ADD CONSTRAINT "fk_flash_sales_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "flash_sale_products"
+ADD CONSTRAINT "fk_flash_sale_products_flash_sale_id" FOREIGN KEY ("flash_sale_id") REFERENCES "flash_sales" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "flash_sale_products"
+ADD CONSTRAINT "fk_flash_sale_products_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "flash_sale_products"
+ADD CONSTRAINT "fk_flash_sale_products_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "flash_sale_products"
+ADD CONSTRAINT "fk_flash_sale_products_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_flash_sales_window ON flash_sales (start_at, end_at) WHERE is_active = true;
+
+CREATE INDEX idx_flash_sale_products_product ON flash_sale_products (product_id);
+
+-- the running campaign price of each product, the biggest discount wins when campaigns overlap
+CREATE VIEW "active_flash_sale_products" AS
+SELECT DISTINCT ON (fsp.product_id)
+    fsp.product_id,
+    fsp.id AS flash_sale_product_id,
+    fsp.flash_sale_id,
+    fsp.discount_percent,
+    fsp.quantity_cap,
+    fsp.sold_quantity,
+    fs.end_at
+FROM flash_sale_products fsp
+JOIN flash_sales fs ON fs.id = fsp.flash_sale_id
+WHERE fs.is_active = true
+AND fs.start_at <= NOW()
+AND fs.end_at > NOW()
+AND (fsp.quantity_cap IS NULL OR fsp.sold_quantity < fsp.quantity_cap)
+ORDER BY fsp.product_id, fsp.discount_percent DESC, fs.end_at ASC, fsp.id ASC;
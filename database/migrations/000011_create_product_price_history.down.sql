@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS "trg_product_price_history" ON "products";
+
+DROP FUNCTION IF EXISTS product_price_history_trigger();
+
+DROP INDEX IF EXISTS idx_product_price_history_product_created;
+
+ALTER TABLE "product_price_history"
+DROP CONSTRAINT "fk_product_price_history_updated_by";
+
+ALTER TABLE "product_price_history"
+DROP CONSTRAINT "fk_product_price_history_created_by";
+
+ALTER TABLE "product_price_history"
+DROP CONSTRAINT "fk_product_price_history_product_id";
+
+DROP TABLE "product_price_history";
@@ -0,0 +1,58 @@
+CREATE TABLE "product_price_history" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "old_price" numeric(10, 2),
+    "old_discount_percent" numeric(5, 2),
+    "price" numeric(10, 2) NOT NULL,
+    "discount_percent" numeric(5, 2) NOT NULL DEFAULT 0,
+    "effective_price" numeric(10, 2) NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "product_price_history"
+ADD CONSTRAINT "fk_product_price_history_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_price_history"
+ADD CONSTRAINT "fk_product_price_history_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_price_history"
+ADD CONSTRAINT "fk_product_price_history_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_product_price_history_product_created ON product_price_history (product_id, created_at DESC);
+
+-- every price or discount change is recorded with the actor from updated_by, whichever code path wrote it
+CREATE OR REPLACE FUNCTION product_price_history_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.price IS NOT DISTINCT FROM OLD.price
+        AND COALESCE(NEW.discount_percent, 0) = COALESCE(OLD.discount_percent, 0) THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO product_price_history (
+        product_id, old_price, old_discount_percent, price, discount_percent, effective_price, created_by, updated_by
+    ) VALUES (
+        NEW.id,
+        CASE WHEN TG_OP = 'UPDATE' THEN OLD.price END,
+        CASE WHEN TG_OP = 'UPDATE' THEN COALESCE(OLD.discount_percent, 0) END,
+        NEW.price,
+        COALESCE(NEW.discount_percent, 0),
+        NEW.price * (1 - COALESCE(NEW.discount_percent, 0) / 100.0),
+        NEW.updated_by,
+        NEW.updated_by
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_price_history"
+AFTER INSERT OR UPDATE OF "price", "discount_percent" ON "products"
+FOR EACH ROW EXECUTE FUNCTION product_price_history_trigger();
+
+-- current prices are the starting point of the history
+INSERT INTO product_price_history (product_id, price, discount_percent, effective_price, created_by, updated_by)
+SELECT id, price, COALESCE(discount_percent, 0), price * (1 - COALESCE(discount_percent, 0) / 100.0), updated_by, updated_by
+FROM products;
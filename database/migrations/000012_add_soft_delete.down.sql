@@ -0,0 +1,23 @@
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+
+DROP INDEX IF EXISTS idx_products_deleted_at;
+
+ALTER TABLE "users"
+DROP CONSTRAINT "fk_users_deleted_by";
+
+ALTER TABLE "categories"
+DROP CONSTRAINT "fk_categories_deleted_by";
+
+ALTER TABLE "products"
+DROP CONSTRAINT "fk_products_deleted_by";
+
+ALTER TABLE "users" DROP COLUMN "deleted_by";
+ALTER TABLE "users" DROP COLUMN "deleted_at";
+
+ALTER TABLE "categories" DROP COLUMN "deleted_by";
+ALTER TABLE "categories" DROP COLUMN "deleted_at";
+
+ALTER TABLE "products" DROP COLUMN "deleted_by";
+ALTER TABLE "products" DROP COLUMN "deleted_at";
@@ -0,0 +1,24 @@
+ALTER TABLE "products" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "products" ADD COLUMN "deleted_by" int;
+
+ALTER TABLE "categories" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "categories" ADD COLUMN "deleted_by" int;
+
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "users" ADD COLUMN "deleted_by" int;
+
+ALTER TABLE "products"
+ADD CONSTRAINT "fk_products_deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id");
+
+ALTER TABLE "categories"
+ADD CONSTRAINT "fk_categories_deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id");
+
+ALTER TABLE "users"
+ADD CONSTRAINT "fk_users_deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id");
+
+-- only deleted rows are indexed, for the purge
+CREATE INDEX idx_products_deleted_at ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX idx_categories_deleted_at ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
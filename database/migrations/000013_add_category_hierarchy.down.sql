@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_categories_parent_id;
+
+ALTER TABLE "categories"
+DROP CONSTRAINT "fk_categories_parent_id";
+
+ALTER TABLE "categories"
+DROP CONSTRAINT "uq_categories_slug";
+
+ALTER TABLE "categories" DROP COLUMN "sort_order";
+ALTER TABLE "categories" DROP COLUMN "image";
+ALTER TABLE "categories" DROP COLUMN "description";
+ALTER TABLE "categories" DROP COLUMN "slug";
+ALTER TABLE "categories" DROP COLUMN "parent_id";
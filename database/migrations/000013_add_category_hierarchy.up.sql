@@ -0,0 +1,21 @@
+ALTER TABLE "categories" ADD COLUMN "parent_id" int;
+ALTER TABLE "categories" ADD COLUMN "slug" varchar(120);
+ALTER TABLE "categories" ADD COLUMN "description" text;
+ALTER TABLE "categories" ADD COLUMN "image" text;
+ALTER TABLE "categories" ADD COLUMN "sort_order" int NOT NULL DEFAULT 0;
+
+-- existing categories get a slug from their name, the id is appended when two names give the same slug
+UPDATE "categories" SET "slug" = NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER("name"), '[^a-z0-9]+', '-', 'g')), '');
+
+UPDATE "categories" c SET "slug" = COALESCE(c."slug" || '-', 'category-') || c."id"
+WHERE c."slug" IS NULL
+   OR EXISTS (SELECT 1 FROM "categories" o WHERE o."slug" = c."slug" AND o."id" < c."id");
+
+ALTER TABLE "categories" ALTER COLUMN "slug" SET NOT NULL;
+
+ALTER TABLE "categories" ADD CONSTRAINT "uq_categories_slug" UNIQUE ("slug");
+
+ALTER TABLE "categories"
+ADD CONSTRAINT "fk_categories_parent_id" FOREIGN KEY ("parent_id") REFERENCES "categories" ("id");
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id, sort_order);
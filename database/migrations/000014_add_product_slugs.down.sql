@@ -0,0 +1,21 @@
+DROP TRIGGER IF EXISTS "trg_product_slug" ON "products";
+
+DROP FUNCTION IF EXISTS product_slug_trigger();
+
+DROP INDEX IF EXISTS idx_product_slug_redirects_product_id;
+
+ALTER TABLE "product_slug_redirects"
+DROP CONSTRAINT "fk_product_slug_redirects_updated_by";
+
+ALTER TABLE "product_slug_redirects"
+DROP CONSTRAINT "fk_product_slug_redirects_created_by";
+
+ALTER TABLE "product_slug_redirects"
+DROP CONSTRAINT "fk_product_slug_redirects_product_id";
+
+DROP TABLE "product_slug_redirects";
+
+ALTER TABLE "products"
+DROP CONSTRAINT "uq_products_slug";
+
+ALTER TABLE "products" DROP COLUMN "slug";
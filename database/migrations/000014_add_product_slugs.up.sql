@@ -0,0 +1,77 @@
+ALTER TABLE "products" ADD COLUMN "slug" varchar(280);
+
+-- existing products get a slug from their name, the id is appended when two names give the same slug.
+-- Numeric slugs would be read as an id and route names as their route, both get a prefix.
+UPDATE "products" SET "slug" = NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER("name"), '[^a-z0-9]+', '-', 'g')), '');
+
+UPDATE "products" SET "slug" = 'product-' || "slug"
+WHERE "slug" ~ '^[0-9]+$' OR "slug" IN ('suggest', 'trending', 'best-sellers');
+
+UPDATE "products" p SET "slug" = COALESCE(p."slug" || '-', 'product-') || p."id"
+WHERE p."slug" IS NULL
+   OR EXISTS (SELECT 1 FROM "products" o WHERE o."slug" = p."slug" AND o."id" < p."id");
+
+ALTER TABLE "products" ALTER COLUMN "slug" SET NOT NULL;
+
+ALTER TABLE "products" ADD CONSTRAINT "uq_products_slug" UNIQUE ("slug");
+
+CREATE TABLE "product_slug_redirects" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "slug" varchar(280) UNIQUE NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "product_slug_redirects"
+ADD CONSTRAINT "fk_product_slug_redirects_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_slug_redirects"
+ADD CONSTRAINT "fk_product_slug_redirects_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_slug_redirects"
+ADD CONSTRAINT "fk_product_slug_redirects_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_product_slug_redirects_product_id ON product_slug_redirects (product_id);
+
+-- the slug follows the name on every insert and rename, whichever code path wrote it.
+-- A slug is unique across products and redirects, the previous slug of a renamed product becomes a redirect.
+CREATE OR REPLACE FUNCTION product_slug_trigger() RETURNS trigger AS $$
+DECLARE
+    base_slug text;
+    candidate text;
+    suffix int := 1;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.name IS NOT DISTINCT FROM OLD.name THEN
+        RETURN NEW;
+    END IF;
+
+    base_slug := COALESCE(NULLIF(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(NEW.name), '[^a-z0-9]+', '-', 'g')), ''), 'product');
+    IF base_slug ~ '^[0-9]+$' OR base_slug IN ('suggest', 'trending', 'best-sellers') THEN
+        base_slug := 'product-' || base_slug;
+    END IF;
+
+    candidate := base_slug;
+    WHILE EXISTS (SELECT 1 FROM products WHERE slug = candidate AND id <> NEW.id)
+        OR EXISTS (SELECT 1 FROM product_slug_redirects WHERE slug = candidate AND product_id <> NEW.id) LOOP
+        suffix := suffix + 1;
+        candidate := base_slug || '-' || suffix;
+    END LOOP;
+
+    IF TG_OP = 'UPDATE' AND candidate <> OLD.slug THEN
+        -- renamed back to an earlier name, that slug is no longer a redirect
+        DELETE FROM product_slug_redirects WHERE slug = candidate;
+        INSERT INTO product_slug_redirects (product_id, slug, created_by, updated_by)
+        VALUES (OLD.id, OLD.slug, NEW.updated_by, NEW.updated_by);
+    END IF;
+
+    NEW.slug := candidate;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_slug"
+BEFORE INSERT OR UPDATE OF "name" ON "products"
+FOR EACH ROW EXECUTE FUNCTION product_slug_trigger();
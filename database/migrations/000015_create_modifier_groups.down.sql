@@ -0,0 +1,72 @@
+DROP INDEX IF EXISTS idx_transaction_item_modifiers_item;
+
+DROP INDEX IF EXISTS idx_cart_modifiers_option;
+
+DROP INDEX IF EXISTS idx_product_modifier_groups_group;
+
+DROP INDEX IF EXISTS idx_modifier_options_group;
+
+ALTER TABLE "transaction_item_modifiers"
+DROP CONSTRAINT "fk_transaction_item_modifiers_updated_by";
+
+ALTER TABLE "transaction_item_modifiers"
+DROP CONSTRAINT "fk_transaction_item_modifiers_created_by";
+
+ALTER TABLE "transaction_item_modifiers"
+DROP CONSTRAINT "fk_transaction_item_modifiers_modifier_option_id";
+
+ALTER TABLE "transaction_item_modifiers"
+DROP CONSTRAINT "fk_transaction_item_modifiers_transaction_item_id";
+
+ALTER TABLE "cart_modifiers"
+DROP CONSTRAINT "fk_cart_modifiers_updated_by";
+
+ALTER TABLE "cart_modifiers"
+DROP CONSTRAINT "fk_cart_modifiers_created_by";
+
+ALTER TABLE "cart_modifiers"
+DROP CONSTRAINT "fk_cart_modifiers_modifier_option_id";
+
+ALTER TABLE "cart_modifiers"
+DROP CONSTRAINT "fk_cart_modifiers_cart_id";
+
+ALTER TABLE "product_modifier_groups"
+DROP CONSTRAINT "fk_product_modifier_groups_updated_by";
+
+ALTER TABLE "product_modifier_groups"
+DROP CONSTRAINT "fk_product_modifier_groups_created_by";
+
+ALTER TABLE "product_modifier_groups"
+DROP CONSTRAINT "fk_product_modifier_groups_modifier_group_id";
+
+ALTER TABLE "product_modifier_groups"
+DROP CONSTRAINT "fk_product_modifier_groups_product_id";
+
+ALTER TABLE "modifier_options"
+DROP CONSTRAINT "fk_modifier_options_updated_by";
+
+ALTER TABLE "modifier_options"
+DROP CONSTRAINT "fk_modifier_options_created_by";
+
+ALTER TABLE "modifier_options"
+DROP CONSTRAINT "fk_modifier_options_modifier_group_id";
+
+ALTER TABLE "modifier_groups"
+DROP CONSTRAINT "fk_modifier_groups_updated_by";
+
+ALTER TABLE "modifier_groups"
+DROP CONSTRAINT "fk_modifier_groups_created_by";
+
+DROP TABLE "transaction_item_modifiers";
+
+ALTER TABLE "transaction_items" DROP COLUMN "modifiers_cost";
+
+ALTER TABLE "carts" DROP COLUMN "modifier_key";
+
+DROP TABLE "cart_modifiers";
+
+DROP TABLE "product_modifier_groups";
+
+DROP TABLE "modifier_options";
+
+DROP TABLE "modifier_groups";
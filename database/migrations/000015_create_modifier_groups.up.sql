@@ -0,0 +1,126 @@
+CREATE TABLE "modifier_groups" (
+    "id" serial PRIMARY KEY,
+    "name" varchar(100) NOT NULL,
+    "min_select" int NOT NULL DEFAULT 0 CHECK ("min_select" >= 0),
+    "max_select" int NOT NULL DEFAULT 1 CHECK ("max_select" > 0),
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK ("max_select" >= "min_select")
+);
+
+CREATE TABLE "modifier_options" (
+    "id" serial PRIMARY KEY,
+    "modifier_group_id" int NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "price" numeric(10, 2) NOT NULL DEFAULT 0 CHECK ("price" >= 0),
+    "is_active" bool NOT NULL DEFAULT true,
+    "sort_order" int NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("modifier_group_id", "name")
+);
+
+CREATE TABLE "product_modifier_groups" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "modifier_group_id" int NOT NULL,
+    "sort_order" int NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("product_id", "modifier_group_id")
+);
+
+CREATE TABLE "cart_modifiers" (
+    "id" serial PRIMARY KEY,
+    "cart_id" int NOT NULL,
+    "modifier_option_id" int NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("cart_id", "modifier_option_id")
+);
+
+-- sorted option ids of the line, the same product with the same choices is merged into one line
+ALTER TABLE "carts" ADD COLUMN "modifier_key" text NOT NULL DEFAULT '';
+
+ALTER TABLE "transaction_items" ADD COLUMN "modifiers_cost" numeric(10, 2) NOT NULL DEFAULT 0;
+
+-- names and prices are copied so the order keeps what was paid after the menu changes
+CREATE TABLE "transaction_item_modifiers" (
+    "id" serial PRIMARY KEY,
+    "transaction_item_id" int NOT NULL,
+    "modifier_option_id" int,
+    "group_name" varchar(100) NOT NULL,
+    "option_name" varchar(100) NOT NULL,
+    "price" numeric(10, 2) NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "modifier_groups"
+ADD CONSTRAINT "fk_modifier_groups_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "modifier_groups"
+ADD CONSTRAINT "fk_modifier_groups_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "modifier_options"
+ADD CONSTRAINT "fk_modifier_options_modifier_group_id" FOREIGN KEY ("modifier_group_id") REFERENCES "modifier_groups" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "modifier_options"
+ADD CONSTRAINT "fk_modifier_options_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "modifier_options"
+ADD CONSTRAINT "fk_modifier_options_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_modifier_groups"
+ADD CONSTRAINT "fk_product_modifier_groups_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_modifier_groups"
+ADD CONSTRAINT "fk_product_modifier_groups_modifier_group_id" FOREIGN KEY ("modifier_group_id") REFERENCES "modifier_groups" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_modifier_groups"
+ADD CONSTRAINT "fk_product_modifier_groups_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_modifier_groups"
+ADD CONSTRAINT "fk_product_modifier_groups_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "cart_modifiers"
+ADD CONSTRAINT "fk_cart_modifiers_cart_id" FOREIGN KEY ("cart_id") REFERENCES "carts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "cart_modifiers"
+ADD CONSTRAINT "fk_cart_modifiers_modifier_option_id" FOREIGN KEY ("modifier_option_id") REFERENCES "modifier_options" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "cart_modifiers"
+ADD CONSTRAINT "fk_cart_modifiers_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "cart_modifiers"
+ADD CONSTRAINT "fk_cart_modifiers_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "transaction_item_modifiers"
+ADD CONSTRAINT "fk_transaction_item_modifiers_transaction_item_id" FOREIGN KEY ("transaction_item_id") REFERENCES "transaction_items" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transaction_item_modifiers"
+ADD CONSTRAINT "fk_transaction_item_modifiers_modifier_option_id" FOREIGN KEY ("modifier_option_id") REFERENCES "modifier_options" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "transaction_item_modifiers"
+ADD CONSTRAINT "fk_transaction_item_modifiers_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "transaction_item_modifiers"
+ADD CONSTRAINT "fk_transaction_item_modifiers_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_modifier_options_group ON modifier_options (modifier_group_id, sort_order);
+
+CREATE INDEX idx_product_modifier_groups_group ON product_modifier_groups (modifier_group_id);
+
+CREATE INDEX idx_cart_modifiers_option ON cart_modifiers (modifier_option_id);
+
+CREATE INDEX idx_transaction_item_modifiers_item ON transaction_item_modifiers (transaction_item_id);
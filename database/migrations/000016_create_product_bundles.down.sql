@@ -0,0 +1,55 @@
+DROP TRIGGER IF EXISTS "trg_bundle_items_stock" ON "product_bundle_items";
+
+DROP TRIGGER IF EXISTS "trg_bundle_component_stock" ON "products";
+
+DROP TRIGGER IF EXISTS "trg_bundle_own_stock" ON "products";
+
+DROP FUNCTION IF EXISTS bundle_items_stock_trigger();
+
+DROP FUNCTION IF EXISTS bundle_component_stock_trigger();
+
+DROP FUNCTION IF EXISTS bundle_own_stock_trigger();
+
+DROP FUNCTION IF EXISTS bundle_stock(int);
+
+DROP INDEX IF EXISTS idx_transaction_item_components_item;
+
+DROP INDEX IF EXISTS idx_product_bundle_items_component;
+
+DROP INDEX IF EXISTS idx_product_bundle_items_bundle;
+
+ALTER TABLE "transaction_item_components"
+DROP CONSTRAINT "fk_transaction_item_components_updated_by";
+
+ALTER TABLE "transaction_item_components"
+DROP CONSTRAINT "fk_transaction_item_components_created_by";
+
+ALTER TABLE "transaction_item_components"
+DROP CONSTRAINT "fk_transaction_item_components_product_id";
+
+ALTER TABLE "transaction_item_components"
+DROP CONSTRAINT "fk_transaction_item_components_transaction_item_id";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_updated_by";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_created_by";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_variant_id";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_size_id";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_component_product_id";
+
+ALTER TABLE "product_bundle_items"
+DROP CONSTRAINT "fk_product_bundle_items_bundle_product_id";
+
+DROP TABLE "transaction_item_components";
+
+DROP TABLE "product_bundle_items";
+
+ALTER TABLE "products" DROP COLUMN "is_bundle";
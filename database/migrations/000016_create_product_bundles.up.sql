@@ -0,0 +1,129 @@
+ALTER TABLE "products" ADD COLUMN "is_bundle" bool NOT NULL DEFAULT false;
+
+CREATE TABLE "product_bundle_items" (
+    "id" serial PRIMARY KEY,
+    "bundle_product_id" int NOT NULL,
+    "component_product_id" int NOT NULL,
+    "size_id" int,
+    "variant_id" int,
+    "quantity" int NOT NULL DEFAULT 1 CHECK ("quantity" > 0),
+    "sort_order" int NOT NULL DEFAULT 0,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK ("bundle_product_id" <> "component_product_id")
+);
+
+-- components are copied so the order shows what the barista prepares after the bundle changes
+CREATE TABLE "transaction_item_components" (
+    "id" serial PRIMARY KEY,
+    "transaction_item_id" int NOT NULL,
+    "product_id" int,
+    "product_name" varchar(255) NOT NULL,
+    "size" varchar(10),
+    "variant" varchar(50),
+    "quantity" int NOT NULL CHECK ("quantity" > 0),
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_bundle_product_id" FOREIGN KEY ("bundle_product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_component_product_id" FOREIGN KEY ("component_product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_size_id" FOREIGN KEY ("size_id") REFERENCES "sizes" ("id");
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_variant_id" FOREIGN KEY ("variant_id") REFERENCES "variants" ("id");
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_bundle_items"
+ADD CONSTRAINT "fk_product_bundle_items_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "transaction_item_components"
+ADD CONSTRAINT "fk_transaction_item_components_transaction_item_id" FOREIGN KEY ("transaction_item_id") REFERENCES "transaction_items" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transaction_item_components"
+ADD CONSTRAINT "fk_transaction_item_components_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "transaction_item_components"
+ADD CONSTRAINT "fk_transaction_item_components_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "transaction_item_components"
+ADD CONSTRAINT "fk_transaction_item_components_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_product_bundle_items_bundle ON product_bundle_items (bundle_product_id, sort_order);
+
+CREATE INDEX idx_product_bundle_items_component ON product_bundle_items (component_product_id);
+
+CREATE INDEX idx_transaction_item_components_item ON transaction_item_components (transaction_item_id);
+
+-- how many bundles the components allow, a deleted component makes the bundle unavailable
+CREATE OR REPLACE FUNCTION bundle_stock(bundle_id int) RETURNS int AS $$
+    SELECT COALESCE(MIN(CASE WHEN p.deleted_at IS NULL THEN COALESCE(p.stock, 0) / c.quantity ELSE 0 END), 0)::int
+    FROM (
+        SELECT component_product_id, SUM(quantity) AS quantity
+        FROM product_bundle_items
+        WHERE bundle_product_id = bundle_id
+        GROUP BY component_product_id
+    ) c
+    JOIN products p ON p.id = c.component_product_id
+$$ LANGUAGE sql STABLE;
+
+-- the stock of a bundle is never written directly, every write is replaced by what its components allow
+CREATE OR REPLACE FUNCTION bundle_own_stock_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.stock := bundle_stock(NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_bundle_own_stock"
+BEFORE INSERT OR UPDATE OF stock, is_bundle ON "products"
+FOR EACH ROW
+WHEN (NEW.is_bundle)
+EXECUTE FUNCTION bundle_own_stock_trigger();
+
+-- touching the stock of the bundles fires trg_bundle_own_stock, which recalculates it
+CREATE OR REPLACE FUNCTION bundle_component_stock_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET stock = 0
+    WHERE is_bundle = true
+    AND id IN (SELECT bundle_product_id FROM product_bundle_items WHERE component_product_id = NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_bundle_component_stock"
+AFTER UPDATE OF stock, deleted_at ON "products"
+FOR EACH ROW
+WHEN (NOT NEW.is_bundle AND (NEW.stock IS DISTINCT FROM OLD.stock OR NEW.deleted_at IS DISTINCT FROM OLD.deleted_at))
+EXECUTE FUNCTION bundle_component_stock_trigger();
+
+CREATE OR REPLACE FUNCTION bundle_items_stock_trigger() RETURNS trigger AS $$
+DECLARE
+    bundle_id int;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        bundle_id := OLD.bundle_product_id;
+    ELSE
+        bundle_id := NEW.bundle_product_id;
+    END IF;
+
+    UPDATE products SET stock = 0 WHERE id = bundle_id AND is_bundle = true;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_bundle_items_stock"
+AFTER INSERT OR UPDATE OR DELETE ON "product_bundle_items"
+FOR EACH ROW
+EXECUTE FUNCTION bundle_items_stock_trigger();
@@ -0,0 +1,56 @@
+DROP INDEX IF EXISTS idx_carts_sku_id;
+
+DROP TRIGGER IF EXISTS "trg_product_skus_stock" ON "product_skus";
+
+DROP TRIGGER IF EXISTS "trg_product_sku_own_stock" ON "products";
+
+DROP FUNCTION IF EXISTS product_skus_stock_trigger();
+
+DROP FUNCTION IF EXISTS product_sku_own_stock_trigger();
+
+CREATE OR REPLACE FUNCTION bundle_stock(bundle_id int) RETURNS int AS $$
+    SELECT COALESCE(MIN(CASE WHEN p.deleted_at IS NULL THEN COALESCE(p.stock, 0) / c.quantity ELSE 0 END), 0)::int
+    FROM (
+        SELECT component_product_id, SUM(quantity) AS quantity
+        FROM product_bundle_items
+        WHERE bundle_product_id = bundle_id
+        GROUP BY component_product_id
+    ) c
+    JOIN products p ON p.id = c.component_product_id
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS sku_stock(int, int, int);
+
+DROP INDEX IF EXISTS uq_product_skus_combination;
+
+ALTER TABLE "transaction_item_components"
+DROP CONSTRAINT "fk_transaction_item_components_sku_id";
+
+ALTER TABLE "transaction_items"
+DROP CONSTRAINT "fk_transaction_items_sku_id";
+
+ALTER TABLE "carts"
+DROP CONSTRAINT "fk_carts_sku_id";
+
+ALTER TABLE "product_skus"
+DROP CONSTRAINT "fk_product_skus_updated_by";
+
+ALTER TABLE "product_skus"
+DROP CONSTRAINT "fk_product_skus_created_by";
+
+ALTER TABLE "product_skus"
+DROP CONSTRAINT "fk_product_skus_variant_id";
+
+ALTER TABLE "product_skus"
+DROP CONSTRAINT "fk_product_skus_size_id";
+
+ALTER TABLE "product_skus"
+DROP CONSTRAINT "fk_product_skus_product_id";
+
+ALTER TABLE "transaction_item_components" DROP COLUMN "sku_id";
+
+ALTER TABLE "transaction_items" DROP COLUMN "sku_id";
+
+ALTER TABLE "carts" DROP COLUMN "sku_id";
+
+DROP TABLE "product_skus";
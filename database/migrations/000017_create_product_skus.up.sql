@@ -0,0 +1,119 @@
+CREATE TABLE "product_skus" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "size_id" int,
+    "variant_id" int,
+    "sku_code" varchar(64) UNIQUE,
+    "stock" int NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+    "price" numeric(10, 2) CHECK ("price" > 0),
+    "is_available" bool NOT NULL DEFAULT true,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "carts" ADD COLUMN "sku_id" int;
+
+ALTER TABLE "transaction_items" ADD COLUMN "sku_id" int;
+
+ALTER TABLE "transaction_item_components" ADD COLUMN "sku_id" int;
+
+ALTER TABLE "product_skus"
+ADD CONSTRAINT "fk_product_skus_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_skus"
+ADD CONSTRAINT "fk_product_skus_size_id" FOREIGN KEY ("size_id") REFERENCES "sizes" ("id");
+
+ALTER TABLE "product_skus"
+ADD CONSTRAINT "fk_product_skus_variant_id" FOREIGN KEY ("variant_id") REFERENCES "variants" ("id");
+
+ALTER TABLE "product_skus"
+ADD CONSTRAINT "fk_product_skus_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_skus"
+ADD CONSTRAINT "fk_product_skus_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "carts"
+ADD CONSTRAINT "fk_carts_sku_id" FOREIGN KEY ("sku_id") REFERENCES "product_skus" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transaction_items"
+ADD CONSTRAINT "fk_transaction_items_sku_id" FOREIGN KEY ("sku_id") REFERENCES "product_skus" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "transaction_item_components"
+ADD CONSTRAINT "fk_transaction_item_components_sku_id" FOREIGN KEY ("sku_id") REFERENCES "product_skus" ("id") ON DELETE SET NULL;
+
+-- one SKU per size and variant combination, a product without sizes or variants has a single SKU
+CREATE UNIQUE INDEX uq_product_skus_combination ON product_skus (product_id, COALESCE(size_id, 0), COALESCE(variant_id, 0));
+
+-- stock a product and size and variant can be sold from, products without SKUs keep their product stock
+CREATE OR REPLACE FUNCTION sku_stock(sku_product_id int, sku_size_id int, sku_variant_id int) RETURNS int AS $$
+    SELECT CASE
+        WHEN EXISTS (SELECT 1 FROM product_skus WHERE product_id = sku_product_id) THEN
+            COALESCE((
+                SELECT stock FROM product_skus
+                WHERE product_id = sku_product_id
+                AND size_id IS NOT DISTINCT FROM sku_size_id
+                AND variant_id IS NOT DISTINCT FROM sku_variant_id
+                AND is_available = true
+            ), 0)
+        ELSE (SELECT COALESCE(stock, 0) FROM products WHERE id = sku_product_id)
+    END
+$$ LANGUAGE sql STABLE;
+
+-- bundle components are counted on the SKU of the size and variant they are served in
+CREATE OR REPLACE FUNCTION bundle_stock(bundle_id int) RETURNS int AS $$
+    SELECT COALESCE(MIN(CASE WHEN p.deleted_at IS NULL THEN sku_stock(c.component_product_id, c.size_id, c.variant_id) / c.quantity ELSE 0 END), 0)::int
+    FROM (
+        SELECT component_product_id, size_id, variant_id, SUM(quantity) AS quantity
+        FROM product_bundle_items
+        WHERE bundle_product_id = bundle_id
+        GROUP BY component_product_id, size_id, variant_id
+    ) c
+    JOIN products p ON p.id = c.component_product_id
+$$ LANGUAGE sql STABLE;
+
+-- the stock of a product with SKUs is what its available SKUs hold, writes to it are replaced
+CREATE OR REPLACE FUNCTION product_sku_own_stock_trigger() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM product_skus WHERE product_id = NEW.id) THEN
+        NEW.stock := (SELECT COALESCE(SUM(stock), 0) FROM product_skus WHERE product_id = NEW.id AND is_available = true);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_sku_own_stock"
+BEFORE UPDATE OF stock ON "products"
+FOR EACH ROW
+WHEN (NOT NEW.is_bundle)
+EXECUTE FUNCTION product_sku_own_stock_trigger();
+
+-- touching the product stock fires trg_product_sku_own_stock, which sums the SKUs,
+-- and trg_bundle_component_stock when the sum changed
+CREATE OR REPLACE FUNCTION product_skus_stock_trigger() RETURNS trigger AS $$
+DECLARE
+    sku_product_id int;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        sku_product_id := OLD.product_id;
+    ELSE
+        sku_product_id := NEW.product_id;
+    END IF;
+
+    UPDATE products SET stock = stock WHERE id = sku_product_id AND is_bundle = false;
+
+    -- availability can change without the sum changing, bundles using the product are refreshed anyway
+    UPDATE products SET stock = 0
+    WHERE is_bundle = true
+    AND id IN (SELECT bundle_product_id FROM product_bundle_items WHERE component_product_id = sku_product_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_skus_stock"
+AFTER INSERT OR UPDATE OR DELETE ON "product_skus"
+FOR EACH ROW
+EXECUTE FUNCTION product_skus_stock_trigger();
+
+CREATE INDEX idx_carts_sku_id ON carts (sku_id);
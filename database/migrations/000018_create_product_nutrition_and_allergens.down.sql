@@ -0,0 +1,39 @@
+DROP INDEX IF EXISTS idx_product_allergens_allergen_id;
+
+DROP INDEX IF EXISTS uq_product_nutrition_size;
+
+ALTER TABLE "product_nutrition"
+DROP CONSTRAINT "fk_product_nutrition_updated_by";
+
+ALTER TABLE "product_nutrition"
+DROP CONSTRAINT "fk_product_nutrition_created_by";
+
+ALTER TABLE "product_nutrition"
+DROP CONSTRAINT "fk_product_nutrition_size_id";
+
+ALTER TABLE "product_nutrition"
+DROP CONSTRAINT "fk_product_nutrition_product_id";
+
+ALTER TABLE "product_allergens"
+DROP CONSTRAINT "fk_product_allergens_updated_by";
+
+ALTER TABLE "product_allergens"
+DROP CONSTRAINT "fk_product_allergens_created_by";
+
+ALTER TABLE "product_allergens"
+DROP CONSTRAINT "fk_product_allergens_allergen_id";
+
+ALTER TABLE "product_allergens"
+DROP CONSTRAINT "fk_product_allergens_product_id";
+
+ALTER TABLE "allergens"
+DROP CONSTRAINT "fk_allergens_updated_by";
+
+ALTER TABLE "allergens"
+DROP CONSTRAINT "fk_allergens_created_by";
+
+DROP TABLE "product_nutrition";
+
+DROP TABLE "product_allergens";
+
+DROP TABLE "allergens";
@@ -0,0 +1,86 @@
+CREATE TABLE "allergens" (
+    "id" serial PRIMARY KEY,
+    "name" varchar(50) UNIQUE NOT NULL,
+    "slug" varchar(50) UNIQUE NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+CREATE TABLE "product_allergens" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "allergen_id" int NOT NULL,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    UNIQUE ("product_id", "allergen_id")
+);
+
+-- a row without size is the base of the product, a size row replaces it for that size
+CREATE TABLE "product_nutrition" (
+    "id" serial PRIMARY KEY,
+    "product_id" int NOT NULL,
+    "size_id" int,
+    "calories" numeric(8, 2) CHECK ("calories" >= 0),
+    "fat" numeric(8, 2) CHECK ("fat" >= 0),
+    "carbohydrate" numeric(8, 2) CHECK ("carbohydrate" >= 0),
+    "sugar" numeric(8, 2) CHECK ("sugar" >= 0),
+    "fiber" numeric(8, 2) CHECK ("fiber" >= 0),
+    "protein" numeric(8, 2) CHECK ("protein" >= 0),
+    "sodium" numeric(8, 2) CHECK ("sodium" >= 0),
+    "caffeine" numeric(8, 2) CHECK ("caffeine" >= 0),
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int
+);
+
+ALTER TABLE "allergens"
+ADD CONSTRAINT "fk_allergens_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "allergens"
+ADD CONSTRAINT "fk_allergens_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_allergens"
+ADD CONSTRAINT "fk_product_allergens_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_allergens"
+ADD CONSTRAINT "fk_product_allergens_allergen_id" FOREIGN KEY ("allergen_id") REFERENCES "allergens" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_allergens"
+ADD CONSTRAINT "fk_product_allergens_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_allergens"
+ADD CONSTRAINT "fk_product_allergens_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_nutrition"
+ADD CONSTRAINT "fk_product_nutrition_product_id" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_nutrition"
+ADD CONSTRAINT "fk_product_nutrition_size_id" FOREIGN KEY ("size_id") REFERENCES "sizes" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "product_nutrition"
+ADD CONSTRAINT "fk_product_nutrition_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "product_nutrition"
+ADD CONSTRAINT "fk_product_nutrition_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE UNIQUE INDEX uq_product_nutrition_size ON product_nutrition (product_id, COALESCE(size_id, 0));
+
+CREATE INDEX idx_product_allergens_allergen_id ON product_allergens (allergen_id);
+
+-- allergens are a fixed list, there is no admin endpoint to manage them
+INSERT INTO
+    allergens (name, slug)
+VALUES ('Dairy', 'dairy'),
+    ('Eggs', 'eggs'),
+    ('Gluten', 'gluten'),
+    ('Nuts', 'nuts'),
+    ('Peanuts', 'peanuts'),
+    ('Soy', 'soy'),
+    ('Sesame', 'sesame'),
+    ('Fish', 'fish'),
+    ('Shellfish', 'shellfish');
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_products_unpublish_at;
+
+DROP INDEX IF EXISTS idx_products_publish_at;
+
+ALTER TABLE "products" DROP CONSTRAINT "chk_products_available_hours";
+
+ALTER TABLE "products" DROP CONSTRAINT "chk_products_publish_window";
+
+ALTER TABLE "products" DROP COLUMN "available_until";
+
+ALTER TABLE "products" DROP COLUMN "available_from";
+
+ALTER TABLE "products" DROP COLUMN "unpublish_at";
+
+ALTER TABLE "products" DROP COLUMN "publish_at";
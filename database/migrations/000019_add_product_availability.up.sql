@@ -0,0 +1,21 @@
+ALTER TABLE "products" ADD COLUMN "publish_at" timestamptz;
+
+ALTER TABLE "products" ADD COLUMN "unpublish_at" timestamptz;
+
+-- daily hours in the store timezone, a window ending before it starts runs past midnight
+ALTER TABLE "products" ADD COLUMN "available_from" time;
+
+ALTER TABLE "products" ADD COLUMN "available_until" time;
+
+ALTER TABLE "products"
+ADD CONSTRAINT "chk_products_publish_window" CHECK ("publish_at" IS NULL OR "unpublish_at" IS NULL OR "unpublish_at" > "publish_at");
+
+ALTER TABLE "products"
+ADD CONSTRAINT "chk_products_available_hours" CHECK (
+    ("available_from" IS NULL AND "available_until" IS NULL)
+    OR ("available_from" IS NOT NULL AND "available_until" IS NOT NULL AND "available_from" <> "available_until")
+);
+
+CREATE INDEX idx_products_publish_at ON products (publish_at) WHERE publish_at IS NOT NULL;
+
+CREATE INDEX idx_products_unpublish_at ON products (unpublish_at) WHERE unpublish_at IS NOT NULL;
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_transactions_scheduled_at;
+
+ALTER TABLE "store_holidays"
+DROP CONSTRAINT "fk_store_holidays_updated_by";
+
+ALTER TABLE "store_holidays"
+DROP CONSTRAINT "fk_store_holidays_created_by";
+
+ALTER TABLE "store_hours"
+DROP CONSTRAINT "fk_store_hours_updated_by";
+
+ALTER TABLE "store_hours"
+DROP CONSTRAINT "fk_store_hours_created_by";
+
+ALTER TABLE "transactions" DROP COLUMN "scheduled_at";
+
+DROP TABLE "store_holidays";
+
+DROP TABLE "store_hours";
@@ -0,0 +1,46 @@
+-- weekly opening hours in the store timezone, weekday 0 is Sunday.
+-- A weekday without a row is open all day, a window closing before it opens runs past midnight.
+CREATE TABLE "store_hours" (
+    "id" serial PRIMARY KEY,
+    "weekday" smallint UNIQUE NOT NULL CHECK ("weekday" BETWEEN 0 AND 6),
+    "open_time" time,
+    "close_time" time,
+    "is_closed" bool NOT NULL DEFAULT false,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK ("is_closed" OR ("open_time" IS NOT NULL AND "close_time" IS NOT NULL AND "open_time" <> "close_time"))
+);
+
+-- a holiday replaces the weekly hours of its date, closed all day or with its own hours
+CREATE TABLE "store_holidays" (
+    "id" serial PRIMARY KEY,
+    "date" date UNIQUE NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "open_time" time,
+    "close_time" time,
+    "is_closed" bool NOT NULL DEFAULT true,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK ("is_closed" OR ("open_time" IS NOT NULL AND "close_time" IS NOT NULL AND "open_time" <> "close_time"))
+);
+
+-- orders placed while the store is closed are made when it opens
+ALTER TABLE "transactions" ADD COLUMN "scheduled_at" timestamptz;
+
+ALTER TABLE "store_hours"
+ADD CONSTRAINT "fk_store_hours_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "store_hours"
+ADD CONSTRAINT "fk_store_hours_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "store_holidays"
+ADD CONSTRAINT "fk_store_holidays_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "store_holidays"
+ADD CONSTRAINT "fk_store_holidays_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+CREATE INDEX idx_transactions_scheduled_at ON transactions (scheduled_at) WHERE scheduled_at IS NOT NULL;
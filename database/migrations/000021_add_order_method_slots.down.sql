@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transactions_order_method_slot;
+
+ALTER TABLE "order_methods"
+DROP COLUMN "slot_days_ahead",
+DROP COLUMN "slot_lead_minutes",
+DROP COLUMN "slot_capacity",
+DROP COLUMN "slot_minutes";
@@ -0,0 +1,10 @@
+-- scheduled orders are taken in slots of slot_minutes from the store opening,
+-- each slot takes up to slot_capacity orders of the order method
+ALTER TABLE "order_methods"
+ADD COLUMN "slot_minutes" int NOT NULL DEFAULT 15 CHECK ("slot_minutes" BETWEEN 5 AND 240),
+ADD COLUMN "slot_capacity" int NOT NULL DEFAULT 10 CHECK ("slot_capacity" > 0),
+ADD COLUMN "slot_lead_minutes" int NOT NULL DEFAULT 15 CHECK ("slot_lead_minutes" >= 0),
+ADD COLUMN "slot_days_ahead" int NOT NULL DEFAULT 7 CHECK ("slot_days_ahead" BETWEEN 0 AND 30);
+
+-- reserved capacity of a slot is counted from its orders
+CREATE INDEX idx_transactions_order_method_slot ON transactions (order_method_id, scheduled_at) WHERE scheduled_at IS NOT NULL;
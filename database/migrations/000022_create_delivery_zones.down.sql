@@ -0,0 +1,25 @@
+DROP INDEX IF EXISTS idx_delivery_zones_order_method_id;
+
+ALTER TABLE "transactions"
+DROP CONSTRAINT "fk_transactions_delivery_zone_id";
+
+ALTER TABLE "delivery_zone_points"
+DROP CONSTRAINT "fk_delivery_zone_points_delivery_zone_id";
+
+ALTER TABLE "delivery_zones"
+DROP CONSTRAINT "fk_delivery_zones_updated_by";
+
+ALTER TABLE "delivery_zones"
+DROP CONSTRAINT "fk_delivery_zones_created_by";
+
+ALTER TABLE "delivery_zones"
+DROP CONSTRAINT "fk_delivery_zones_order_method_id";
+
+ALTER TABLE "transactions"
+DROP COLUMN "delivery_longitude",
+DROP COLUMN "delivery_latitude",
+DROP COLUMN "delivery_zone_id";
+
+DROP TABLE "delivery_zone_points";
+
+DROP TABLE "delivery_zones";
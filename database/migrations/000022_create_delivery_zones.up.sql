@@ -0,0 +1,54 @@
+-- an order method with zones charges the fee of the first zone, by sort_order, the customer coordinate falls in.
+-- A radius zone is a ring around the store coordinate, a polygon zone is outlined by its points.
+CREATE TABLE "delivery_zones" (
+    "id" serial PRIMARY KEY,
+    "order_method_id" int NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "kind" varchar(10) NOT NULL CHECK ("kind" IN ('radius', 'polygon')),
+    "min_radius_km" numeric(6, 2),
+    "max_radius_km" numeric(6, 2),
+    "delivery_fee" numeric(10, 2) NOT NULL DEFAULT 0 CHECK ("delivery_fee" >= 0),
+    "min_order" numeric(10, 2) NOT NULL DEFAULT 0 CHECK ("min_order" >= 0),
+    "sort_order" int NOT NULL DEFAULT 0,
+    "is_active" bool NOT NULL DEFAULT true,
+    "created_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "updated_at" timestamp DEFAULT (CURRENT_TIMESTAMP),
+    "created_by" int,
+    "updated_by" int,
+    CHECK (
+        ("kind" = 'radius' AND "min_radius_km" >= 0 AND "max_radius_km" > "min_radius_km")
+        OR ("kind" = 'polygon' AND "min_radius_km" IS NULL AND "max_radius_km" IS NULL)
+    )
+);
+
+CREATE TABLE "delivery_zone_points" (
+    "id" serial PRIMARY KEY,
+    "delivery_zone_id" int NOT NULL,
+    "position" int NOT NULL,
+    "latitude" numeric(9, 6) NOT NULL CHECK ("latitude" BETWEEN -90 AND 90),
+    "longitude" numeric(9, 6) NOT NULL CHECK ("longitude" BETWEEN -180 AND 180),
+    UNIQUE ("delivery_zone_id", "position")
+);
+
+-- the zone and coordinate the delivery fee of an order was computed from
+ALTER TABLE "transactions"
+ADD COLUMN "delivery_zone_id" int,
+ADD COLUMN "delivery_latitude" numeric(9, 6),
+ADD COLUMN "delivery_longitude" numeric(9, 6);
+
+ALTER TABLE "delivery_zones"
+ADD CONSTRAINT "fk_delivery_zones_order_method_id" FOREIGN KEY ("order_method_id") REFERENCES "order_methods" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "delivery_zones"
+ADD CONSTRAINT "fk_delivery_zones_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("id");
+
+ALTER TABLE "delivery_zones"
+ADD CONSTRAINT "fk_delivery_zones_updated_by" FOREIGN KEY ("updated_by") REFERENCES "users" ("id");
+
+ALTER TABLE "delivery_zone_points"
+ADD CONSTRAINT "fk_delivery_zone_points_delivery_zone_id" FOREIGN KEY ("delivery_zone_id") REFERENCES "delivery_zones" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transactions"
+ADD CONSTRAINT "fk_transactions_delivery_zone_id" FOREIGN KEY ("delivery_zone_id") REFERENCES "delivery_zones" ("id") ON DELETE SET NULL;
+
+CREATE INDEX idx_delivery_zones_order_method_id ON delivery_zones (order_method_id, sort_order);
@@ -58,11 +58,11 @@ VALUES (
 
 -- insert categories
 INSERT INTO
-    categories (name, created_by, updated_by)
-VALUES ('smoothies', 1, 1),
-    ('juices', 1, 1),
-    ('drinks', 1, 1),
-    ('foods', 1, 1);
+    categories (name, slug, created_by, updated_by)
+VALUES ('smoothies', 'smoothies', 1, 1),
+    ('juices', 'juices', 1, 1),
+    ('drinks', 'drinks', 1, 1),
+    ('foods', 'foods', 1, 1);
 
 -- insert sizes
 INSERT INTO
@@ -2,11 +2,20 @@ package lib
 
 import (
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenLifetime is how long a token stays valid after login
+const TokenLifetime = 24 * time.Hour
+
+// RevokedUserKey is the redis key that rejects every token of a deleted user until the last one expires
+func RevokedUserKey(userId int) string {
+	return "revoked:user:" + strconv.Itoa(userId)
+}
+
 type UserPayload struct {
 	Id   int    `json:"id"`
 	Role string `json:"role"`
@@ -19,7 +28,7 @@ func GenerateToken(id int, role string) (string, error) {
 		id,
 		role,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenLifetime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -10,8 +10,12 @@ import (
 	"backend-daily-greens/config"
 	"backend-daily-greens/lib"
 	"backend-daily-greens/middlewares"
+	"backend-daily-greens/models"
 	"backend-daily-greens/routes"
+	"log"
 	"net/http"
+	"os"
+	"time"
 
 	_ "backend-daily-greens/docs"
 
@@ -26,8 +30,15 @@ func main() {
 	config.InitDatabase()
 	config.InitRedis()
 	config.InitSupabase()
+	config.InitStore()
 	defer config.CloseDatabase()
 
+	// background jobs, serverless deployments call the admin endpoints instead
+	if interval, err := time.ParseDuration(os.Getenv("RECOMMENDATION_JOB_INTERVAL")); err == nil && interval > 0 {
+		log.Printf("Recommendation job runs every %s", interval)
+		go models.RunRecommendationJob(interval)
+	}
+
 	r := gin.Default()
 
 	r.MaxMultipartMemory = 1 << 20
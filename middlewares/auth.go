@@ -12,58 +12,70 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func Auth() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		authHeader := ctx.Request.Header.Get("Authorization")
-		tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
-		if !found {
-			ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
-				Success: false,
-				Message: "Authorization header required or invalid format",
-			})
-			ctx.Abort()
-			return
+// verifyToken checks the bearer token of the request, on failure it returns the status code and message to respond with
+func verifyToken(ctx *gin.Context) (*lib.UserPayload, int, lib.ResponseError) {
+	authHeader := ctx.Request.Header.Get("Authorization")
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found {
+		return nil, http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "Authorization header required or invalid format",
 		}
+	}
 
-		blacklistKey := "blacklist:" + tokenString
-		exists, err := config.Rdb.Exists(context.Background(), blacklistKey).Result()
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, lib.ResponseError{
-				Success: false,
-				Message: "Failed to verify token",
-				Error:   err.Error(),
-			})
-			ctx.Abort()
-			return
+	token, err := jwt.ParseWithClaims(tokenString, &lib.UserPayload{}, func(token *jwt.Token) (any, error) {
+		return []byte(os.Getenv("APP_SECRET")), nil
+	})
+	if err != nil {
+		return nil, http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "Invalid or expired token",
 		}
+	}
 
-		if exists > 0 {
-			ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
-				Success: false,
-				Message: "Token has been revoked, please login again",
-			})
-			ctx.Abort()
-			return
+	claims, ok := token.Claims.(*lib.UserPayload)
+	if !ok {
+		return nil, http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "Invalid token claims",
 		}
+	}
 
-		token, err := jwt.ParseWithClaims(tokenString, &lib.UserPayload{}, func(token *jwt.Token) (any, error) {
-			return []byte(os.Getenv("APP_SECRET")), nil
-		})
-		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
-				Success: false,
-				Message: "Invalid or expired token",
-			})
-			ctx.Abort()
-			return
+	// a deleted user keeps a valid token until it expires, deleting the user sets a revocation key in redis
+	// for the token lifetime so both keys are checked in one pipelined round trip and postgres is never hit here
+	pipe := config.Rdb.Pipeline()
+	blacklisted := pipe.Exists(context.Background(), "blacklist:"+tokenString)
+	revoked := pipe.Exists(context.Background(), lib.RevokedUserKey(claims.Id))
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return nil, http.StatusInternalServerError, lib.ResponseError{
+			Success: false,
+			Message: "Failed to verify token",
+			Error:   err.Error(),
 		}
+	}
 
-		claims, ok := token.Claims.(*lib.UserPayload)
-		if !ok {
-			ctx.JSON(http.StatusUnauthorized, lib.ResponseError{
-				Success: false,
-				Message: "Invalid token claims",
-			})
+	if blacklisted.Val() > 0 {
+		return nil, http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "Token has been revoked, please login again",
+		}
+	}
+
+	if revoked.Val() > 0 {
+		return nil, http.StatusUnauthorized, lib.ResponseError{
+			Success: false,
+			Message: "Account has been deleted, please contact support",
+		}
+	}
+
+	return claims, http.StatusOK, lib.ResponseError{}
+}
+
+func Auth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, statusCode, response := verifyToken(ctx)
+		if claims == nil {
+			ctx.JSON(statusCode, response)
 			ctx.Abort()
 			return
 		}
@@ -74,3 +86,16 @@ func Auth() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// OptionalAuth sets userId and role when a valid bearer token is sent, any other request passes through anonymous
+func OptionalAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _, _ := verifyToken(ctx)
+		if claims != nil {
+			ctx.Set("userId", claims.Id)
+			ctx.Set("role", claims.Role)
+		}
+
+		ctx.Next()
+	}
+}
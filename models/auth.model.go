@@ -84,7 +84,7 @@ func GetUserByEmail(bodyLogin *Login) (QueryLogin, string, error) {
 	message := ""
 	user := QueryLogin{}
 	rows, err := config.DB.Query(context.Background(),
-		"SELECT id, password, role FROM users WHERE email = $1",
+		"SELECT id, password, role FROM users WHERE email = $1 AND deleted_at IS NULL",
 		bodyLogin.Email,
 	)
 	if err != nil {
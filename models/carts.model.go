@@ -10,33 +10,52 @@ import (
 )
 
 type Cart struct {
-	Id              int     `db:"id" json:"id"`
-	UserId          int     `db:"user_id" json:"userId"`
-	ProductId       int     `db:"product_id" json:"productId"`
-	ProductImage    string  `db:"product_image" json:"productImage"`
-	ProductName     string  `db:"product_name" json:"productName"`
-	ProductPrice    float64 `db:"product_price" json:"productPrice"`
-	IsFlashSale     bool    `db:"is_flash_sale" json:"isFlashSale"`
-	DiscountPercent float64 `db:"discount_percent" json:"discountPercent"`
-	DiscountPrice   float64 `db:"discount_price" json:"discountPrice"`
-	SizeName        string  `db:"size_name" json:"sizeName"`
-	SizeCost        float64 `db:"size_cost" json:"sizeCost"`
-	VariantName     string  `db:"variant_name" json:"variantName"`
-	VariantCost     float64 `db:"variant_cost" json:"variantCost"`
-	Amount          int     `db:"amount" json:"amount"`
-	Subtotal        float64 `db:"subtotal" json:"subtotal"`
+	Id              int                `db:"id" json:"id"`
+	UserId          int                `db:"user_id" json:"userId"`
+	ProductId       int                `db:"product_id" json:"productId"`
+	ProductImage    string             `db:"product_image" json:"productImage"`
+	ProductName     string             `db:"product_name" json:"productName"`
+	SkuId           *int               `db:"sku_id" json:"skuId"`
+	ProductPrice    float64            `db:"product_price" json:"productPrice"`
+	IsFlashSale     bool               `db:"is_flash_sale" json:"isFlashSale"`
+	DiscountPercent float64            `db:"discount_percent" json:"discountPercent"`
+	DiscountPrice   float64            `db:"discount_price" json:"discountPrice"`
+	SizeName        string             `db:"size_name" json:"sizeName"`
+	SizeCost        float64            `db:"size_cost" json:"sizeCost"`
+	VariantName     string             `db:"variant_name" json:"variantName"`
+	VariantCost     float64            `db:"variant_cost" json:"variantCost"`
+	ModifiersCost   float64            `db:"modifiers_cost" json:"modifiersCost"`
+	Modifiers       []SelectedModifier `db:"modifiers" json:"modifiers"`
+	IsBundle        bool               `db:"is_bundle" json:"isBundle"`
+	IsAvailableNow  bool               `db:"is_available_now" json:"isAvailableNow"`
+	Components      []BundleComponent  `db:"components" json:"components"`
+	Amount          int                `db:"amount" json:"amount"`
+	Subtotal        float64            `db:"subtotal" json:"subtotal"`
+	// running flash sale the price comes from, its quantity cap is claimed at checkout
+	FlashSaleProductId *int `db:"flash_sale_product_id" json:"-"`
 }
 
 type CartRequest struct {
-	Id        int     `json:"id" swaggerignore:"true"`
-	UserId    int     `json:"userId" swaggerignore:"true"`
-	ProductId int     `json:"productId"`
-	SizeId    int     `json:"sizeId"`
-	VariantId int     `json:"variantId"`
-	Amount    int     `json:"amount"`
-	Subtotal  float64 `json:"subtotal" swaggerignore:"true"`
+	Id        int `json:"id" swaggerignore:"true"`
+	UserId    int `json:"userId" swaggerignore:"true"`
+	ProductId int `json:"productId"`
+	SizeId    int `json:"sizeId"`
+	VariantId int `json:"variantId"`
+	// options of the modifier groups attached to the product
+	ModifierOptionIds []int   `json:"modifierOptionIds"`
+	Amount            int     `json:"amount"`
+	Subtotal          float64 `json:"subtotal" swaggerignore:"true"`
 }
 
+// cartPriceColumns is productPriceColumns on the price of the SKU in the cart, joined as "sk"
+const cartPriceColumns = `
+			COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) AS discount_percent,
+			CASE
+				WHEN COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) = 0 THEN 0
+				ELSE COALESCE(sk.price, p.price) * (1 - (COALESCE(MAX(fs.discount_percent), p.discount_percent) / 100.0))
+			END AS discount_price,
+			(COALESCE(p.is_flash_sale, false) OR COUNT(fs.product_id) > 0) AS is_flash_sale`
+
 func GetListCart(userId int) ([]Cart, string, error) {
 	carts := []Cart{}
 	message := ""
@@ -50,27 +69,61 @@ func GetListCart(userId int) ([]Cart, string, error) {
 			c.product_id, 
 			COALESCE(MAX(pi.product_image), '') AS product_image, 
 			p.name AS product_name,
-			p.price AS product_price,
-			p.is_flash_sale,
-			p.discount_percent,
-			CASE 
-				WHEN p.discount_percent = 0 OR p.discount_percent IS NULL THEN 0
-				ELSE p.price * (1 - (p.discount_percent/100.0))
-			END AS discount_price,
-			s.name AS size_name,
-			s.size_cost AS size_cost,
-			v.name AS variant_name,
-			v.variant_cost AS variant_cost,
-			c.amount, 
-			c.subtotal
+			c.sku_id,
+			COALESCE(sk.price, p.price) AS product_price,
+			`+cartPriceColumns+`,
+			MAX(fs.flash_sale_product_id) AS flash_sale_product_id,
+			COALESCE(s.name, '') AS size_name,
+			COALESCE(s.size_cost, 0) AS size_cost,
+			COALESCE(v.name, '') AS variant_name,
+			COALESCE(v.variant_cost, 0) AS variant_cost,
+			m.modifiers_cost,
+			m.modifiers,
+			p.is_bundle,
+			`+productAvailableNowColumn(2)+`,
+			b.components,
+			c.amount,
+			(COALESCE(sk.price, p.price) * (1 - (COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) / 100.0)) + COALESCE(s.size_cost, 0) + COALESCE(v.variant_cost, 0) + m.modifiers_cost) * c.amount AS subtotal
 			FROM carts c
 		LEFT JOIN products p ON p.id = c.product_id
+		LEFT JOIN product_skus sk ON sk.id = c.sku_id
 		LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
 		LEFT JOIN sizes s  ON s.id = c.size_id
 		LEFT JOIN variants v ON v.id = c.variant_id
+		LEFT JOIN LATERAL (
+			SELECT
+				COALESCE(SUM(o.price), 0) AS modifiers_cost,
+				COALESCE(
+					JSONB_AGG(
+						JSONB_BUILD_OBJECT('modifierOptionId', o.id, 'groupName', g.name, 'optionName', o.name, 'price', o.price)
+						ORDER BY g.id, o.sort_order, o.id
+					) FILTER (WHERE o.id IS NOT NULL),
+					'[]'
+				) AS modifiers
+			FROM cart_modifiers cm
+			JOIN modifier_options o ON o.id = cm.modifier_option_id
+			JOIN modifier_groups g ON g.id = o.modifier_group_id
+			WHERE cm.cart_id = c.id
+		) m ON true
+		LEFT JOIN LATERAL (
+			SELECT
+				COALESCE(
+					JSONB_AGG(
+						JSONB_BUILD_OBJECT('productId', bp.id, 'productName', bp.name, 'size', COALESCE(bs.name, ''), 'variant', COALESCE(bv.name, ''), 'quantity', bi.quantity)
+						ORDER BY bi.sort_order, bi.id
+					) FILTER (WHERE bi.id IS NOT NULL),
+					'[]'
+				) AS components
+			FROM product_bundle_items bi
+			JOIN products bp ON bp.id = bi.component_product_id
+			LEFT JOIN sizes bs ON bs.id = bi.size_id
+			LEFT JOIN variants bv ON bv.id = bi.variant_id
+			WHERE bi.bundle_product_id = c.product_id
+		) b ON true
 		WHERE c.user_id = $1
-		GROUP BY c.id, c.product_id, p.name, p.price, p.is_flash_sale, p.discount_percent, s.name, s.size_cost, v.name, v.variant_cost
-		ORDER BY c.updated_at DESC`, userId)
+		GROUP BY c.id, c.product_id, p.id, sk.price, s.name, s.size_cost, v.name, v.variant_cost, m.modifiers_cost, m.modifiers, b.components
+		ORDER BY c.updated_at DESC`, userId, storeClock())
 	if err != nil {
 		message = "Failed to fetch list carts from database"
 		return carts, message, err
@@ -87,6 +140,18 @@ func GetListCart(userId int) ([]Cart, string, error) {
 	return carts, message, nil
 }
 
+// cartSubtotalSelect prices a cart line like GetListCart and checkout do, an active flash sale takes the
+// place of the product discount. $1 product, $2 size, $3 variant, $4 amount, $5 modifiers cost, $6 sku
+const cartSubtotalSelect = `SELECT
+				(COALESCE(sk.price, p.price) * (1 - (COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) / 100.0)) + COALESCE(s.size_cost, 0) + COALESCE(v.variant_cost, 0) + $5) * $4 AS subtotal
+			FROM products p
+			LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+			LEFT JOIN product_skus sk ON sk.id = $6
+			LEFT JOIN sizes s ON s.id = $2
+			LEFT JOIN variants v ON v.id = $3
+			WHERE p.id = $1
+			GROUP BY p.id, sk.price, s.size_cost, v.variant_cost`
+
 func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 	ctx := context.Background()
 	responseCart := CartRequest{}
@@ -99,57 +164,105 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 	}
 	defer tx.Rollback(ctx)
 
-	// get stock product
+	// get stock product, the stock of a bundle is what its components allow
 	var stock int
-	err = tx.QueryRow(ctx, `SELECT stock FROM products WHERE id = $1`, bodyAdd.ProductId).Scan(&stock)
+	var isBundle, isAvailableNow bool
+	err = tx.QueryRow(ctx,
+		`SELECT stock, is_bundle, `+productAvailableNowColumn(2)+` FROM products p WHERE id = $1 AND deleted_at IS NULL`,
+		bodyAdd.ProductId, storeClock(),
+	).Scan(&stock, &isBundle, &isAvailableNow)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Product not found"
+			return responseCart, message, err
+		}
 		message = "Internal server error while get stock from products"
 		return responseCart, message, err
 	}
 
+	// scheduled products and products outside their daily hours cannot be ordered
+	if !isAvailableNow {
+		message = "Product is not available at this time"
+		return responseCart, message, errors.New(message)
+	}
+
 	if bodyAdd.Amount <= 0 {
 		message = "invalid amount, must be greater than 0"
 		return responseCart, message, errors.New(message)
 	}
 
-	if bodyAdd.Amount > stock {
-		message = "amount exceeds available stock"
-		return responseCart, message, errors.New(message)
+	// a bundle is served in the sizes and variants set on its components
+	var sizeId, variantId *int
+	if isBundle {
+		if bodyAdd.SizeId != 0 || bodyAdd.VariantId != 0 {
+			message = "Bundle sizes and variants are set per component"
+			return responseCart, message, errors.New(message)
+		}
+	} else {
+		sizeId, variantId = &bodyAdd.SizeId, &bodyAdd.VariantId
 	}
 
-	// check whether the cart item already exists in the database
-	var cartIsExist bool
-	err = tx.QueryRow(
-		ctx,
-		"SELECT EXISTS(SELECT 1 FROM carts WHERE user_id = $1 AND product_id = $2 AND size_id = $3 AND variant_id = $4)", bodyAdd.UserId, bodyAdd.ProductId, bodyAdd.SizeId, bodyAdd.VariantId).Scan(&cartIsExist)
+	// a product with SKUs is sold from the SKU of the chosen size and variant
+	var skuId *int
+	hasSkus := false
+	if !isBundle {
+		err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM product_skus WHERE product_id = $1)`, bodyAdd.ProductId).Scan(&hasSkus)
+		if err != nil {
+			message = "Internal server error while checking product SKUs"
+			return responseCart, message, err
+		}
+	}
+	if hasSkus {
+		var isAvailable bool
+		err = tx.QueryRow(ctx,
+			`SELECT id, stock, is_available FROM product_skus
+			WHERE product_id = $1 AND size_id IS NOT DISTINCT FROM NULLIF($2, 0) AND variant_id IS NOT DISTINCT FROM NULLIF($3, 0)`,
+			bodyAdd.ProductId, bodyAdd.SizeId, bodyAdd.VariantId,
+		).Scan(&skuId, &stock, &isAvailable)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			message = "Internal server error while checking product SKU"
+			return responseCart, message, err
+		}
+		if err != nil || !isAvailable {
+			message = "Selected size and variant is not available"
+			return responseCart, message, errors.New(message)
+		}
+	}
+
+	// check the chosen modifiers, lines with the same choices are merged
+	if bodyAdd.ModifierOptionIds == nil {
+		bodyAdd.ModifierOptionIds = []int{}
+	}
+	modifierKey, modifiersCost, message, err := resolveCartModifiers(ctx, tx, bodyAdd.ProductId, bodyAdd.ModifierOptionIds)
 	if err != nil {
+		return responseCart, message, err
+	}
+
+	// check whether the cart item already exists in the database
+	var oldAmount int
+	err = tx.QueryRow(ctx,
+		`SELECT id, amount FROM carts 
+		WHERE user_id = $1 AND product_id = $2 AND size_id IS NOT DISTINCT FROM $3 AND variant_id IS NOT DISTINCT FROM $4 AND modifier_key = $5 AND sku_id IS NOT DISTINCT FROM $6`,
+		bodyAdd.UserId, bodyAdd.ProductId, sizeId, variantId, modifierKey, skuId,
+	).Scan(&bodyAdd.Id, &oldAmount)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		message = "Internal server error while checking cart"
 		return responseCart, message, err
 	}
+	cartIsExist := err == nil
+
+	if bodyAdd.Amount+oldAmount > stock {
+		message = "amount exceeds available stock"
+		return responseCart, message, errors.New(message)
+	}
 
 	if cartIsExist {
-		// get the previous amount
-		var oldAmount int
-		err = tx.QueryRow(ctx,
-			`SELECT amount FROM carts 
-     		WHERE user_id = $1 AND product_id = $2 AND size_id = $3 AND variant_id = $4`,
-			bodyAdd.UserId, bodyAdd.ProductId, bodyAdd.SizeId, bodyAdd.VariantId,
-		).Scan(&oldAmount)
-		if err != nil {
-			message = "Internal server error while get amount of the product"
-			return responseCart, message, err
-		}
 		bodyAdd.Amount += oldAmount
 
 		// calculate new subtotal
 		err := tx.QueryRow(ctx,
-			`SELECT 
-				((p.price * (1-(p.discount_percent/100))) + s.size_cost + v.variant_cost) * $4 AS subtotal
-			FROM products p
-			JOIN sizes s ON s.id = $2
-			JOIN variants v ON v.id = $3
-			WHERE p.id = $1`,
-			bodyAdd.ProductId, bodyAdd.SizeId, bodyAdd.VariantId, bodyAdd.Amount,
+			cartSubtotalSelect,
+			bodyAdd.ProductId, sizeId, variantId, bodyAdd.Amount, modifiersCost, skuId,
 		).Scan(&bodyAdd.Subtotal)
 		if err != nil {
 			message = "Internal server error while calculate subtotal"
@@ -159,11 +272,11 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 		// update cart items
 		_, err = tx.Exec(
 			ctx,
-			`UPDATE carts SET amount = $1, subtotal = $2, updated_at = NOW(), updated_by = $3 WHERE user_id = $4`,
+			`UPDATE carts SET amount = $1, subtotal = $2, updated_at = NOW(), updated_by = $3 WHERE id = $4`,
 			bodyAdd.Amount,
 			bodyAdd.Subtotal,
 			bodyAdd.UserId,
-			bodyAdd.UserId,
+			bodyAdd.Id,
 		)
 		if err != nil {
 			message = "Internal server error while updating cart"
@@ -172,13 +285,8 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 	} else {
 		// calculate subtotal for new cart
 		err := tx.QueryRow(ctx,
-			`SELECT 
-				((p.price * (1-(p.discount_percent/100))) + s.size_cost + v.variant_cost) * $4 AS subtotal
-			FROM products p
-			JOIN sizes s ON s.id = $2
-			JOIN variants v ON v.id = $3
-			WHERE p.id = $1`,
-			bodyAdd.ProductId, bodyAdd.SizeId, bodyAdd.VariantId, bodyAdd.Amount,
+			cartSubtotalSelect,
+			bodyAdd.ProductId, sizeId, variantId, bodyAdd.Amount, modifiersCost, skuId,
 		).Scan(&bodyAdd.Subtotal)
 		if err != nil {
 			message = "Internal server error while calculate subtotal"
@@ -188,13 +296,15 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 		// add cart items
 		err = tx.QueryRow(
 			ctx,
-			`INSERT INTO carts (user_id, product_id, size_id, variant_id, amount, subtotal, created_by, updated_by)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`INSERT INTO carts (user_id, product_id, size_id, variant_id, sku_id, modifier_key, amount, subtotal, created_by, updated_by)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			 RETURNING id`,
 			bodyAdd.UserId,
 			bodyAdd.ProductId,
-			bodyAdd.SizeId,
-			bodyAdd.VariantId,
+			sizeId,
+			variantId,
+			skuId,
+			modifierKey,
 			bodyAdd.Amount,
 			bodyAdd.Subtotal,
 			bodyAdd.UserId,
@@ -204,6 +314,17 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 			message = "Internal server error while adding cart"
 			return responseCart, message, err
 		}
+
+		// add the chosen modifiers of the cart item
+		_, err = tx.Exec(ctx,
+			`INSERT INTO cart_modifiers (cart_id, modifier_option_id, created_by, updated_by)
+			 SELECT $1, UNNEST($2::int[]), $3, $3`,
+			bodyAdd.Id, bodyAdd.ModifierOptionIds, bodyAdd.UserId,
+		)
+		if err != nil {
+			message = "Internal server error while adding cart modifiers"
+			return responseCart, message, err
+		}
 	}
 
 	// commit transaction
@@ -215,13 +336,14 @@ func AddToCart(bodyAdd CartRequest) (CartRequest, string, error) {
 
 	message = "Cart added successfully"
 	responseCart = CartRequest{
-		Id:        bodyAdd.Id,
-		UserId:    bodyAdd.UserId,
-		ProductId: bodyAdd.ProductId,
-		SizeId:    bodyAdd.SizeId,
-		VariantId: bodyAdd.VariantId,
-		Amount:    bodyAdd.Amount,
-		Subtotal:  bodyAdd.Subtotal,
+		Id:                bodyAdd.Id,
+		UserId:            bodyAdd.UserId,
+		ProductId:         bodyAdd.ProductId,
+		SizeId:            bodyAdd.SizeId,
+		VariantId:         bodyAdd.VariantId,
+		ModifierOptionIds: bodyAdd.ModifierOptionIds,
+		Amount:            bodyAdd.Amount,
+		Subtotal:          bodyAdd.Subtotal,
 	}
 
 	return responseCart, message, nil
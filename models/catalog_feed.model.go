@@ -0,0 +1,122 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SitemapEntry is an indexable page, Kind is "product" or "category"
+type SitemapEntry struct {
+	Kind      string    `db:"kind" json:"kind"`
+	Slug      string    `db:"slug" json:"slug"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// ProductFeedItem is one product of the marketplace feed, DiscountPrice is 0 without a discount
+type ProductFeedItem struct {
+	Id              int       `db:"id" json:"id"`
+	Slug            string    `db:"slug" json:"slug"`
+	Name            string    `db:"name" json:"name"`
+	Description     string    `db:"description" json:"description"`
+	Price           float64   `db:"price" json:"price"`
+	DiscountPercent float64   `db:"discount_percent" json:"discountPercent"`
+	DiscountPrice   float64   `db:"discount_price" json:"discountPrice"`
+	IsFlashSale     bool      `db:"is_flash_sale" json:"isFlashSale"`
+	Stock           int       `db:"stock" json:"stock"`
+	ImageLink       string    `db:"image_link" json:"imageLink"`
+	ProductType     string    `db:"product_type" json:"productType"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updatedAt"`
+	Link            string    `db:"-" json:"link"`
+	Availability    string    `db:"-" json:"availability"`
+}
+
+// GetSitemapEntries returns the active products and visible categories with their last update
+func GetSitemapEntries() ([]SitemapEntry, string, error) {
+	entries := []SitemapEntry{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT 'product' AS kind, slug, COALESCE(updated_at, created_at, NOW()) AS updated_at
+		FROM products p
+		WHERE is_active = true AND deleted_at IS NULL AND `+productPublishedCondition+`
+		UNION ALL
+		SELECT 'category' AS kind, slug, COALESCE(updated_at, created_at, NOW()) AS updated_at
+		FROM categories
+		WHERE deleted_at IS NULL
+		ORDER BY kind DESC, slug ASC`)
+	if err != nil {
+		message = "Failed to fetch sitemap entries from database"
+		return entries, message, err
+	}
+	defer rows.Close()
+
+	entries, err = pgx.CollectRows(rows, pgx.RowToStructByName[SitemapEntry])
+	if err != nil {
+		message = "Failed to process sitemap entries from database"
+		return entries, message, err
+	}
+
+	message = "Success get sitemap entries"
+	return entries, message, nil
+}
+
+// GetProductFeed returns every active product with its current price after discounts, the first image
+// and the top level category path of its first category as product type
+func GetProductFeed() ([]ProductFeedItem, string, error) {
+	items := []ProductFeedItem{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`WITH RECURSIVE category_paths AS (
+			SELECT id, name::text AS path FROM categories WHERE parent_id IS NULL AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, cp.path || ' > ' || c.name
+			FROM categories c
+			JOIN category_paths cp ON cp.id = c.parent_id
+			WHERE c.deleted_at IS NULL
+		)
+		SELECT
+			p.id,
+			p.slug,
+			p.name,
+			COALESCE(p.description, '') AS description,
+			p.price,
+			`+productPriceColumns+`,
+			COALESCE(p.stock, 0) AS stock,
+			COALESCE((
+				SELECT pi.product_image FROM product_images pi
+				WHERE pi.product_id = p.id
+				ORDER BY pi.is_primary DESC, pi.id ASC
+				LIMIT 1
+			), '') AS image_link,
+			COALESCE((
+				SELECT cp.path FROM product_categories pc
+				JOIN category_paths cp ON cp.id = pc.category_id
+				WHERE pc.product_id = p.id
+				ORDER BY pc.id ASC
+				LIMIT 1
+			), '') AS product_type,
+			COALESCE(p.updated_at, p.created_at, NOW()) AS updated_at
+		FROM products p
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+		WHERE p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		GROUP BY p.id
+		ORDER BY p.id ASC`)
+	if err != nil {
+		message = "Failed to fetch product feed from database"
+		return items, message, err
+	}
+	defer rows.Close()
+
+	items, err = pgx.CollectRows(rows, pgx.RowToStructByName[ProductFeedItem])
+	if err != nil {
+		message = "Failed to process product feed from database"
+		return items, message, err
+	}
+
+	message = "Success get product feed"
+	return items, message, nil
+}
@@ -2,8 +2,10 @@ package models
 
 import (
 	"backend-daily-greens/config"
+	"backend-daily-greens/utils"
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -11,22 +13,63 @@ import (
 )
 
 type Category struct {
-	Id        int       `json:"id" db:"id"`
-	Name      string    `json:"name" form:"name" db:"name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-	CreatedBy int       `json:"created_by,omitempty" db:"-"`
-	UpdatedBy int       `json:"updated_by,omitempty" db:"-"`
+	Id          int        `json:"id" db:"id"`
+	ParentId    *int       `json:"parent_id" form:"parentId" db:"parent_id"`
+	Name        string     `json:"name" form:"name" db:"name"`
+	Slug        string     `json:"slug" form:"slug" db:"slug"`
+	Description string     `json:"description" form:"description" db:"description"`
+	Image       string     `json:"image" form:"-" db:"image"`
+	SortOrder   int        `json:"sort_order" form:"sortOrder" db:"sort_order"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at" db:"deleted_at"`
+	CreatedBy   int        `json:"created_by,omitempty" db:"-"`
+	UpdatedBy   int        `json:"updated_by,omitempty" db:"-"`
 }
 
-func GetTotalDataCategories(search string) (int, error) {
+// CategoryUpdateRequest only changes the fields that are sent, parentId 0 moves the category to the top level
+type CategoryUpdateRequest struct {
+	Name        *string `form:"name"`
+	Slug        *string `form:"slug"`
+	Description *string `form:"description"`
+	ParentId    *int    `form:"parentId"`
+	SortOrder   *int    `form:"sortOrder"`
+	Image       string  `form:"-"`
+}
+
+// CategoryNode is a category of the public tree, ProductCount includes the products of every subcategory
+type CategoryNode struct {
+	Id           int             `json:"id" db:"id"`
+	ParentId     *int            `json:"parent_id" db:"parent_id"`
+	Name         string          `json:"name" db:"name"`
+	Slug         string          `json:"slug" db:"slug"`
+	Description  string          `json:"description" db:"description"`
+	Image        string          `json:"image" db:"image"`
+	SortOrder    int             `json:"sort_order" db:"sort_order"`
+	ProductCount int             `json:"product_count" db:"product_count"`
+	Children     []*CategoryNode `json:"children" db:"-"`
+}
+
+const categoryColumns = `id, parent_id, name, slug, COALESCE(description, '') AS description,
+			COALESCE(image, '') AS image, sort_order, created_at, updated_at, deleted_at`
+
+// categoriesDeletedCondition hides soft deleted categories unless includeDeleted (admin)
+func categoriesDeletedCondition(includeDeleted bool) string {
+	if includeDeleted {
+		return ""
+	}
+	return ` AND deleted_at IS NULL`
+}
+
+func GetTotalDataCategories(search string, includeDeleted bool) (int, error) {
 	totalData := 0
 	var err error
 	if search != "" {
 		err = config.DB.QueryRow(context.Background(),
-			`SELECT COUNT(*) FROM categories WHERE name ILIKE $1`, "%"+search+"%").Scan(&totalData)
+			`SELECT COUNT(*) FROM categories WHERE name ILIKE $1`+categoriesDeletedCondition(includeDeleted), "%"+search+"%").Scan(&totalData)
 	} else {
-		err = config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM categories`).Scan(&totalData)
+		err = config.DB.QueryRow(context.Background(),
+			`SELECT COUNT(*) FROM categories WHERE true`+categoriesDeletedCondition(includeDeleted)).Scan(&totalData)
 	}
 	if err != nil {
 		return totalData, err
@@ -35,7 +78,7 @@ func GetTotalDataCategories(search string) (int, error) {
 	return totalData, nil
 }
 
-func GetListAllCategories(page int, limit int, search string) ([]Category, string, error) {
+func GetListAllCategories(page int, limit int, search string, includeDeleted bool) ([]Category, string, error) {
 	offset := (page - 1) * limit
 	var rows pgx.Rows
 	var err error
@@ -44,16 +87,17 @@ func GetListAllCategories(page int, limit int, search string) ([]Category, strin
 
 	if search != "" {
 		rows, err = config.DB.Query(context.Background(),
-			`SELECT id, name, created_at, updated_at
+			`SELECT `+categoryColumns+`
 			FROM categories
-			WHERE name ILIKE $3
-			ORDER BY id ASC
+			WHERE name ILIKE $3`+categoriesDeletedCondition(includeDeleted)+`
+			ORDER BY sort_order ASC, id ASC
 			LIMIT $1 OFFSET $2`, limit, offset, "%"+search+"%")
 	} else {
 		rows, err = config.DB.Query(context.Background(),
-			`SELECT id, name, created_at, updated_at
+			`SELECT `+categoryColumns+`
 			FROM categories
-			ORDER BY id ASC
+			WHERE true`+categoriesDeletedCondition(includeDeleted)+`
+			ORDER BY sort_order ASC, id ASC
 			LIMIT $1 OFFSET $2`, limit, offset)
 	}
 
@@ -77,7 +121,7 @@ func GetCategoryById(id int) (Category, string, error) {
 	category := Category{}
 	message := ""
 	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, name, created_at, updated_at
+		`SELECT `+categoryColumns+`
 		FROM categories
 		WHERE id = $1`, id)
 	if err != nil {
@@ -100,6 +144,74 @@ func GetCategoryById(id int) (Category, string, error) {
 	return category, message, nil
 }
 
+// GetCategoryTree returns the visible categories nested under their parent, ordered by sort order then name.
+// Subcategories of a deleted category are left out with it.
+func GetCategoryTree() ([]*CategoryNode, string, error) {
+	tree := []*CategoryNode{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`WITH RECURSIVE subtree AS (
+			SELECT id AS root_id, id FROM categories WHERE deleted_at IS NULL
+			UNION ALL
+			SELECT s.root_id, c.id
+			FROM subtree s
+			JOIN categories c ON c.parent_id = s.id
+			WHERE c.deleted_at IS NULL
+		),
+		counts AS (
+			SELECT s.root_id, COUNT(DISTINCT p.id) AS product_count
+			FROM subtree s
+			JOIN product_categories pc ON pc.category_id = s.id
+			JOIN products p ON p.id = pc.product_id
+			WHERE p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+			GROUP BY s.root_id
+		)
+		SELECT
+			c.id,
+			c.parent_id,
+			c.name,
+			c.slug,
+			COALESCE(c.description, '') AS description,
+			COALESCE(c.image, '') AS image,
+			c.sort_order,
+			COALESCE(counts.product_count, 0) AS product_count
+		FROM categories c
+		LEFT JOIN counts ON counts.root_id = c.id
+		WHERE c.deleted_at IS NULL
+		ORDER BY c.sort_order ASC, c.name ASC, c.id ASC`)
+	if err != nil {
+		message = "Failed to fetch categories from database"
+		return tree, message, err
+	}
+	defer rows.Close()
+
+	nodes, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[CategoryNode])
+	if err != nil {
+		message = "Failed to process category data from database"
+		return tree, message, err
+	}
+
+	byId := make(map[int]*CategoryNode, len(nodes))
+	for _, node := range nodes {
+		node.Children = []*CategoryNode{}
+		byId[node.Id] = node
+	}
+
+	for _, node := range nodes {
+		if node.ParentId == nil {
+			tree = append(tree, node)
+			continue
+		}
+		if parent, ok := byId[*node.ParentId]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	message = "Success get category tree"
+	return tree, message, nil
+}
+
 func CheckCategoryName(name string) (bool, error) {
 	var exists bool
 	err := config.DB.QueryRow(
@@ -114,16 +226,81 @@ func CheckCategoryName(name string) (bool, error) {
 	return exists, nil
 }
 
+// CheckCategorySlugExcludingId checks slug uniqueness, id 0 checks against every category
+func CheckCategorySlugExcludingId(slug string, id int) (bool, error) {
+	var exists bool
+	err := config.DB.QueryRow(
+		context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM categories WHERE slug = $1 AND id != $2)", slug, id,
+	).Scan(&exists)
+
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+// CheckCategoryParent validates parentId as the parent of categoryId (0 for a new category).
+// The returned message is empty when the parent is allowed.
+func CheckCategoryParent(categoryId int, parentId int) (string, error) {
+	var parentExists, isDescendant bool
+	err := config.DB.QueryRow(context.Background(),
+		`WITH RECURSIVE descendants AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id FROM categories c JOIN descendants d ON c.parent_id = d.id
+		)
+		SELECT
+			EXISTS(SELECT 1 FROM categories WHERE id = $2 AND deleted_at IS NULL),
+			EXISTS(SELECT 1 FROM descendants WHERE id = $2)`,
+		categoryId, parentId,
+	).Scan(&parentExists, &isDescendant)
+	if err != nil {
+		return "Internal server error while checking parent category", err
+	}
+
+	if !parentExists {
+		return "Parent category not found", nil
+	}
+
+	if isDescendant {
+		return "Category cannot be moved under itself or one of its subcategories", nil
+	}
+
+	return "", nil
+}
+
+// CheckCategoryHasChildren reports whether a category still has subcategories that are not deleted
+func CheckCategoryHasChildren(categoryId int) (bool, error) {
+	var exists bool
+	err := config.DB.QueryRow(
+		context.Background(),
+		"SELECT EXISTS(SELECT 1 FROM categories WHERE parent_id = $1 AND deleted_at IS NULL)", categoryId,
+	).Scan(&exists)
+
+	if err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
 func InsertDataCategory(userId int, bodyCreate *Category) (bool, string, error) {
 	isSuccess := false
 	message := ""
 
 	err := config.DB.QueryRow(
 		context.Background(),
-		`INSERT INTO categories (name, created_by, updated_by)
-		 VALUES ($1, $2, $3)
+		`INSERT INTO categories (name, slug, description, image, parent_id, sort_order, created_by, updated_by)
+		 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8)
 		 RETURNING id`,
 		bodyCreate.Name,
+		bodyCreate.Slug,
+		bodyCreate.Description,
+		bodyCreate.Image,
+		bodyCreate.ParentId,
+		bodyCreate.SortOrder,
 		userId,
 		userId,
 	).Scan(&bodyCreate.Id)
@@ -151,18 +328,35 @@ func CheckCategoryNameExcludingId(name string, id int) (bool, error) {
 	return exists, nil
 }
 
-func UpdateDataCategory(categoryId int, userId int, name string) (bool, string, error) {
+func UpdateDataCategory(categoryId int, userId int, bodyUpdate CategoryUpdateRequest) (bool, string, error) {
 	isSuccess := false
 	message := ""
 
+	setParent := bodyUpdate.ParentId != nil
+	parentId := 0
+	if setParent {
+		parentId = *bodyUpdate.ParentId
+	}
+
 	commandTag, err := config.DB.Exec(
 		context.Background(),
 		`UPDATE categories 
 		 SET name = COALESCE(NULLIF($1, ''), name),
-		     updated_by = $2,
+		     slug = COALESCE(NULLIF($2, ''), slug),
+		     description = CASE WHEN $3::text IS NULL THEN description ELSE NULLIF($3, '') END,
+		     parent_id = CASE WHEN $4::bool THEN NULLIF($5::int, 0) ELSE parent_id END,
+		     sort_order = COALESCE($6, sort_order),
+		     image = COALESCE(NULLIF($7, ''), image),
+		     updated_by = $8,
 		     updated_at = NOW()
-		 WHERE id = $3`,
-		name,
+		 WHERE id = $9`,
+		bodyUpdate.Name,
+		bodyUpdate.Slug,
+		bodyUpdate.Description,
+		setParent,
+		parentId,
+		bodyUpdate.SortOrder,
+		bodyUpdate.Image,
 		userId,
 		categoryId,
 	)
@@ -181,11 +375,96 @@ func UpdateDataCategory(categoryId int, userId int, name string) (bool, string,
 	return isSuccess, message, nil
 }
 
-func DeleteDataCategory(categoryId int) (pgconn.CommandTag, error) {
-	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM categories WHERE id = $1`, categoryId)
+// DeleteDataCategory soft deletes a category, it disappears from the shop but stays linked to its products
+func DeleteDataCategory(categoryId int, userId int) (pgconn.CommandTag, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE categories
+		 SET deleted_at = NOW(),
+		     deleted_by = $2,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NULL`, categoryId, userId)
+	if err != nil {
+		return commandTag, err
+	}
+
+	return commandTag, nil
+}
+
+func RestoreDataCategory(categoryId int, userId int) (pgconn.CommandTag, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE categories
+		 SET deleted_at = NULL,
+		     deleted_by = NULL,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NOT NULL`, categoryId, userId)
 	if err != nil {
 		return commandTag, err
 	}
 
 	return commandTag, nil
 }
+
+// PurgeDeletedCategories permanently removes categories soft deleted at least olderThanDays ago.
+// Categories still linked to a product or a subcategory are skipped, unlink them or purge those first.
+func PurgeDeletedCategories(olderThanDays int) (PurgeResult, string, error) {
+	result := PurgeResult{}
+	message := ""
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return result, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := softDeletedIds(ctx, tx, "categories", olderThanDays)
+	if err != nil {
+		message = "Failed to fetch deleted categories"
+		return result, message, err
+	}
+
+	images := map[int]string{}
+	rows, err := tx.Query(ctx, `SELECT id, image FROM categories WHERE id = ANY($1::int[]) AND image IS NOT NULL`, ids)
+	if err != nil {
+		message = "Failed to get url category images to delete"
+		return result, message, err
+	}
+	for rows.Next() {
+		var categoryId int
+		var image string
+		if err := rows.Scan(&categoryId, &image); err != nil {
+			rows.Close()
+			message = "Failed to get url category images to delete"
+			return result, message, err
+		}
+		images[categoryId] = image
+	}
+	rows.Close()
+
+	result, err = purgeRows(ctx, tx, "categories", ids)
+	if err != nil {
+		message = "Internal server error while purging categories"
+		return result, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return result, message, err
+	}
+
+	// rows are gone already, a failed storage delete only leaves an orphaned file
+	for _, categoryId := range result.Purged {
+		if image, ok := images[categoryId]; ok {
+			if err := utils.DeleteFromSupabase(image, "categories"); err != nil {
+				log.Printf("Failed to delete image %s of purged category %d: %v", image, categoryId, err)
+			}
+		}
+	}
+
+	message = "Deleted categories purged successfully"
+	return result, message, nil
+}
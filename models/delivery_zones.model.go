@@ -0,0 +1,448 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// earthRadiusKm is the mean earth radius used for distances from the store
+const earthRadiusKm = 6371.0
+
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" db:"latitude" example:"-6.2"`
+	Longitude float64 `json:"longitude" db:"longitude" example:"106.816666"`
+}
+
+// DeliveryZone is an area an order method delivers to with its own fee and minimum order,
+// a radius zone is a ring around the store and a polygon zone is outlined by its points
+type DeliveryZone struct {
+	Id            int        `json:"id" db:"id"`
+	OrderMethodId int        `json:"orderMethodId" db:"order_method_id"`
+	OrderMethod   string     `json:"orderMethod" db:"order_method"`
+	Name          string     `json:"name" db:"name"`
+	Kind          string     `json:"kind" db:"kind"`
+	MinRadiusKm   *float64   `json:"minRadiusKm" db:"min_radius_km"`
+	MaxRadiusKm   *float64   `json:"maxRadiusKm" db:"max_radius_km"`
+	DeliveryFee   float64    `json:"deliveryFee" db:"delivery_fee"`
+	MinOrder      float64    `json:"minOrder" db:"min_order"`
+	SortOrder     int        `json:"sortOrder" db:"sort_order"`
+	IsActive      bool       `json:"isActive" db:"is_active"`
+	Points        []GeoPoint `json:"points" db:"-"`
+}
+
+// DeliveryZoneRequest creates or replaces a zone, radius zones use the radii and polygon zones the points
+type DeliveryZoneRequest struct {
+	OrderMethodId int        `json:"orderMethodId" example:"2"`
+	Name          string     `json:"name" example:"Within 3 km"`
+	Kind          string     `json:"kind" example:"radius"`
+	MinRadiusKm   *float64   `json:"minRadiusKm" example:"0"`
+	MaxRadiusKm   *float64   `json:"maxRadiusKm" example:"3"`
+	Points        []GeoPoint `json:"points"`
+	DeliveryFee   float64    `json:"deliveryFee" example:"8000"`
+	MinOrder      float64    `json:"minOrder" example:"25000"`
+	SortOrder     int        `json:"sortOrder"`
+	IsActive      *bool      `json:"isActive"`
+}
+
+const deliveryZoneSelect = `
+		SELECT
+			dz.id,
+			dz.order_method_id,
+			om.name AS order_method,
+			dz.name,
+			dz.kind,
+			dz.min_radius_km,
+			dz.max_radius_km,
+			dz.delivery_fee,
+			dz.min_order,
+			dz.sort_order,
+			dz.is_active
+		FROM delivery_zones dz
+		JOIN order_methods om ON om.id = dz.order_method_id`
+
+// GetListDeliveryZones lists the zones in the order they are matched, orderMethodId 0 lists every order method
+func GetListDeliveryZones(orderMethodId int) ([]DeliveryZone, string, error) {
+	ctx := context.Background()
+	deliveryZones := []DeliveryZone{}
+	message := ""
+
+	rows, err := config.DB.Query(ctx,
+		deliveryZoneSelect+`
+		WHERE ($1 = 0 OR dz.order_method_id = $1)
+		ORDER BY dz.order_method_id ASC, dz.sort_order ASC, dz.id ASC`, orderMethodId)
+	if err != nil {
+		message = "Failed to fetch delivery zones from database"
+		return deliveryZones, message, err
+	}
+	defer rows.Close()
+
+	deliveryZones, err = pgx.CollectRows(rows, pgx.RowToStructByName[DeliveryZone])
+	if err != nil {
+		message = "Failed to process delivery zone data"
+		return deliveryZones, message, err
+	}
+
+	for i := range deliveryZones {
+		deliveryZones[i].Points, err = getDeliveryZonePoints(ctx, deliveryZones[i].Id)
+		if err != nil {
+			message = "Failed to fetch delivery zone points from database"
+			return deliveryZones, message, err
+		}
+	}
+
+	message = "Success get delivery zones"
+	return deliveryZones, message, nil
+}
+
+func GetDeliveryZoneById(id int) (DeliveryZone, string, error) {
+	ctx := context.Background()
+	deliveryZone := DeliveryZone{}
+	message := ""
+
+	rows, err := config.DB.Query(ctx, deliveryZoneSelect+` WHERE dz.id = $1`, id)
+	if err != nil {
+		message = "Failed to fetch delivery zone from database"
+		return deliveryZone, message, err
+	}
+	defer rows.Close()
+
+	deliveryZone, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[DeliveryZone])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Delivery zone not found"
+			return deliveryZone, message, err
+		}
+		message = "Failed to process delivery zone data"
+		return deliveryZone, message, err
+	}
+
+	deliveryZone.Points, err = getDeliveryZonePoints(ctx, id)
+	if err != nil {
+		message = "Failed to fetch delivery zone points from database"
+		return deliveryZone, message, err
+	}
+
+	message = "Success get delivery zone"
+	return deliveryZone, message, nil
+}
+
+func getDeliveryZonePoints(ctx context.Context, deliveryZoneId int) ([]GeoPoint, error) {
+	rows, err := config.DB.Query(ctx,
+		`SELECT latitude, longitude
+		FROM delivery_zone_points
+		WHERE delivery_zone_id = $1
+		ORDER BY position ASC`, deliveryZoneId)
+	if err != nil {
+		return []GeoPoint{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[GeoPoint])
+}
+
+func isValidGeoPoint(point GeoPoint) bool {
+	return point.Latitude >= -90 && point.Latitude <= 90 && point.Longitude >= -180 && point.Longitude <= 180
+}
+
+// validateDeliveryZone checks a zone and clears the fields its kind does not use
+func validateDeliveryZone(ctx context.Context, tx pgx.Tx, zone DeliveryZoneRequest) (DeliveryZoneRequest, string, error) {
+	zone.Name = strings.TrimSpace(zone.Name)
+	if zone.Name == "" {
+		return zone, "Name is required", errors.New("missing delivery zone name")
+	}
+	if zone.DeliveryFee < 0 {
+		return zone, "Delivery fee cannot be negative", errors.New("invalid delivery fee")
+	}
+	if zone.MinOrder < 0 {
+		return zone, "Minimum order cannot be negative", errors.New("invalid minimum order")
+	}
+
+	switch zone.Kind {
+	case "radius":
+		if config.StoreLatitude == nil {
+			return zone, "Store coordinate is not configured", errors.New("STORE_LATITUDE and STORE_LONGITUDE are not set")
+		}
+		if zone.MinRadiusKm == nil {
+			zero := 0.0
+			zone.MinRadiusKm = &zero
+		}
+		if zone.MaxRadiusKm == nil || *zone.MinRadiusKm < 0 || *zone.MaxRadiusKm <= *zone.MinRadiusKm {
+			return zone, "Max radius must be greater than min radius", errors.New("invalid delivery zone radius")
+		}
+		zone.Points = nil
+	case "polygon":
+		if len(zone.Points) < 3 {
+			return zone, "Polygon needs at least 3 points", fmt.Errorf("polygon has %d points", len(zone.Points))
+		}
+		for _, point := range zone.Points {
+			if !isValidGeoPoint(point) {
+				return zone, "Invalid coordinate", fmt.Errorf("invalid point %v, %v", point.Latitude, point.Longitude)
+			}
+		}
+		if isSelfIntersecting(zone.Points) {
+			return zone, "Polygon edges cannot cross each other", errors.New("self-intersecting polygon")
+		}
+		zone.MinRadiusKm, zone.MaxRadiusKm = nil, nil
+	default:
+		return zone, "Kind must be radius or polygon", errors.New("invalid delivery zone kind " + zone.Kind)
+	}
+
+	var orderMethodExists bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM order_methods WHERE id = $1)`, zone.OrderMethodId).Scan(&orderMethodExists)
+	if err != nil {
+		return zone, "Internal server error while checking order method", err
+	}
+	if !orderMethodExists {
+		return zone, "Order method not found", fmt.Errorf("order method %d not found", zone.OrderMethodId)
+	}
+
+	return zone, "", nil
+}
+
+func replaceDeliveryZonePoints(ctx context.Context, tx pgx.Tx, deliveryZoneId int, points []GeoPoint) error {
+	_, err := tx.Exec(ctx, `DELETE FROM delivery_zone_points WHERE delivery_zone_id = $1`, deliveryZoneId)
+	if err != nil {
+		return err
+	}
+
+	for i, point := range points {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO delivery_zone_points (delivery_zone_id, position, latitude, longitude)
+			VALUES ($1, $2, $3, $4)`,
+			deliveryZoneId, i, point.Latitude, point.Longitude)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func InsertDeliveryZone(userId int, bodyCreate DeliveryZoneRequest) (int, string, error) {
+	ctx := context.Background()
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return 0, "Failed to start database transaction", err
+	}
+	defer tx.Rollback(ctx)
+
+	zone, message, err := validateDeliveryZone(ctx, tx, bodyCreate)
+	if err != nil {
+		return 0, message, err
+	}
+	isActive := zone.IsActive == nil || *zone.IsActive
+
+	var deliveryZoneId int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO delivery_zones (order_method_id, name, kind, min_radius_km, max_radius_km, delivery_fee, min_order, sort_order, is_active, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		RETURNING id`,
+		zone.OrderMethodId, zone.Name, zone.Kind, zone.MinRadiusKm, zone.MaxRadiusKm,
+		zone.DeliveryFee, zone.MinOrder, zone.SortOrder, isActive, userId).Scan(&deliveryZoneId)
+	if err != nil {
+		return 0, "Internal server error while creating delivery zone", err
+	}
+
+	err = replaceDeliveryZonePoints(ctx, tx, deliveryZoneId, zone.Points)
+	if err != nil {
+		return 0, "Internal server error while saving delivery zone points", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, "Failed to commit transaction", err
+	}
+
+	return deliveryZoneId, "Delivery zone created successfully", nil
+}
+
+// UpdateDeliveryZone replaces a zone with the request
+func UpdateDeliveryZone(deliveryZoneId int, userId int, bodyUpdate DeliveryZoneRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	zone, message, err := validateDeliveryZone(ctx, tx, bodyUpdate)
+	if err != nil {
+		return isSuccess, message, err
+	}
+	isActive := zone.IsActive == nil || *zone.IsActive
+
+	commandTag, err := tx.Exec(ctx,
+		`UPDATE delivery_zones
+		SET order_method_id = $1,
+			name = $2,
+			kind = $3,
+			min_radius_km = $4,
+			max_radius_km = $5,
+			delivery_fee = $6,
+			min_order = $7,
+			sort_order = $8,
+			is_active = $9,
+			updated_by = $10,
+			updated_at = NOW()
+		WHERE id = $11`,
+		zone.OrderMethodId, zone.Name, zone.Kind, zone.MinRadiusKm, zone.MaxRadiusKm,
+		zone.DeliveryFee, zone.MinOrder, zone.SortOrder, isActive, userId, deliveryZoneId)
+	if err != nil {
+		message = "Internal server error while updating delivery zone"
+		return isSuccess, message, err
+	}
+	if commandTag.RowsAffected() == 0 {
+		message = "Delivery zone not found"
+		return isSuccess, message, nil
+	}
+
+	err = replaceDeliveryZonePoints(ctx, tx, deliveryZoneId, zone.Points)
+	if err != nil {
+		message = "Internal server error while saving delivery zone points"
+		return isSuccess, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Delivery zone updated successfully"
+	return isSuccess, message, nil
+}
+
+func DeleteDeliveryZone(deliveryZoneId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM delivery_zones WHERE id = $1`, deliveryZoneId)
+	if err != nil {
+		return false, "Internal server error while deleting delivery zone", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Delivery zone not found", nil
+	}
+
+	return true, "Delivery zone deleted successfully", nil
+}
+
+// distanceKm is the great-circle distance between two coordinates
+func distanceKm(from GeoPoint, to GeoPoint) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	deltaLatitude := toRadians(to.Latitude - from.Latitude)
+	deltaLongitude := toRadians(to.Longitude - from.Longitude)
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(from.Latitude))*math.Cos(toRadians(to.Latitude))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// isInPolygon casts a ray from point and counts the edges it crosses, an odd count is inside.
+// Coordinates are treated as a flat plane, which holds for areas the size of a city.
+func isInPolygon(point GeoPoint, polygon []GeoPoint) bool {
+	isInside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > point.Latitude) != (b.Latitude > point.Latitude) &&
+			point.Longitude < (b.Longitude-a.Longitude)*(point.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			isInside = !isInside
+		}
+	}
+	return isInside
+}
+
+// isSelfIntersecting tells whether two edges of the polygon that do not share a point cross or touch
+func isSelfIntersecting(polygon []GeoPoint) bool {
+	// orientation is positive when c turns left of a to b, negative when right and 0 when collinear
+	orientation := func(a, b, c GeoPoint) float64 {
+		return (b.Longitude-a.Longitude)*(c.Latitude-a.Latitude) - (b.Latitude-a.Latitude)*(c.Longitude-a.Longitude)
+	}
+	isOnSegment := func(a, b, c GeoPoint) bool {
+		return math.Min(a.Longitude, b.Longitude) <= c.Longitude && c.Longitude <= math.Max(a.Longitude, b.Longitude) &&
+			math.Min(a.Latitude, b.Latitude) <= c.Latitude && c.Latitude <= math.Max(a.Latitude, b.Latitude)
+	}
+	isCrossing := func(a, b, c, d GeoPoint) bool {
+		o1, o2, o3, o4 := orientation(a, b, c), orientation(a, b, d), orientation(c, d, a), orientation(c, d, b)
+		if ((o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)) && ((o3 > 0 && o4 < 0) || (o3 < 0 && o4 > 0)) {
+			return true
+		}
+		return (o1 == 0 && isOnSegment(a, b, c)) || (o2 == 0 && isOnSegment(a, b, d)) ||
+			(o3 == 0 && isOnSegment(c, d, a)) || (o4 == 0 && isOnSegment(c, d, b))
+	}
+
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			// the first and the last edge share the first point
+			if i == 0 && j == n-1 {
+				continue
+			}
+			if isCrossing(polygon[i], polygon[(i+1)%n], polygon[j], polygon[(j+1)%n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindDeliveryZone is the first active zone of the order method, by sort order, that point falls in.
+// It returns nil without error when the order method has no zones and charges its flat delivery fee.
+func FindDeliveryZone(orderMethodId int, point *GeoPoint) (*DeliveryZone, string, error) {
+	ctx := context.Background()
+
+	rows, err := config.DB.Query(ctx,
+		deliveryZoneSelect+`
+		WHERE dz.order_method_id = $1 AND dz.is_active = true
+		ORDER BY dz.sort_order ASC, dz.id ASC`, orderMethodId)
+	if err != nil {
+		return nil, "Failed to fetch delivery zones from database", err
+	}
+	deliveryZones, err := pgx.CollectRows(rows, pgx.RowToStructByName[DeliveryZone])
+	if err != nil {
+		return nil, "Failed to process delivery zone data", err
+	}
+	if len(deliveryZones) == 0 {
+		return nil, "", nil
+	}
+
+	if point == nil {
+		return nil, "Latitude and longitude are required for this order method", errors.New("missing delivery coordinate")
+	}
+	if !isValidGeoPoint(*point) {
+		return nil, "Invalid coordinate", fmt.Errorf("invalid coordinate %v, %v", point.Latitude, point.Longitude)
+	}
+
+	for i, zone := range deliveryZones {
+		switch zone.Kind {
+		case "radius":
+			if config.StoreLatitude == nil {
+				return nil, "Store coordinate is not configured", errors.New("STORE_LATITUDE and STORE_LONGITUDE are not set")
+			}
+			distance := distanceKm(GeoPoint{Latitude: *config.StoreLatitude, Longitude: *config.StoreLongitude}, *point)
+			if distance >= *zone.MinRadiusKm && distance < *zone.MaxRadiusKm {
+				return &deliveryZones[i], "Success find delivery zone", nil
+			}
+		case "polygon":
+			points, err := getDeliveryZonePoints(ctx, zone.Id)
+			if err != nil {
+				return nil, "Failed to fetch delivery zone points from database", err
+			}
+			if isInPolygon(*point, points) {
+				return &deliveryZones[i], "Success find delivery zone", nil
+			}
+		}
+	}
+
+	return nil, "Address is outside the delivery area", fmt.Errorf("no delivery zone of order method %d covers %v, %v", orderMethodId, point.Latitude, point.Longitude)
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name string
+		from GeoPoint
+		to   GeoPoint
+		want float64
+	}{
+		{"same point", GeoPoint{Latitude: -6.2, Longitude: 106.8}, GeoPoint{Latitude: -6.2, Longitude: 106.8}, 0},
+		{"one degree of latitude", GeoPoint{Latitude: 0, Longitude: 0}, GeoPoint{Latitude: 1, Longitude: 0}, 111.19},
+		{"one degree of longitude on the equator", GeoPoint{Latitude: 0, Longitude: 0}, GeoPoint{Latitude: 0, Longitude: 1}, 111.19},
+		{"jakarta to bandung", GeoPoint{Latitude: -6.2088, Longitude: 106.8456}, GeoPoint{Latitude: -6.9175, Longitude: 107.6191}, 116.2},
+		{"across the antimeridian", GeoPoint{Latitude: 0, Longitude: 179.5}, GeoPoint{Latitude: 0, Longitude: -179.5}, 111.19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distanceKm(tt.from, tt.to)
+			if math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("distanceKm() = %.2f, want %.2f", got, tt.want)
+			}
+			if back := distanceKm(tt.to, tt.from); math.Abs(back-got) > 1e-9 {
+				t.Errorf("distanceKm() is not symmetric, %.6f and %.6f", got, back)
+			}
+		})
+	}
+}
+
+func TestIsInPolygon(t *testing.T) {
+	square := []GeoPoint{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 1},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 1, Longitude: 0},
+	}
+	// an L shape, the top right quarter is cut out
+	lShape := []GeoPoint{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 2, Longitude: 1},
+		{Latitude: 2, Longitude: 0},
+	}
+
+	tests := []struct {
+		name    string
+		point   GeoPoint
+		polygon []GeoPoint
+		want    bool
+	}{
+		{"center of square", GeoPoint{Latitude: 0.5, Longitude: 0.5}, square, true},
+		{"left of square", GeoPoint{Latitude: 0.5, Longitude: -0.5}, square, false},
+		{"right of square", GeoPoint{Latitude: 0.5, Longitude: 1.5}, square, false},
+		{"above square", GeoPoint{Latitude: 1.5, Longitude: 0.5}, square, false},
+		{"below square", GeoPoint{Latitude: -0.5, Longitude: 0.5}, square, false},
+		{"level with a vertex", GeoPoint{Latitude: 1, Longitude: 2}, square, false},
+		{"inside the foot of the L", GeoPoint{Latitude: 0.5, Longitude: 1.5}, lShape, true},
+		{"inside the stem of the L", GeoPoint{Latitude: 1.5, Longitude: 0.5}, lShape, true},
+		{"in the cut out corner of the L", GeoPoint{Latitude: 1.5, Longitude: 1.5}, lShape, false},
+		{"empty polygon", GeoPoint{Latitude: 0.5, Longitude: 0.5}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isInPolygon(tt.point, tt.polygon); got != tt.want {
+				t.Errorf("isInPolygon() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSelfIntersecting(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon []GeoPoint
+		want    bool
+	}{
+		{"triangle", []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 0}}, false},
+		{"square", []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 1}, {Latitude: 1, Longitude: 0}}, false},
+		{"bow tie", []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 1, Longitude: 1}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 0}}, true},
+		{"concave", []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 2}, {Latitude: 1, Longitude: 2}, {Latitude: 1, Longitude: 1}, {Latitude: 2, Longitude: 1}, {Latitude: 2, Longitude: 0}}, false},
+		{"edge touching a vertex", []GeoPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 2}, {Latitude: 1, Longitude: 1}, {Latitude: 0, Longitude: 1}, {Latitude: -1, Longitude: 1}}, true},
+		{"pentagram", []GeoPoint{{Latitude: 0, Longitude: 1}, {Latitude: 2, Longitude: 1.6}, {Latitude: 0.8, Longitude: 0}, {Latitude: 0.8, Longitude: 2}, {Latitude: 2, Longitude: 0.4}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSelfIntersecting(tt.polygon); got != tt.want {
+				t.Errorf("isSelfIntersecting() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
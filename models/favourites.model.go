@@ -0,0 +1,155 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetTotalDataUserFavourites(userId int) (int, error) {
+	totalData := 0
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*)
+		FROM user_favourites uf
+		JOIN products p ON p.id = uf.product_id
+		WHERE uf.user_id = $1 AND p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition, userId).Scan(&totalData)
+	return totalData, err
+}
+
+func GetListUserFavourites(userId int, page int, limit int) ([]PublicProductResponse, string, error) {
+	offset := (page - 1) * limit
+	products := []PublicProductResponse{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			p.id,
+			p.name,
+			p.slug,
+			p.description,
+			p.price,
+			`+productPriceColumns+`,
+			true AS is_favourite,
+			COALESCE(MAX(pi.product_image), '') AS product_image
+		FROM user_favourites uf
+		JOIN products p ON p.id = uf.product_id
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+		WHERE uf.user_id = $1 AND p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		GROUP BY p.id, uf.id
+		ORDER BY uf.created_at DESC, uf.id DESC
+		LIMIT $2 OFFSET $3`, userId, limit, offset)
+	if err != nil {
+		message = "Failed to fetch favourites from database"
+		return products, message, err
+	}
+	defer rows.Close()
+
+	products, err = pgx.CollectRows(rows, pgx.RowToStructByName[PublicProductResponse])
+	if err != nil {
+		message = "Failed to process favourite data from database"
+		return products, message, err
+	}
+
+	message = "Success get favourite products"
+	return products, message, nil
+}
+
+// AddFavourite returns created false when the product was already a favourite
+func AddFavourite(userId int, productId int) (bool, string, error) {
+	created := false
+	message := ""
+
+	var isActive bool
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(is_active, true) FROM products WHERE id = $1 AND deleted_at IS NULL`, productId).Scan(&isActive)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Product not found"
+			return created, message, err
+		}
+		message = "Failed to check product existence"
+		return created, message, err
+	}
+
+	if !isActive {
+		message = "Product not found"
+		return created, message, errors.New("product is not active")
+	}
+
+	commandTag, err := config.DB.Exec(context.Background(),
+		`INSERT INTO user_favourites (user_id, product_id, created_by, updated_by)
+		VALUES ($1, $2, $1, $1)
+		ON CONFLICT (user_id, product_id) DO NOTHING`, userId, productId)
+	if err != nil {
+		message = "Failed to add product to favourites"
+		return created, message, err
+	}
+
+	created = commandTag.RowsAffected() > 0
+	if created {
+		message = "Product added to favourites"
+	} else {
+		message = "Product is already in favourites"
+	}
+	return created, message, nil
+}
+
+func RemoveFavourite(userId int, productId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`DELETE FROM user_favourites WHERE user_id = $1 AND product_id = $2`, userId, productId)
+	if err != nil {
+		return false, "Failed to remove product from favourites", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Favourite not found", nil
+	}
+
+	return true, "Product removed from favourites", nil
+}
+
+// GetFavouritedProductIds returns which of productIds the user has favourited
+func GetFavouritedProductIds(userId int, productIds []int) (map[int]bool, error) {
+	favourited := map[int]bool{}
+	if len(productIds) == 0 {
+		return favourited, nil
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT product_id FROM user_favourites WHERE user_id = $1 AND product_id = ANY($2)`, userId, productIds)
+	if err != nil {
+		return favourited, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productId int
+		if err := rows.Scan(&productId); err != nil {
+			return favourited, err
+		}
+		favourited[productId] = true
+	}
+
+	return favourited, rows.Err()
+}
+
+func InvalidateFavouriteCache(ctx context.Context) error {
+	keys, err := config.Rdb.Keys(ctx, "/favourite-products*").Result()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		err = config.Rdb.Del(ctx, keys...).Err()
+		if err != nil {
+			return err
+		}
+		log.Printf("Invalidated %d cache keys for pattern /favourite-products*", len(keys))
+	}
+
+	return nil
+}
@@ -14,9 +14,13 @@ type PaymentMethod struct {
 }
 
 type OrderMethod struct {
-	Id          int     `json:"id" db:"id"`
-	Name        string  `json:"name" db:"name"`
-	DeliveryFee float64 `json:"deliveryFee" db:"delivery_fee"`
+	Id              int     `json:"id" db:"id"`
+	Name            string  `json:"name" db:"name"`
+	DeliveryFee     float64 `json:"deliveryFee" db:"delivery_fee"`
+	SlotMinutes     int     `json:"slotMinutes" db:"slot_minutes"`
+	SlotCapacity    int     `json:"slotCapacity" db:"slot_capacity"`
+	SlotLeadMinutes int     `json:"slotLeadMinutes" db:"slot_lead_minutes"`
+	SlotDaysAhead   int     `json:"slotDaysAhead" db:"slot_days_ahead"`
 }
 
 func GetAllPaymentMethods() ([]PaymentMethod, string, error) {
@@ -51,7 +55,7 @@ func GetAllOrderMethods() ([]OrderMethod, string, error) {
 
 	rows, err := config.DB.Query(
 		context.Background(),
-		`SELECT id, name, delivery_fee 
+		`SELECT id, name, delivery_fee, slot_minutes, slot_capacity, slot_lead_minutes, slot_days_ahead
 		 FROM order_methods 
 		 ORDER BY id ASC`,
 	)
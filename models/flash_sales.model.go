@@ -0,0 +1,407 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// flashSaleNextChangeKey holds the unix time of the next campaign start or end
+const flashSaleNextChangeKey = "flashSales:nextChange"
+
+type FlashSale struct {
+	Id        int                `json:"id" db:"id"`
+	Name      string             `json:"name" db:"name"`
+	StartAt   time.Time          `json:"startAt" db:"start_at"`
+	EndAt     time.Time          `json:"endAt" db:"end_at"`
+	IsActive  bool               `json:"isActive" db:"is_active"`
+	Status    string             `json:"status" db:"status"`
+	CreatedAt time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time          `json:"updatedAt" db:"updated_at"`
+	Products  []FlashSaleProduct `json:"products" db:"-"`
+}
+
+type FlashSaleProduct struct {
+	ProductId       int     `json:"productId" db:"product_id"`
+	ProductName     string  `json:"productName" db:"product_name"`
+	DiscountPercent float64 `json:"discountPercent" db:"discount_percent"`
+	QuantityCap     *int    `json:"quantityCap" db:"quantity_cap"`
+	SoldQuantity    int     `json:"soldQuantity" db:"sold_quantity"`
+}
+
+type FlashSaleRequest struct {
+	Name     string                    `json:"name"`
+	StartAt  *time.Time                `json:"startAt"`
+	EndAt    *time.Time                `json:"endAt"`
+	IsActive *bool                     `json:"isActive"`
+	Products []FlashSaleProductRequest `json:"products"`
+}
+
+type FlashSaleProductRequest struct {
+	ProductId       int     `json:"productId"`
+	DiscountPercent float64 `json:"discountPercent"`
+	QuantityCap     *int    `json:"quantityCap"`
+}
+
+const flashSaleSelect = `
+		SELECT
+			id,
+			name,
+			start_at,
+			end_at,
+			is_active,
+			CASE
+				WHEN is_active = false THEN 'disabled'
+				WHEN NOW() < start_at THEN 'scheduled'
+				WHEN NOW() < end_at THEN 'running'
+				ELSE 'ended'
+			END AS status,
+			created_at,
+			updated_at
+		FROM flash_sales`
+
+func GetTotalDataFlashSales() (int, error) {
+	totalData := 0
+	err := config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM flash_sales`).Scan(&totalData)
+	return totalData, err
+}
+
+func GetListFlashSales(page int, limit int) ([]FlashSale, string, error) {
+	offset := (page - 1) * limit
+	flashSales := []FlashSale{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		flashSaleSelect+`
+		ORDER BY start_at DESC, id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		message = "Failed to fetch flash sales from database"
+		return flashSales, message, err
+	}
+	defer rows.Close()
+
+	flashSales, err = pgx.CollectRows(rows, pgx.RowToStructByName[FlashSale])
+	if err != nil {
+		message = "Failed to process flash sale data from database"
+		return flashSales, message, err
+	}
+
+	for i := range flashSales {
+		flashSales[i].Products, err = getFlashSaleProducts(flashSales[i].Id)
+		if err != nil {
+			message = "Failed to fetch flash sale products from database"
+			return flashSales, message, err
+		}
+	}
+
+	message = "Success get all flash sales"
+	return flashSales, message, nil
+}
+
+func GetFlashSaleById(id int) (FlashSale, string, error) {
+	flashSale := FlashSale{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), flashSaleSelect+` WHERE id = $1`, id)
+	if err != nil {
+		message = "Failed to fetch flash sale from database"
+		return flashSale, message, err
+	}
+	defer rows.Close()
+
+	flashSale, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[FlashSale])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Flash sale not found"
+			return flashSale, message, err
+		}
+		message = "Failed to process flash sale data"
+		return flashSale, message, err
+	}
+
+	flashSale.Products, err = getFlashSaleProducts(id)
+	if err != nil {
+		message = "Failed to fetch flash sale products from database"
+		return flashSale, message, err
+	}
+
+	message = "Success get flash sale"
+	return flashSale, message, nil
+}
+
+func getFlashSaleProducts(flashSaleId int) ([]FlashSaleProduct, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT fsp.product_id, p.name AS product_name, fsp.discount_percent, fsp.quantity_cap, fsp.sold_quantity
+		FROM flash_sale_products fsp
+		JOIN products p ON p.id = fsp.product_id
+		WHERE fsp.flash_sale_id = $1
+		ORDER BY fsp.id ASC`, flashSaleId)
+	if err != nil {
+		return []FlashSaleProduct{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[FlashSaleProduct])
+}
+
+// validateFlashSaleProducts checks the product list of a create or update request
+func validateFlashSaleProducts(products []FlashSaleProductRequest) (string, error) {
+	seen := map[int]bool{}
+	for _, product := range products {
+		if seen[product.ProductId] {
+			return "Product is listed more than once", errors.New("duplicate product " + strconv.Itoa(product.ProductId))
+		}
+		seen[product.ProductId] = true
+
+		if product.DiscountPercent <= 0 || product.DiscountPercent > 100 {
+			return "Discount percent must be greater than 0 and at most 100", errors.New("invalid discount percent")
+		}
+		if product.QuantityCap != nil && *product.QuantityCap < 1 {
+			return "Quantity cap must be greater than 0", errors.New("invalid quantity cap")
+		}
+	}
+	return "", nil
+}
+
+// replaceFlashSaleProducts swaps the product list, keeping sold quantities of products that stay
+func replaceFlashSaleProducts(ctx context.Context, tx pgx.Tx, flashSaleId int, userId int, products []FlashSaleProductRequest) (string, error) {
+	productIds := make([]int, len(products))
+	for i, product := range products {
+		productIds[i] = product.ProductId
+	}
+
+	var found int
+	err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM products WHERE id = ANY($1::int[])`, productIds).Scan(&found)
+	if err != nil {
+		return "Failed to check product existence", err
+	}
+	if found != len(productIds) {
+		return "Product not found", errors.New("flash sale lists an unknown product")
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM flash_sale_products WHERE flash_sale_id = $1 AND NOT (product_id = ANY($2::int[]))`,
+		flashSaleId, productIds)
+	if err != nil {
+		return "Failed to update flash sale products", err
+	}
+
+	for _, product := range products {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO flash_sale_products (flash_sale_id, product_id, discount_percent, quantity_cap, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			ON CONFLICT (flash_sale_id, product_id) DO UPDATE
+			SET discount_percent = EXCLUDED.discount_percent,
+				quantity_cap = EXCLUDED.quantity_cap,
+				updated_by = EXCLUDED.updated_by,
+				updated_at = NOW()`,
+			flashSaleId, product.ProductId, product.DiscountPercent, product.QuantityCap, userId)
+		if err != nil {
+			return "Failed to save flash sale products", err
+		}
+	}
+
+	return "", nil
+}
+
+func InsertFlashSale(userId int, bodyCreate FlashSaleRequest) (int, string, error) {
+	ctx := context.Background()
+	message := ""
+
+	if bodyCreate.Name == "" || bodyCreate.StartAt == nil || bodyCreate.EndAt == nil {
+		message = "Name, startAt and endAt are required"
+		return 0, message, errors.New("missing required field")
+	}
+	if !bodyCreate.EndAt.After(*bodyCreate.StartAt) {
+		message = "endAt must be after startAt"
+		return 0, message, errors.New("invalid flash sale window")
+	}
+	if len(bodyCreate.Products) == 0 {
+		message = "Flash sale needs at least one product"
+		return 0, message, errors.New("empty product list")
+	}
+	if message, err := validateFlashSaleProducts(bodyCreate.Products); err != nil {
+		return 0, message, err
+	}
+
+	isActive := true
+	if bodyCreate.IsActive != nil {
+		isActive = *bodyCreate.IsActive
+	}
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return 0, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var flashSaleId int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO flash_sales (name, start_at, end_at, is_active, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id`,
+		bodyCreate.Name, *bodyCreate.StartAt, *bodyCreate.EndAt, isActive, userId).Scan(&flashSaleId)
+	if err != nil {
+		message = "Internal server error while inserting flash sale"
+		return 0, message, err
+	}
+
+	if message, err := replaceFlashSaleProducts(ctx, tx, flashSaleId, userId, bodyCreate.Products); err != nil {
+		return 0, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return 0, message, err
+	}
+
+	message = "Flash sale created successfully"
+	return flashSaleId, message, nil
+}
+
+// UpdateFlashSale only replaces the product list when products is sent
+func UpdateFlashSale(flashSaleId int, userId int, bodyUpdate FlashSaleRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	if bodyUpdate.Products != nil {
+		if len(bodyUpdate.Products) == 0 {
+			message = "Flash sale needs at least one product"
+			return isSuccess, message, errors.New("empty product list")
+		}
+		if message, err := validateFlashSaleProducts(bodyUpdate.Products); err != nil {
+			return isSuccess, message, err
+		}
+	}
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var startAt, endAt time.Time
+	err = tx.QueryRow(ctx,
+		`UPDATE flash_sales
+		SET name = COALESCE(NULLIF($1, ''), name),
+			start_at = COALESCE($2, start_at),
+			end_at = COALESCE($3, end_at),
+			is_active = COALESCE($4, is_active),
+			updated_by = $5,
+			updated_at = NOW()
+		WHERE id = $6
+		RETURNING start_at, end_at`,
+		bodyUpdate.Name, bodyUpdate.StartAt, bodyUpdate.EndAt, bodyUpdate.IsActive, userId, flashSaleId).Scan(&startAt, &endAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Flash sale not found"
+			return isSuccess, message, nil
+		}
+		message = "Internal server error while updating flash sale"
+		return isSuccess, message, err
+	}
+
+	if !endAt.After(startAt) {
+		message = "endAt must be after startAt"
+		return isSuccess, message, errors.New("invalid flash sale window")
+	}
+
+	if bodyUpdate.Products != nil {
+		if message, err := replaceFlashSaleProducts(ctx, tx, flashSaleId, userId, bodyUpdate.Products); err != nil {
+			return isSuccess, message, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Flash sale updated successfully"
+	return isSuccess, message, nil
+}
+
+func DeleteFlashSale(flashSaleId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM flash_sales WHERE id = $1`, flashSaleId)
+	if err != nil {
+		return false, "Internal server error while deleting flash sale", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Flash sale not found", nil
+	}
+
+	return true, "Flash sale deleted successfully", nil
+}
+
+// InvalidateFlashSaleCache drops cached prices after a campaign changes
+func InvalidateFlashSaleCache(ctx context.Context) error {
+	if err := config.Rdb.Del(ctx, flashSaleNextChangeKey).Err(); err != nil {
+		return err
+	}
+	return InvalidateProductCache(ctx)
+}
+
+// SyncFlashSaleCache drops cached prices once a campaign starts or ends, and cached listings once
+// a product is published, unpublished or its daily hours open or close.
+// It is checked on read instead of by a timer so it also works on serverless deployments.
+func SyncFlashSaleCache(ctx context.Context) error {
+	nextChange, err := config.Rdb.Get(ctx, flashSaleNextChangeKey).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if err == nil && time.Now().Unix() < nextChange {
+		return nil
+	}
+
+	if err == nil {
+		log.Printf("Flash sale or product schedule changed, invalidating product cache")
+		if err := InvalidateProductCache(ctx); err != nil {
+			return err
+		}
+	}
+
+	var next *time.Time
+	err = config.DB.QueryRow(ctx,
+		`SELECT MIN(boundary) FROM (
+			SELECT start_at AS boundary FROM flash_sales WHERE is_active = true AND start_at > NOW()
+			UNION ALL
+			SELECT end_at AS boundary FROM flash_sales WHERE is_active = true AND end_at > NOW()
+			UNION ALL
+			SELECT publish_at AS boundary FROM products WHERE deleted_at IS NULL AND publish_at > NOW()
+			UNION ALL
+			SELECT unpublish_at AS boundary FROM products WHERE deleted_at IS NULL AND unpublish_at > NOW()
+			UNION ALL
+			-- next time the daily hours open or close, in the store timezone
+			SELECT (
+				(NOW() AT TIME ZONE $1)::date + h.hour
+				+ CASE WHEN h.hour <= (NOW() AT TIME ZONE $1)::time THEN INTERVAL '1 day' ELSE INTERVAL '0' END
+			) AT TIME ZONE $1 AS boundary
+			FROM products p
+			CROSS JOIN LATERAL (VALUES (p.available_from), (p.available_until)) h(hour)
+			WHERE p.deleted_at IS NULL AND h.hour IS NOT NULL
+		) boundaries`, config.StoreLocation.String()).Scan(&next)
+	if err != nil {
+		return err
+	}
+
+	// nothing scheduled, look again later in case a campaign was added without invalidation
+	nextUnix := time.Now().Add(time.Hour).Unix()
+	if next != nil {
+		nextUnix = next.Unix()
+	}
+	return config.Rdb.Set(ctx, flashSaleNextChangeKey, nextUnix, 0).Err()
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"backend-daily-greens/config"
+	"backend-daily-greens/utils"
 	"context"
 	"errors"
 	"fmt"
@@ -24,48 +25,56 @@ type HistoryDetail struct {
 	UserId           int            `json:"userId" db:"user_id"`
 	NoInvoice        string         `json:"noInvoice" db:"no_invoice"`
 	DateTransaction  time.Time      `json:"dateOrder" db:"date_transaction"`
+	ScheduledAt      *time.Time     `json:"scheduledAt" db:"scheduled_at"`
 	FullName         string         `json:"fullName" db:"full_name"`
 	Email            string         `json:"email" db:"email"`
 	Address          string         `json:"address" db:"address"`
 	Phone            string         `json:"phone" db:"phone"`
 	PaymentMethod    string         `json:"paymentMethod" db:"payment_method"`
 	OrderMethod      string         `json:"orderMethod" db:"order_method"`
+	DeliveryZone     *string        `json:"deliveryZone" db:"delivery_zone"`
 	Status           string         `json:"status" db:"status"`
 	DeliveryFee      float64        `json:"deliveryFee" db:"delivery_fee"`
 	AdminFee         float64        `json:"adminFee" db:"admin_fee"`
 	Tax              float64        `json:"tax" db:"tax"`
 	TotalTransaction float64        `json:"totalTransaction" db:"total_transaction"`
+	TotalRefunded    float64        `json:"totalRefunded" db:"total_refunded"`
+	NetTotal         float64        `json:"netTotal" db:"net_total"`
 	HistoryItems     []HistoryItems `json:"historyItems" db:"-"`
 }
 
 type HistoryItems struct {
-	Id              int     `json:"id" db:"id"`
-	TransactionId   int     `json:"transactionId" db:"transaction_id"`
-	ProductId       int     `json:"productId" db:"product_id"`
-	ProductName     string  `json:"productName" db:"product_name"`
-	ProductImage    string  `json:"productImage" db:"product_image"`
-	ProductPrice    float64 `json:"productPrice" db:"product_price"`
-	DiscountPercent float64 `json:"discountPercent" db:"discount_percent"`
-	DiscountPrice   float64 `json:"discountPrice" db:"discount_price"`
-	SizeName        string  `json:"sizeName" db:"size"`
-	SizeCost        float64 `json:"sizeCost" db:"size_cost"`
-	VariantName     string  `json:"variantName" db:"variant"`
-	VariantCost     float64 `json:"variantCost" db:"variant_cost"`
-	Amount          int     `json:"amount" db:"amount"`
-	Subtotal        float64 `json:"subtotal" db:"subtotal"`
+	Id              int                `json:"id" db:"id"`
+	TransactionId   int                `json:"transactionId" db:"transaction_id"`
+	ProductId       int                `json:"productId" db:"product_id"`
+	ProductName     string             `json:"productName" db:"product_name"`
+	ProductImage    string             `json:"productImage" db:"product_image"`
+	ProductPrice    float64            `json:"productPrice" db:"product_price"`
+	DiscountPercent float64            `json:"discountPercent" db:"discount_percent"`
+	DiscountPrice   float64            `json:"discountPrice" db:"discount_price"`
+	SizeName        string             `json:"sizeName" db:"size"`
+	SizeCost        float64            `json:"sizeCost" db:"size_cost"`
+	VariantName     string             `json:"variantName" db:"variant"`
+	VariantCost     float64            `json:"variantCost" db:"variant_cost"`
+	ModifiersCost   float64            `json:"modifiersCost" db:"modifiers_cost"`
+	Modifiers       []SelectedModifier `json:"modifiers" db:"modifiers"`
+	Components      []BundleComponent  `json:"components" db:"components"`
+	Amount          int                `json:"amount" db:"amount"`
+	Subtotal        float64            `json:"subtotal" db:"subtotal"`
+	RefundedAmount  int                `json:"refundedAmount" db:"refunded_amount"`
+	RefundedTotal   float64            `json:"refundedTotal" db:"refunded_total"`
 }
 
-func GetListHistories(userId int, page int, limit int, date string, statusId int) ([]History, int, string, error) {
-	histories := []History{}
-	totalData := 0
-	message := ""
+// historyListQuery builds the history list query of a user grouped per transaction, without order and limit
+func historyListQuery(userId int, date string, statusId int) (string, []any) {
 	query := `SELECT 
 				t.id,
 				t.no_invoice,
 				t.date_transaction,
 				s.name AS status,
 				t.total_transaction,
-				COALESCE(MAX(pi.product_image), '') AS image
+				COALESCE(MAX(pi.product_image), '') AS image,
+				t.date_transaction::text AS sort_key
 			FROM transactions t
 			JOIN status s ON t.status_id = s.id
 			JOIN transaction_items ti ON t.id = ti.transaction_id
@@ -85,23 +94,44 @@ func GetListHistories(userId int, page int, limit int, date string, statusId int
 	if statusId > 0 {
 		query += fmt.Sprintf(" AND t.status_id = $%d", paramIndex)
 		params = append(params, statusId)
-		paramIndex++
 	}
 
-	groupClause := " GROUP BY t.id, s.name, t.no_invoice, t.date_transaction, t.total_transaction"
+	return query, params
+}
+
+const historyGroupClause = " GROUP BY t.id, s.name, t.no_invoice, t.date_transaction, t.total_transaction"
 
-	// get total data
-	countQuery := "SELECT COUNT(*) FROM (" + query + groupClause + ") AS sub"
+// historyRow carries the keyset sort key next to the history
+type historyRow struct {
+	History
+	SortKey string `db:"sort_key"`
+}
+
+func GetTotalDataHistories(userId int, date string, statusId int) (int, error) {
+	totalData := 0
+	query, params := historyListQuery(userId, date, statusId)
+
+	countQuery := "SELECT COUNT(*) FROM (" + query + historyGroupClause + ") AS sub"
 	err := config.DB.QueryRow(context.Background(), countQuery, params...).Scan(&totalData)
+	return totalData, err
+}
+
+func GetListHistories(userId int, page int, limit int, date string, statusId int) ([]History, int, string, error) {
+	histories := []History{}
+	message := ""
+
+	// get total data
+	totalData, err := GetTotalDataHistories(userId, date, statusId)
 	if err != nil {
 		message = "Failed to count total transactions in database"
 		return histories, totalData, message, err
 	}
 
-	query += groupClause
+	query, params := historyListQuery(userId, date, statusId)
+	query += historyGroupClause
 
 	offset := (page - 1) * limit
-	query += fmt.Sprintf(" ORDER BY t.date_transaction DESC, t.id DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
+	query += fmt.Sprintf(" ORDER BY t.date_transaction DESC, t.id DESC LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
 	params = append(params, limit, offset)
 
 	rows, err := config.DB.Query(context.Background(), query, params...)
@@ -111,16 +141,66 @@ func GetListHistories(userId int, page int, limit int, date string, statusId int
 	}
 	defer rows.Close()
 
-	histories, err = pgx.CollectRows(rows, pgx.RowToStructByName[History])
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByName[historyRow])
 	if err != nil {
 		message = "Failed to process transaction data from database"
 		return histories, totalData, message, err
 	}
+	for _, row := range result {
+		histories = append(histories, row.History)
+	}
 
 	message = "Successfully retrieved transaction histories"
 	return histories, totalData, message, nil
 }
 
+// GetListHistoriesCursor lists histories newest first with keyset pagination on (date_transaction, id)
+func GetListHistoriesCursor(userId int, encodedCursor string, limit int, date string, statusId int) ([]History, utils.CursorPage, string, error) {
+	histories := []History{}
+	message := ""
+
+	cursor, err := utils.DecodeCursor(encodedCursor, "date_desc")
+	if err != nil {
+		message = "Invalid cursor"
+		return histories, utils.CursorPage{}, message, err
+	}
+
+	query, params := historyListQuery(userId, date, statusId)
+
+	condition, orderBy := utils.KeysetCondition("t.date_transaction", "t.id", "timestamp", true, cursor, len(params)+1)
+	if cursor != nil {
+		query += " AND " + condition
+		params = append(params, cursor.Value, cursor.Id)
+	}
+
+	query += historyGroupClause
+	params = append(params, limit+1)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy, len(params))
+
+	rows, err := config.DB.Query(context.Background(), query, params...)
+	if err != nil {
+		message = "Failed to fetch transactions from database"
+		return histories, utils.CursorPage{}, message, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByName[historyRow])
+	if err != nil {
+		message = "Failed to process transaction data from database"
+		return histories, utils.CursorPage{}, message, err
+	}
+
+	result, cursors := utils.KeysetPage(result, limit, cursor, "date_desc", func(row historyRow) (string, int) {
+		return row.SortKey, row.Id
+	})
+	for _, row := range result {
+		histories = append(histories, row.History)
+	}
+
+	message = "Successfully retrieved transaction histories"
+	return histories, cursors, message, nil
+}
+
 func GetDetailHistory(noInvoice string) (HistoryDetail, string, error) {
 	historyDetail := HistoryDetail{}
 	message := ""
@@ -139,25 +219,36 @@ func GetDetailHistory(noInvoice string) (HistoryDetail, string, error) {
 			t.user_id,
 			t.no_invoice,
 			t.date_transaction,
+			t.scheduled_at,
 			t.full_name,
 			t.email,
 			t.address,
 			t.phone,
 			pm.name AS payment_method,
 			om.name AS order_method,
+			dz.name AS delivery_zone,
 			s.name AS status,
 			t.delivery_fee,
 			t.admin_fee,
 			t.tax,
-			t.total_transaction
+			t.total_transaction,
+			COALESCE(r.total_refunded, 0) AS total_refunded,
+			t.total_transaction - COALESCE(r.total_refunded, 0) AS net_total
 		FROM 
 			transactions t
 		JOIN 
 			payment_methods pm ON t.payment_method_id = pm.id
 		JOIN
 			order_methods om ON t.order_method_id = om.id
+		LEFT JOIN
+			delivery_zones dz ON t.delivery_zone_id = dz.id
 		JOIN 
 			status s ON t.status_id = s.id
+		LEFT JOIN (
+			SELECT transaction_id, SUM(refund_amount) AS total_refunded
+			FROM refunds
+			GROUP BY transaction_id
+		) r ON r.transaction_id = t.id
 		WHERE t.no_invoice = $1`, noInvoice)
 	if err != nil {
 		message = "Failed to fetch history from database"
@@ -190,8 +281,11 @@ func GetDetailHistory(noInvoice string) (HistoryDetail, string, error) {
 			ti.size_cost,
 			ti.variant,
 			ti.variant_cost,
+			ti.modifiers_cost,`+transactionItemModifiersColumn+`,`+transactionItemComponentsColumn+`,
 			ti.amount,
-			ti.subtotal
+			ti.subtotal,
+			COALESCE((SELECT SUM(ri.amount) FROM refund_items ri WHERE ri.transaction_item_id = ti.id), 0)::int AS refunded_amount,
+			COALESCE((SELECT SUM(ri.refund_amount) FROM refund_items ri WHERE ri.transaction_item_id = ti.id), 0) AS refunded_total
 		FROM transaction_items ti
 		LEFT JOIN product_images pi ON ti.product_id = pi.product_id
 		WHERE transaction_id = $1
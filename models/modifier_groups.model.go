@@ -0,0 +1,529 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ModifierGroup struct {
+	Id        int              `json:"id" db:"id"`
+	Name      string           `json:"name" db:"name"`
+	MinSelect int              `json:"minSelect" db:"min_select"`
+	MaxSelect int              `json:"maxSelect" db:"max_select"`
+	CreatedAt time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time        `json:"updatedAt" db:"updated_at"`
+	Options   []ModifierOption `json:"options" db:"-"`
+}
+
+type ModifierOption struct {
+	Id        int     `json:"id" db:"id"`
+	Name      string  `json:"name" db:"name"`
+	Price     float64 `json:"price" db:"price"`
+	IsActive  bool    `json:"isActive" db:"is_active"`
+	SortOrder int     `json:"sortOrder" db:"sort_order"`
+}
+
+type ModifierGroupRequest struct {
+	Name      string                  `json:"name"`
+	MinSelect *int                    `json:"minSelect"`
+	MaxSelect *int                    `json:"maxSelect"`
+	Options   []ModifierOptionRequest `json:"options"`
+}
+
+// ModifierOptionRequest updates the option with the given id, an option without id is added
+type ModifierOptionRequest struct {
+	Id        *int    `json:"id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	IsActive  *bool   `json:"isActive"`
+	SortOrder int     `json:"sortOrder"`
+}
+
+// ProductModifierGroup is a group attached to a product with its orderable options
+type ProductModifierGroup struct {
+	Id        int              `json:"id" db:"id"`
+	Name      string           `json:"name" db:"name"`
+	MinSelect int              `json:"minSelect" db:"min_select"`
+	MaxSelect int              `json:"maxSelect" db:"max_select"`
+	Options   []ModifierOption `json:"options" db:"options"`
+}
+
+// SelectedModifier is an option chosen on a cart line or a copy of it on an ordered product
+type SelectedModifier struct {
+	ModifierOptionId *int    `json:"modifierOptionId"`
+	GroupName        string  `json:"groupName"`
+	OptionName       string  `json:"optionName"`
+	Price            float64 `json:"price"`
+}
+
+const modifierGroupSelect = `
+		SELECT id, name, min_select, max_select, created_at, updated_at
+		FROM modifier_groups`
+
+func GetTotalDataModifierGroups() (int, error) {
+	totalData := 0
+	err := config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM modifier_groups`).Scan(&totalData)
+	return totalData, err
+}
+
+func GetListModifierGroups(page int, limit int) ([]ModifierGroup, string, error) {
+	offset := (page - 1) * limit
+	modifierGroups := []ModifierGroup{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		modifierGroupSelect+`
+		ORDER BY name ASC, id ASC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		message = "Failed to fetch modifier groups from database"
+		return modifierGroups, message, err
+	}
+	defer rows.Close()
+
+	modifierGroups, err = pgx.CollectRows(rows, pgx.RowToStructByName[ModifierGroup])
+	if err != nil {
+		message = "Failed to process modifier group data from database"
+		return modifierGroups, message, err
+	}
+
+	for i := range modifierGroups {
+		modifierGroups[i].Options, err = getModifierOptions(modifierGroups[i].Id)
+		if err != nil {
+			message = "Failed to fetch modifier options from database"
+			return modifierGroups, message, err
+		}
+	}
+
+	message = "Success get all modifier groups"
+	return modifierGroups, message, nil
+}
+
+func GetModifierGroupById(id int) (ModifierGroup, string, error) {
+	modifierGroup := ModifierGroup{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), modifierGroupSelect+` WHERE id = $1`, id)
+	if err != nil {
+		message = "Failed to fetch modifier group from database"
+		return modifierGroup, message, err
+	}
+	defer rows.Close()
+
+	modifierGroup, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[ModifierGroup])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Modifier group not found"
+			return modifierGroup, message, err
+		}
+		message = "Failed to process modifier group data"
+		return modifierGroup, message, err
+	}
+
+	modifierGroup.Options, err = getModifierOptions(id)
+	if err != nil {
+		message = "Failed to fetch modifier options from database"
+		return modifierGroup, message, err
+	}
+
+	message = "Success get modifier group"
+	return modifierGroup, message, nil
+}
+
+func getModifierOptions(modifierGroupId int) ([]ModifierOption, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, name, price, is_active, sort_order
+		FROM modifier_options
+		WHERE modifier_group_id = $1
+		ORDER BY sort_order ASC, id ASC`, modifierGroupId)
+	if err != nil {
+		return []ModifierOption{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[ModifierOption])
+}
+
+// validateModifierLimits checks the selection limits against the number of options
+func validateModifierLimits(minSelect int, maxSelect int, optionCount int) (string, error) {
+	if minSelect < 0 || maxSelect < 1 || maxSelect < minSelect {
+		return "minSelect must be at least 0 and maxSelect at least 1 and not below minSelect", errors.New("invalid selection limits")
+	}
+	if maxSelect > optionCount {
+		return "maxSelect cannot be greater than the number of options", errors.New("invalid selection limits")
+	}
+	return "", nil
+}
+
+// validateModifierOptions checks the option list of a create or update request
+func validateModifierOptions(options []ModifierOptionRequest) (string, error) {
+	seen := map[string]bool{}
+	for _, option := range options {
+		name := strings.ToLower(strings.TrimSpace(option.Name))
+		if name == "" {
+			return "Option name is required", errors.New("missing option name")
+		}
+		if seen[name] {
+			return "Option name is listed more than once", errors.New("duplicate option " + option.Name)
+		}
+		seen[name] = true
+
+		if option.Price < 0 {
+			return "Option price cannot be negative", errors.New("invalid option price")
+		}
+	}
+	return "", nil
+}
+
+// replaceModifierOptions swaps the option list, options sent with their id keep it so cart lines stay valid
+func replaceModifierOptions(ctx context.Context, tx pgx.Tx, modifierGroupId int, userId int, options []ModifierOptionRequest) (string, error) {
+	keepIds := []int{}
+	for _, option := range options {
+		if option.Id != nil {
+			keepIds = append(keepIds, *option.Id)
+		}
+	}
+
+	var found int
+	err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM modifier_options WHERE modifier_group_id = $1 AND id = ANY($2::int[])`,
+		modifierGroupId, keepIds).Scan(&found)
+	if err != nil {
+		return "Failed to check modifier option existence", err
+	}
+	if found != len(keepIds) {
+		return "Modifier option not found", errors.New("modifier group lists an unknown option")
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM modifier_options WHERE modifier_group_id = $1 AND NOT (id = ANY($2::int[]))`,
+		modifierGroupId, keepIds)
+	if err != nil {
+		return "Failed to update modifier options", err
+	}
+
+	// names are renamed before they are rewritten so swapping two names does not hit the unique key
+	_, err = tx.Exec(ctx,
+		`UPDATE modifier_options SET name = '#' || id WHERE modifier_group_id = $1`, modifierGroupId)
+	if err != nil {
+		return "Failed to update modifier options", err
+	}
+
+	for _, option := range options {
+		isActive := true
+		if option.IsActive != nil {
+			isActive = *option.IsActive
+		}
+
+		if option.Id != nil {
+			_, err = tx.Exec(ctx,
+				`UPDATE modifier_options
+				SET name = $1, price = $2, is_active = $3, sort_order = $4, updated_by = $5, updated_at = NOW()
+				WHERE id = $6`,
+				strings.TrimSpace(option.Name), option.Price, isActive, option.SortOrder, userId, *option.Id)
+		} else {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO modifier_options (modifier_group_id, name, price, is_active, sort_order, created_by, updated_by)
+				VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+				modifierGroupId, strings.TrimSpace(option.Name), option.Price, isActive, option.SortOrder, userId)
+		}
+		if err != nil {
+			return "Failed to save modifier options", err
+		}
+	}
+
+	return "", nil
+}
+
+func InsertModifierGroup(userId int, bodyCreate ModifierGroupRequest) (int, string, error) {
+	ctx := context.Background()
+	message := ""
+
+	if strings.TrimSpace(bodyCreate.Name) == "" {
+		message = "Name is required"
+		return 0, message, errors.New("missing required field")
+	}
+	if len(bodyCreate.Options) == 0 {
+		message = "Modifier group needs at least one option"
+		return 0, message, errors.New("empty option list")
+	}
+
+	minSelect, maxSelect := 0, 1
+	if bodyCreate.MinSelect != nil {
+		minSelect = *bodyCreate.MinSelect
+	}
+	if bodyCreate.MaxSelect != nil {
+		maxSelect = *bodyCreate.MaxSelect
+	}
+	if message, err := validateModifierOptions(bodyCreate.Options); err != nil {
+		return 0, message, err
+	}
+	if message, err := validateModifierLimits(minSelect, maxSelect, len(bodyCreate.Options)); err != nil {
+		return 0, message, err
+	}
+	for _, option := range bodyCreate.Options {
+		if option.Id != nil {
+			message = "Modifier option not found"
+			return 0, message, errors.New("new modifier group lists an existing option")
+		}
+	}
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return 0, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var modifierGroupId int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO modifier_groups (name, min_select, max_select, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING id`,
+		strings.TrimSpace(bodyCreate.Name), minSelect, maxSelect, userId).Scan(&modifierGroupId)
+	if err != nil {
+		message = "Internal server error while inserting modifier group"
+		return 0, message, err
+	}
+
+	if message, err := replaceModifierOptions(ctx, tx, modifierGroupId, userId, bodyCreate.Options); err != nil {
+		return 0, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return 0, message, err
+	}
+
+	message = "Modifier group created successfully"
+	return modifierGroupId, message, nil
+}
+
+// UpdateModifierGroup only replaces the option list when options is sent
+func UpdateModifierGroup(modifierGroupId int, userId int, bodyUpdate ModifierGroupRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	if bodyUpdate.Options != nil {
+		if len(bodyUpdate.Options) == 0 {
+			message = "Modifier group needs at least one option"
+			return isSuccess, message, errors.New("empty option list")
+		}
+		if message, err := validateModifierOptions(bodyUpdate.Options); err != nil {
+			return isSuccess, message, err
+		}
+	}
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var minSelect, maxSelect int
+	err = tx.QueryRow(ctx,
+		`UPDATE modifier_groups
+		SET name = COALESCE(NULLIF($1, ''), name),
+			min_select = COALESCE($2, min_select),
+			max_select = COALESCE($3, max_select),
+			updated_by = $4,
+			updated_at = NOW()
+		WHERE id = $5
+		RETURNING min_select, max_select`,
+		strings.TrimSpace(bodyUpdate.Name), bodyUpdate.MinSelect, bodyUpdate.MaxSelect, userId, modifierGroupId).Scan(&minSelect, &maxSelect)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Modifier group not found"
+			return isSuccess, message, nil
+		}
+		message = "Internal server error while updating modifier group"
+		return isSuccess, message, err
+	}
+
+	// limits are checked against the options the group has after the update
+	optionCount := len(bodyUpdate.Options)
+	if bodyUpdate.Options == nil {
+		err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM modifier_options WHERE modifier_group_id = $1`, modifierGroupId).Scan(&optionCount)
+		if err != nil {
+			message = "Failed to check modifier option existence"
+			return isSuccess, message, err
+		}
+	}
+	if message, err := validateModifierLimits(minSelect, maxSelect, optionCount); err != nil {
+		return isSuccess, message, err
+	}
+
+	if bodyUpdate.Options != nil {
+		if message, err := replaceModifierOptions(ctx, tx, modifierGroupId, userId, bodyUpdate.Options); err != nil {
+			return isSuccess, message, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Modifier group updated successfully"
+	return isSuccess, message, nil
+}
+
+func DeleteModifierGroup(modifierGroupId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM modifier_groups WHERE id = $1`, modifierGroupId)
+	if err != nil {
+		return false, "Internal server error while deleting modifier group", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Modifier group not found", nil
+	}
+
+	return true, "Modifier group deleted successfully", nil
+}
+
+func InsertProductModifierGroups(tx pgx.Tx, productId int, modifierGroupIds []int, userId int) error {
+	for i, modifierGroupId := range modifierGroupIds {
+		_, err := tx.Exec(
+			context.Background(),
+			`INSERT INTO product_modifier_groups (product_id, modifier_group_id, sort_order, created_by, updated_by)
+			 VALUES ($1, $2, $3, $4, $5)`,
+			productId,
+			modifierGroupId,
+			i,
+			userId,
+			userId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DeleteProductModifierGroups(tx pgx.Tx, productId int) error {
+	_, err := tx.Exec(
+		context.Background(),
+		`DELETE FROM product_modifier_groups WHERE product_id = $1`,
+		productId,
+	)
+	return err
+}
+
+// getProductModifierGroups lists the groups of a product in the order they were attached, inactive options are left out
+func getProductModifierGroups(tx pgx.Tx, productId int) ([]ProductModifierGroup, error) {
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+			g.id,
+			g.name,
+			g.min_select,
+			g.max_select,
+			COALESCE(
+				JSON_AGG(
+					JSON_BUILD_OBJECT('id', o.id, 'name', o.name, 'price', o.price, 'isActive', o.is_active, 'sortOrder', o.sort_order)
+					ORDER BY o.sort_order, o.id
+				) FILTER (WHERE o.id IS NOT NULL),
+				'[]'
+			) AS options
+		FROM product_modifier_groups pmg
+		JOIN modifier_groups g ON g.id = pmg.modifier_group_id
+		LEFT JOIN modifier_options o ON o.modifier_group_id = g.id AND o.is_active = true
+		WHERE pmg.product_id = $1
+		GROUP BY g.id, pmg.sort_order
+		ORDER BY pmg.sort_order, g.id`, productId)
+	if err != nil {
+		return []ProductModifierGroup{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[ProductModifierGroup])
+}
+
+// resolveCartModifiers checks the chosen options against the groups of the product.
+// It returns the key that identifies the choice on a cart line and the price the options add to one item.
+func resolveCartModifiers(ctx context.Context, tx pgx.Tx, productId int, optionIds []int) (string, float64, string, error) {
+	seen := map[int]bool{}
+	for _, optionId := range optionIds {
+		if seen[optionId] {
+			return "", 0, "Modifier option is selected more than once", fmt.Errorf("option %d is selected more than once", optionId)
+		}
+		seen[optionId] = true
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT
+			g.id,
+			g.name,
+			g.min_select,
+			g.max_select,
+			COUNT(o.id)::int AS selected,
+			COALESCE(SUM(o.price), 0) AS cost
+		FROM product_modifier_groups pmg
+		JOIN modifier_groups g ON g.id = pmg.modifier_group_id
+		LEFT JOIN modifier_options o ON o.modifier_group_id = g.id AND o.is_active = true AND o.id = ANY($2::int[])
+		WHERE pmg.product_id = $1
+		GROUP BY g.id`, productId, optionIds)
+	if err != nil {
+		return "", 0, "Internal server error while checking modifiers", err
+	}
+	defer rows.Close()
+
+	matched := 0
+	var totalCost float64
+	for rows.Next() {
+		var groupId, minSelect, maxSelect, selected int
+		var groupName string
+		var cost float64
+		if err := rows.Scan(&groupId, &groupName, &minSelect, &maxSelect, &selected, &cost); err != nil {
+			return "", 0, "Internal server error while checking modifiers", err
+		}
+		if message, err := checkModifierSelection(groupName, minSelect, maxSelect, selected); err != nil {
+			return "", 0, message, err
+		}
+		matched += selected
+		totalCost += cost
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, "Internal server error while checking modifiers", err
+	}
+
+	if matched != len(optionIds) {
+		return "", 0, "Modifier option is not available for this product", errors.New("option does not belong to the product or is inactive")
+	}
+
+	return modifierOptionsKey(optionIds), totalCost, "", nil
+}
+
+// checkModifierSelection checks the number of options selected in a group against its min and max
+func checkModifierSelection(groupName string, minSelect int, maxSelect int, selected int) (string, error) {
+	if selected < minSelect {
+		return "Required modifier is not selected", fmt.Errorf("select at least %d option(s) of %s", minSelect, groupName)
+	}
+	if selected > maxSelect {
+		return "Too many modifier options selected", fmt.Errorf("select at most %d option(s) of %s", maxSelect, groupName)
+	}
+	return "", nil
+}
+
+// modifierOptionsKey is the sorted option ids, so the same options in any order end up in the same cart line
+func modifierOptionsKey(optionIds []int) string {
+	sortedIds := append([]int{}, optionIds...)
+	sort.Ints(sortedIds)
+	keyParts := make([]string, len(sortedIds))
+	for i, optionId := range sortedIds {
+		keyParts[i] = strconv.Itoa(optionId)
+	}
+	return strings.Join(keyParts, ",")
+}
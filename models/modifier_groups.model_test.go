@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestCheckModifierSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		minSelect   int
+		maxSelect   int
+		selected    int
+		wantMessage string
+	}{
+		{"optional group left empty", 0, 1, 0, ""},
+		{"optional group with one option", 0, 1, 1, ""},
+		{"optional group with too many options", 0, 1, 2, "Too many modifier options selected"},
+		{"required group left empty", 1, 1, 0, "Required modifier is not selected"},
+		{"required group with one option", 1, 1, 1, ""},
+		{"pick two to three, one selected", 2, 3, 1, "Required modifier is not selected"},
+		{"pick two to three, two selected", 2, 3, 2, ""},
+		{"pick two to three, three selected", 2, 3, 3, ""},
+		{"pick two to three, four selected", 2, 3, 4, "Too many modifier options selected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := checkModifierSelection("Milk", tt.minSelect, tt.maxSelect, tt.selected)
+			if message != tt.wantMessage {
+				t.Errorf("checkModifierSelection() message = %q, want %q", message, tt.wantMessage)
+			}
+			if (err != nil) != (tt.wantMessage != "") {
+				t.Errorf("checkModifierSelection() err = %v, want error %v", err, tt.wantMessage != "")
+			}
+		})
+	}
+}
+
+func TestModifierOptionsKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		optionIds []int
+		want      string
+	}{
+		{"no options", nil, ""},
+		{"one option", []int{7}, "7"},
+		{"sorted", []int{2, 10, 3}, "2,3,10"},
+		{"same options in another order", []int{10, 3, 2}, "2,3,10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := modifierOptionsKey(tt.optionIds); got != tt.want {
+				t.Errorf("modifierOptionsKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
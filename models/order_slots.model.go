@@ -0,0 +1,258 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OrderSlot is a time an order of an order method can be scheduled for, remaining is what is left of its capacity
+type OrderSlot struct {
+	StartAt     time.Time `json:"startAt"`
+	EndAt       time.Time `json:"endAt"`
+	Capacity    int       `json:"capacity"`
+	Remaining   int       `json:"remaining"`
+	IsAvailable bool      `json:"isAvailable"`
+}
+
+// OrderMethodSlotsRequest sets how scheduled orders of an order method are taken
+type OrderMethodSlotsRequest struct {
+	SlotMinutes     int `json:"slotMinutes" example:"15"`
+	SlotCapacity    int `json:"slotCapacity" example:"10"`
+	SlotLeadMinutes int `json:"slotLeadMinutes" example:"15"`
+	SlotDaysAhead   int `json:"slotDaysAhead" example:"7"`
+}
+
+// orderSlotSettings are the slot settings of an order method
+type orderSlotSettings struct {
+	SlotMinutes     int `db:"slot_minutes"`
+	SlotCapacity    int `db:"slot_capacity"`
+	SlotLeadMinutes int `db:"slot_lead_minutes"`
+	SlotDaysAhead   int `db:"slot_days_ahead"`
+}
+
+const orderSlotSettingsSelect = `SELECT slot_minutes, slot_capacity, slot_lead_minutes, slot_days_ahead FROM order_methods WHERE id = $1`
+
+// windowAt is the opening window t falls in, a window of the day before can run past midnight
+func (schedule storeSchedule) windowAt(t time.Time) (time.Time, time.Time, bool) {
+	t = t.In(config.StoreLocation)
+	for offset := -1; offset <= 0; offset++ {
+		opensAt, closesAt, isOpenDay := schedule.window(t.AddDate(0, 0, offset))
+		if isOpenDay && !t.Before(opensAt) && t.Before(closesAt) {
+			return opensAt, closesAt, true
+		}
+	}
+	return t, t, false
+}
+
+// isSlotStart tells whether startAt starts a whole slot of slotLength in the window from opensAt to closesAt
+func isSlotStart(opensAt time.Time, closesAt time.Time, startAt time.Time, slotLength time.Duration) bool {
+	return !startAt.Before(opensAt) && !startAt.Add(slotLength).After(closesAt) && startAt.Sub(opensAt)%slotLength == 0
+}
+
+// countReservedSlots counts the scheduled orders of an order method per slot start between from and to,
+// cancelled and fully refunded orders give their slot back
+func countReservedSlots(ctx context.Context, tx pgx.Tx, orderMethodId int, from time.Time, to time.Time) (map[int64]int, error) {
+	reserved := map[int64]int{}
+
+	rows, err := tx.Query(ctx,
+		`SELECT t.scheduled_at, COUNT(*)
+		FROM transactions t
+		LEFT JOIN status s ON s.id = t.status_id
+		WHERE t.order_method_id = $1 AND t.scheduled_at >= $2 AND t.scheduled_at < $3
+			AND LOWER(COALESCE(s.name, '')) NOT IN ('cancelled', 'canceled')
+			AND NOT EXISTS (SELECT 1 FROM refunds r WHERE r.transaction_id = t.id AND r.is_full_refund = true)
+		GROUP BY t.scheduled_at`,
+		orderMethodId, from, to)
+	if err != nil {
+		return reserved, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var startAt time.Time
+		var count int
+		if err := rows.Scan(&startAt, &count); err != nil {
+			return reserved, err
+		}
+		reserved[startAt.Unix()] = count
+	}
+	return reserved, rows.Err()
+}
+
+// GetOrderSlots lists the slots of the opening window that starts on date, slots inside the lead time are left out
+func GetOrderSlots(orderMethodId int, date time.Time) ([]OrderSlot, string, error) {
+	ctx := context.Background()
+	slots := []OrderSlot{}
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return slots, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, orderSlotSettingsSelect, orderMethodId)
+	if err != nil {
+		message = "Failed to fetch order method from database"
+		return slots, message, err
+	}
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[orderSlotSettings])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Order method not found"
+			return slots, message, err
+		}
+		message = "Failed to process order method data"
+		return slots, message, err
+	}
+
+	now := time.Now().In(config.StoreLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.StoreLocation)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, config.StoreLocation)
+	if day.Before(today) || day.After(today.AddDate(0, 0, settings.SlotDaysAhead)) {
+		message = "Date is outside the booking window"
+		return slots, message, fmt.Errorf("slots can be booked up to %d days from today", settings.SlotDaysAhead)
+	}
+
+	schedule, err := getStoreSchedule(ctx, day, day)
+	if err != nil {
+		message = "Failed to fetch store hours from database"
+		return slots, message, err
+	}
+	opensAt, closesAt, isOpenDay := schedule.window(day)
+	if !isOpenDay {
+		message = "Store is closed on this date"
+		return slots, message, nil
+	}
+
+	reserved, err := countReservedSlots(ctx, tx, orderMethodId, opensAt, closesAt)
+	if err != nil {
+		message = "Failed to fetch reserved slots from database"
+		return slots, message, err
+	}
+
+	earliest := now.Add(time.Duration(settings.SlotLeadMinutes) * time.Minute)
+	slotLength := time.Duration(settings.SlotMinutes) * time.Minute
+	for startAt := opensAt; !startAt.Add(slotLength).After(closesAt); startAt = startAt.Add(slotLength) {
+		if startAt.Before(earliest) {
+			continue
+		}
+		remaining := max(settings.SlotCapacity-reserved[startAt.Unix()], 0)
+		slots = append(slots, OrderSlot{
+			StartAt:     startAt,
+			EndAt:       startAt.Add(slotLength),
+			Capacity:    settings.SlotCapacity,
+			Remaining:   remaining,
+			IsAvailable: remaining > 0,
+		})
+	}
+
+	message = "Success get order slots"
+	return slots, message, nil
+}
+
+// GetNextOrderSlot is the first slot of an order method with capacity left, nil when there is none in its booking window
+func GetNextOrderSlot(orderMethodId int) (*time.Time, string, error) {
+	var daysAhead int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT slot_days_ahead FROM order_methods WHERE id = $1`, orderMethodId).Scan(&daysAhead)
+	if err != nil {
+		return nil, "Invalid order method id", err
+	}
+
+	today := time.Now().In(config.StoreLocation)
+	for offset := 0; offset <= daysAhead; offset++ {
+		slots, message, err := GetOrderSlots(orderMethodId, today.AddDate(0, 0, offset))
+		if err != nil {
+			return nil, message, err
+		}
+		for _, slot := range slots {
+			if slot.IsAvailable {
+				return &slot.StartAt, "Success get next order slot", nil
+			}
+		}
+	}
+	return nil, "No order slot available", nil
+}
+
+// reserveOrderSlot takes one order of the slot starting at startAt. The order method row is locked
+// so that checkouts for the same slot are counted one after another.
+func reserveOrderSlot(ctx context.Context, tx pgx.Tx, orderMethodId int, startAt time.Time) (string, error) {
+	rows, err := tx.Query(ctx, orderSlotSettingsSelect+` FOR UPDATE`, orderMethodId)
+	if err != nil {
+		return "Internal server error while checking order slot", err
+	}
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[orderSlotSettings])
+	if err != nil {
+		return "Internal server error while checking order slot", err
+	}
+
+	now := time.Now().In(config.StoreLocation)
+	startAt = startAt.In(config.StoreLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.StoreLocation)
+	lastDay := today.AddDate(0, 0, settings.SlotDaysAhead+1)
+	if startAt.Before(now.Add(time.Duration(settings.SlotLeadMinutes)*time.Minute)) || !startAt.Before(lastDay) {
+		return "Time slot is not available", fmt.Errorf("slot %s is outside the booking window", startAt.Format(time.RFC3339))
+	}
+
+	schedule, err := getStoreSchedule(ctx, startAt.AddDate(0, 0, -1), startAt)
+	if err != nil {
+		return "Internal server error while checking store hours", err
+	}
+	slotLength := time.Duration(settings.SlotMinutes) * time.Minute
+	opensAt, closesAt, isOpen := schedule.windowAt(startAt)
+	if !isOpen || !isSlotStart(opensAt, closesAt, startAt, slotLength) {
+		return "Time slot is not available", fmt.Errorf("slot %s does not start a slot in opening hours", startAt.Format(time.RFC3339))
+	}
+
+	reserved, err := countReservedSlots(ctx, tx, orderMethodId, startAt, startAt.Add(time.Second))
+	if err != nil {
+		return "Internal server error while checking order slot", err
+	}
+	if reserved[startAt.Unix()] >= settings.SlotCapacity {
+		return "Time slot is full", fmt.Errorf("slot %s has no capacity left", startAt.Format(time.RFC3339))
+	}
+
+	return "", nil
+}
+
+func UpdateOrderMethodSlots(orderMethodId int, userId int, bodyUpdate OrderMethodSlotsRequest) (bool, string, error) {
+	if bodyUpdate.SlotMinutes < 5 || bodyUpdate.SlotMinutes > 240 {
+		return false, "Slot minutes must be between 5 and 240", errors.New("invalid slot minutes")
+	}
+	if bodyUpdate.SlotCapacity < 1 {
+		return false, "Slot capacity must be greater than 0", errors.New("invalid slot capacity")
+	}
+	if bodyUpdate.SlotLeadMinutes < 0 {
+		return false, "Slot lead minutes cannot be negative", errors.New("invalid slot lead minutes")
+	}
+	if bodyUpdate.SlotDaysAhead < 0 || bodyUpdate.SlotDaysAhead > 30 {
+		return false, "Slot days ahead must be between 0 and 30", errors.New("invalid slot days ahead")
+	}
+
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE order_methods
+		SET slot_minutes = $1,
+			slot_capacity = $2,
+			slot_lead_minutes = $3,
+			slot_days_ahead = $4,
+			updated_by = $5,
+			updated_at = NOW()
+		WHERE id = $6`,
+		bodyUpdate.SlotMinutes, bodyUpdate.SlotCapacity, bodyUpdate.SlotLeadMinutes, bodyUpdate.SlotDaysAhead, userId, orderMethodId)
+	if err != nil {
+		return false, "Internal server error while updating order method slots", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Order method not found", nil
+	}
+
+	return true, "Order method slots updated successfully", nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"testing"
+	"time"
+)
+
+func TestWindowAt(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := config.StoreLocation
+	config.StoreLocation = location
+	defer func() { config.StoreLocation = previous }()
+
+	clock := func(value string) *string { return &value }
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, location)
+	}
+
+	// 2026-10-19 is a Monday, sunday has no hours set and is open all day
+	schedule := storeSchedule{
+		hours: map[time.Weekday]StoreHour{
+			time.Monday:    {Weekday: 1, OpenTime: clock("07:00"), CloseTime: clock("21:00")},
+			time.Tuesday:   {Weekday: 2, OpenTime: clock("07:00"), CloseTime: clock("21:00")},
+			time.Wednesday: {Weekday: 3, IsClosed: true},
+			time.Thursday:  {Weekday: 4, OpenTime: clock("07:00"), CloseTime: clock("21:00")},
+			time.Friday:    {Weekday: 5, OpenTime: clock("18:00"), CloseTime: clock("02:00")},
+			time.Saturday:  {Weekday: 6, IsClosed: true},
+		},
+		holidays: map[string]StoreHoliday{
+			"2026-10-20": {Date: "2026-10-20", Name: "Short day", OpenTime: clock("10:00"), CloseTime: clock("14:00")},
+			"2026-10-22": {Date: "2026-10-22", Name: "Closed day", IsClosed: true},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		t            time.Time
+		wantOpen     bool
+		wantOpensAt  time.Time
+		wantClosesAt time.Time
+	}{
+		{"inside opening hours", at(19, 10, 0), true, at(19, 7, 0), at(19, 21, 0)},
+		{"at opening", at(19, 7, 0), true, at(19, 7, 0), at(19, 21, 0)},
+		{"before opening", at(19, 6, 59), false, time.Time{}, time.Time{}},
+		{"at closing", at(19, 21, 0), false, time.Time{}, time.Time{}},
+		{"utc time in store hours", time.Date(2026, time.October, 19, 3, 0, 0, 0, time.UTC), true, at(19, 7, 0), at(19, 21, 0)},
+		{"holiday hours replace the weekday", at(20, 8, 0), false, time.Time{}, time.Time{}},
+		{"inside holiday hours", at(20, 13, 59), true, at(20, 10, 0), at(20, 14, 0)},
+		{"closed weekday", at(21, 12, 0), false, time.Time{}, time.Time{}},
+		{"closed holiday", at(22, 12, 0), false, time.Time{}, time.Time{}},
+		{"evening of an overnight window", at(23, 23, 30), true, at(23, 18, 0), at(24, 2, 0)},
+		{"after midnight of an overnight window", at(24, 1, 30), true, at(23, 18, 0), at(24, 2, 0)},
+		{"after an overnight window", at(24, 2, 0), false, time.Time{}, time.Time{}},
+		{"weekday without hours", at(25, 3, 0), true, at(25, 0, 0), at(26, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opensAt, closesAt, isOpen := schedule.windowAt(tt.t)
+			if isOpen != tt.wantOpen {
+				t.Fatalf("windowAt() open = %v, want %v", isOpen, tt.wantOpen)
+			}
+			if !isOpen {
+				return
+			}
+			if !opensAt.Equal(tt.wantOpensAt) || !closesAt.Equal(tt.wantClosesAt) {
+				t.Errorf("windowAt() = %s - %s, want %s - %s", opensAt, closesAt, tt.wantOpensAt, tt.wantClosesAt)
+			}
+		})
+	}
+}
+
+func TestIsSlotStart(t *testing.T) {
+	opensAt := time.Date(2026, time.October, 19, 7, 0, 0, 0, time.UTC)
+	closesAt := time.Date(2026, time.October, 19, 21, 0, 0, 0, time.UTC)
+	slot := func(hour int, minute int) time.Time {
+		return time.Date(2026, time.October, 19, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		startAt    time.Time
+		slotLength time.Duration
+		want       bool
+	}{
+		{"first slot", slot(7, 0), 15 * time.Minute, true},
+		{"aligned slot", slot(12, 45), 15 * time.Minute, true},
+		{"off by minutes", slot(12, 50), 15 * time.Minute, false},
+		{"off by seconds", slot(12, 45).Add(time.Second), 15 * time.Minute, false},
+		{"last slot", slot(20, 45), 15 * time.Minute, true},
+		{"slot running past closing", slot(20, 30), 45 * time.Minute, false},
+		{"aligned to an odd length", slot(8, 30), 45 * time.Minute, true},
+		{"before opening", slot(6, 45), 15 * time.Minute, false},
+		{"at closing", slot(21, 0), 15 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSlotStart(opensAt, closesAt, tt.startAt, tt.slotLength); got != tt.want {
+				t.Errorf("isSlotStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// productPublishedCondition matches products inside their publish window
+const productPublishedCondition = `(p.publish_at IS NULL OR p.publish_at <= NOW())
+			AND (p.unpublish_at IS NULL OR p.unpublish_at > NOW())`
+
+// productInHoursCondition matches products without daily hours or inside them at the store clock in clockParam,
+// a window ending before it starts runs past midnight
+func productInHoursCondition(clockParam int) string {
+	return fmt.Sprintf(`(p.available_from IS NULL OR CASE
+				WHEN p.available_from < p.available_until THEN $%[1]d::time >= p.available_from AND $%[1]d::time < p.available_until
+				ELSE $%[1]d::time >= p.available_from OR $%[1]d::time < p.available_until
+			END)`, clockParam)
+}
+
+// productAvailableNowColumn tells whether the product can be ordered right now, see productInHoursCondition
+func productAvailableNowColumn(clockParam int) string {
+	return `(` + productPublishedCondition + ` AND ` + productInHoursCondition(clockParam) + `) AS is_available_now`
+}
+
+// storeClock is the time of day at the store that daily product hours are compared to
+func storeClock() string {
+	return time.Now().In(config.StoreLocation).Format("15:04:05")
+}
+
+// GetProductsUnavailableAt returns the names of the products that cannot be ordered for at,
+// a scheduled order is checked against its slot instead of now
+func GetProductsUnavailableAt(productIds []int, at time.Time) ([]string, string, error) {
+	names := []string{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT p.name
+		FROM products p
+		WHERE p.id = ANY($1)
+			AND NOT ((p.publish_at IS NULL OR p.publish_at <= $2::timestamptz)
+			AND (p.unpublish_at IS NULL OR p.unpublish_at > $2::timestamptz)
+			AND `+productInHoursCondition(3)+`)
+		ORDER BY p.name ASC`,
+		productIds, at, at.In(config.StoreLocation).Format("15:04:05"))
+	if err != nil {
+		message = "Failed to fetch product availability from database"
+		return names, message, err
+	}
+	defer rows.Close()
+
+	names, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		message = "Failed to process product availability"
+		return names, message, err
+	}
+
+	message = "Success check product availability"
+	return names, message, nil
+}
+
+// ProductAvailabilityRequest replaces the schedule of a product, null clears a value.
+// Hours are HH:MM in the store timezone.
+type ProductAvailabilityRequest struct {
+	PublishAt      *time.Time `json:"publishAt"`
+	UnpublishAt    *time.Time `json:"unpublishAt"`
+	AvailableFrom  *string    `json:"availableFrom" example:"07:00"`
+	AvailableUntil *string    `json:"availableUntil" example:"11:00"`
+}
+
+func parseAvailableHour(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	hour, err := time.Parse("15:04", *value)
+	if err != nil {
+		return nil, err
+	}
+	formatted := hour.Format("15:04")
+	return &formatted, nil
+}
+
+func UpdateProductAvailability(productId int, userId int, bodyUpdate ProductAvailabilityRequest) (bool, string, error) {
+	if bodyUpdate.PublishAt != nil && bodyUpdate.UnpublishAt != nil && !bodyUpdate.UnpublishAt.After(*bodyUpdate.PublishAt) {
+		return false, "Unpublish date must be after publish date", errors.New("invalid publish window")
+	}
+
+	if (bodyUpdate.AvailableFrom == nil) != (bodyUpdate.AvailableUntil == nil) {
+		return false, "Available from and until must be set together", errors.New("incomplete available hours")
+	}
+
+	availableFrom, err := parseAvailableHour(bodyUpdate.AvailableFrom)
+	if err != nil {
+		return false, "Available hours must be in HH:MM format", err
+	}
+	availableUntil, err := parseAvailableHour(bodyUpdate.AvailableUntil)
+	if err != nil {
+		return false, "Available hours must be in HH:MM format", err
+	}
+	if availableFrom != nil && *availableFrom == *availableUntil {
+		return false, "Available hours cannot start and end at the same time", errors.New("empty available hours")
+	}
+
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE products
+		SET publish_at = $1,
+			unpublish_at = $2,
+			available_from = $3::time,
+			available_until = $4::time,
+			updated_by = $5,
+			updated_at = NOW()
+		WHERE id = $6 AND deleted_at IS NULL`,
+		bodyUpdate.PublishAt, bodyUpdate.UnpublishAt, availableFrom, availableUntil, userId, productId)
+	if err != nil {
+		return false, "Internal server error while updating product availability", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Product not found", nil
+	}
+
+	return true, "Product availability updated successfully", nil
+}
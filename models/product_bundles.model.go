@@ -0,0 +1,215 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BundleItem is a component product of a bundle with the size and variant it is served in
+type BundleItem struct {
+	ProductId   int     `json:"productId" db:"product_id"`
+	ProductName string  `json:"productName" db:"product_name"`
+	SizeId      *int    `json:"sizeId" db:"size_id"`
+	Size        *string `json:"size" db:"size"`
+	VariantId   *int    `json:"variantId" db:"variant_id"`
+	Variant     *string `json:"variant" db:"variant"`
+	Quantity    int     `json:"quantity" db:"quantity"`
+}
+
+type BundleItemRequest struct {
+	ProductId int  `json:"productId"`
+	SizeId    *int `json:"sizeId"`
+	VariantId *int `json:"variantId"`
+	Quantity  int  `json:"quantity"`
+}
+
+// BundleItemsRequest replaces the components of a product, an empty list makes it a regular product again
+type BundleItemsRequest struct {
+	Items []BundleItemRequest `json:"items"`
+}
+
+// BundleComponent is a component on a cart line or a copy of it on an ordered bundle
+type BundleComponent struct {
+	ProductId   *int   `json:"productId"`
+	ProductName string `json:"productName"`
+	Size        string `json:"size"`
+	Variant     string `json:"variant"`
+	Quantity    int    `json:"quantity"`
+}
+
+const bundleItemsSelect = `
+		SELECT
+			bi.component_product_id AS product_id,
+			p.name AS product_name,
+			bi.size_id,
+			s.name AS size,
+			bi.variant_id,
+			v.name AS variant,
+			bi.quantity
+		FROM product_bundle_items bi
+		JOIN products p ON p.id = bi.component_product_id
+		LEFT JOIN sizes s ON s.id = bi.size_id
+		LEFT JOIN variants v ON v.id = bi.variant_id
+		WHERE bi.bundle_product_id = $1
+		ORDER BY bi.sort_order ASC, bi.id ASC`
+
+func GetProductBundleItems(productId int) ([]BundleItem, string, error) {
+	bundleItems := []BundleItem{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), bundleItemsSelect, productId)
+	if err != nil {
+		message = "Failed to fetch bundle items from database"
+		return bundleItems, message, err
+	}
+	defer rows.Close()
+
+	bundleItems, err = pgx.CollectRows(rows, pgx.RowToStructByName[BundleItem])
+	if err != nil {
+		message = "Failed to process bundle items data"
+		return bundleItems, message, err
+	}
+
+	message = "Success get bundle items"
+	return bundleItems, message, nil
+}
+
+func getBundleItems(tx pgx.Tx, productId int) ([]BundleItem, error) {
+	rows, err := tx.Query(context.Background(), bundleItemsSelect, productId)
+	if err != nil {
+		return []BundleItem{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[BundleItem])
+}
+
+// validateBundleItem checks one component, its size and variant must be offered by the component product
+func validateBundleItem(ctx context.Context, tx pgx.Tx, productId int, item BundleItemRequest) (string, error) {
+	if item.Quantity <= 0 {
+		return "Quantity must be greater than 0", errors.New("invalid bundle item quantity")
+	}
+	if item.ProductId == productId {
+		return "A bundle cannot contain itself", errors.New("bundle lists itself as component")
+	}
+
+	var isBundle bool
+	err := tx.QueryRow(ctx,
+		`SELECT is_bundle FROM products WHERE id = $1 AND deleted_at IS NULL`, item.ProductId).Scan(&isBundle)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "Component product not found", fmt.Errorf("component product %d not found", item.ProductId)
+		}
+		return "Internal server error while checking component product", err
+	}
+	if isBundle {
+		return "A bundle cannot contain another bundle", fmt.Errorf("component product %d is a bundle", item.ProductId)
+	}
+
+	if item.SizeId != nil {
+		var sizeIsOffered bool
+		err = tx.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM product_sizes WHERE product_id = $1 AND size_id = $2)`,
+			item.ProductId, *item.SizeId).Scan(&sizeIsOffered)
+		if err != nil {
+			return "Internal server error while checking component size", err
+		}
+		if !sizeIsOffered {
+			return "Size is not available for the component product", fmt.Errorf("product %d has no size %d", item.ProductId, *item.SizeId)
+		}
+	}
+
+	if item.VariantId != nil {
+		var variantIsOffered bool
+		err = tx.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = $1 AND variant_id = $2)`,
+			item.ProductId, *item.VariantId).Scan(&variantIsOffered)
+		if err != nil {
+			return "Internal server error while checking component variant", err
+		}
+		if !variantIsOffered {
+			return "Variant is not available for the component product", fmt.Errorf("product %d has no variant %d", item.ProductId, *item.VariantId)
+		}
+	}
+
+	return "", nil
+}
+
+// ReplaceProductBundleItems swaps the components of a product, its stock then follows the components
+func ReplaceProductBundleItems(productId int, userId int, items []BundleItemRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var isComponent bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM product_bundle_items WHERE component_product_id = p.id)
+		FROM products p
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+		FOR UPDATE`, productId).Scan(&isComponent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Product not found"
+			return isSuccess, message, nil
+		}
+		message = "Internal server error while checking product"
+		return isSuccess, message, err
+	}
+	if isComponent && len(items) > 0 {
+		message = "Product is a component of another bundle"
+		return isSuccess, message, errors.New("bundle components cannot become bundles")
+	}
+
+	for _, item := range items {
+		if message, err := validateBundleItem(ctx, tx, productId, item); err != nil {
+			return isSuccess, message, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM product_bundle_items WHERE bundle_product_id = $1`, productId)
+	if err != nil {
+		message = "Internal server error while deleting old bundle items"
+		return isSuccess, message, err
+	}
+
+	for i, item := range items {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO product_bundle_items (bundle_product_id, component_product_id, size_id, variant_id, quantity, sort_order, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`,
+			productId, item.ProductId, item.SizeId, item.VariantId, item.Quantity, i, userId)
+		if err != nil {
+			message = "Internal server error while inserting bundle items"
+			return isSuccess, message, err
+		}
+	}
+
+	// switching is_bundle recalculates the stock of the bundle from its components
+	_, err = tx.Exec(ctx,
+		`UPDATE products SET is_bundle = $1, updated_by = $2, updated_at = NOW() WHERE id = $3`,
+		len(items) > 0, userId, productId)
+	if err != nil {
+		message = "Internal server error while updating product"
+		return isSuccess, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Bundle items updated successfully"
+	return isSuccess, message, nil
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// productPriceBuckets is the number of equal width buckets in the price histogram
+const productPriceBuckets = 5
+
+type ProductFacetCount struct {
+	Id    int    `db:"id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Count int    `db:"count" json:"count"`
+}
+
+type ProductPriceBucket struct {
+	MinPrice float64 `db:"min_price" json:"minPrice"`
+	MaxPrice float64 `db:"max_price" json:"maxPrice"`
+	Count    int     `db:"count" json:"count"`
+}
+
+type ProductFacets struct {
+	Categories  []ProductFacetCount  `json:"categories"`
+	Sizes       []ProductFacetCount  `json:"sizes"`
+	Variants    []ProductFacetCount  `json:"variants"`
+	Allergens   []ProductFacetCount  `json:"allergens"`
+	PriceRanges []ProductPriceBucket `json:"priceRanges"`
+	FlashSale   int                  `json:"flashSale"`
+	InStock     int                  `json:"inStock"`
+}
+
+// getFacetCounts counts matching products per row of a lookup table joined through its pivot table
+func getFacetCounts(table string, pivot string, foreignKey string, filter PublicProductFilter) ([]ProductFacetCount, error) {
+	conditions, args, _ := publicProductConditions(filter, []any{})
+	where := ""
+	if table == "categories" {
+		// soft deleted categories are hidden from the public
+		where = ` WHERE t.deleted_at IS NULL`
+	}
+	query := fmt.Sprintf(`
+		SELECT t.id, t.name, COUNT(DISTINCT fp.id) AS count
+		FROM %s t
+		LEFT JOIN %s pt ON pt.%s = t.id
+		LEFT JOIN (SELECT p.id FROM products p %s) fp ON fp.id = pt.product_id%s
+		GROUP BY t.id
+		ORDER BY t.name ASC`, table, pivot, foreignKey, conditions, where)
+
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return []ProductFacetCount{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[ProductFacetCount])
+}
+
+// GetProductFacets aggregates the public product list. Each facet ignores its own filter
+// so the client can still show the other options of a facet that is already selected.
+func GetProductFacets(filter PublicProductFilter) (ProductFacets, string, error) {
+	facets := ProductFacets{}
+	message := ""
+	var err error
+
+	withoutCategories := filter
+	withoutCategories.Categories = nil
+	facets.Categories, err = getFacetCounts("categories", "product_categories", "category_id", withoutCategories)
+	if err != nil {
+		message = "Failed to count products per category"
+		return facets, message, err
+	}
+
+	withoutSizes := filter
+	withoutSizes.Sizes = nil
+	facets.Sizes, err = getFacetCounts("sizes", "product_sizes", "size_id", withoutSizes)
+	if err != nil {
+		message = "Failed to count products per size"
+		return facets, message, err
+	}
+
+	withoutVariants := filter
+	withoutVariants.Variants = nil
+	facets.Variants, err = getFacetCounts("variants", "product_variants", "variant_id", withoutVariants)
+	if err != nil {
+		message = "Failed to count products per variant"
+		return facets, message, err
+	}
+
+	withoutAllergens := filter
+	withoutAllergens.ExcludeAllergens = nil
+	facets.Allergens, err = getFacetCounts("allergens", "product_allergens", "allergen_id", withoutAllergens)
+	if err != nil {
+		message = "Failed to count products per allergen"
+		return facets, message, err
+	}
+
+	withoutPrice := filter
+	withoutPrice.MinPrice = 0
+	withoutPrice.MaxPrice = 0
+	conditions, args, _ := publicProductConditions(withoutPrice, []any{})
+	rows, err := config.DB.Query(context.Background(), fmt.Sprintf(`
+		WITH fp AS (SELECT p.price FROM products p %s),
+		bounds AS (SELECT MIN(price) AS lo, MAX(price) AS hi FROM fp),
+		bucketed AS (
+			SELECT
+				CASE WHEN b.hi = b.lo THEN 1 ELSE LEAST(WIDTH_BUCKET(fp.price, b.lo, b.hi, %[2]d), %[2]d) END AS bucket,
+				b.lo,
+				b.hi
+			FROM fp CROSS JOIN bounds b
+		)
+		SELECT
+			(lo + (bucket - 1) * (hi - lo) / %[2]d)::float8 AS min_price,
+			(lo + bucket * (hi - lo) / %[2]d)::float8 AS max_price,
+			COUNT(*) AS count
+		FROM bucketed
+		GROUP BY bucket, lo, hi
+		ORDER BY bucket ASC`, conditions, productPriceBuckets), args...)
+	if err != nil {
+		message = "Failed to build price histogram"
+		return facets, message, err
+	}
+	facets.PriceRanges, err = pgx.CollectRows(rows, pgx.RowToStructByName[ProductPriceBucket])
+	if err != nil {
+		message = "Failed to build price histogram"
+		return facets, message, err
+	}
+
+	withoutFlashSale := filter
+	withoutFlashSale.FlashSale = false
+	conditions, args, _ = publicProductConditions(withoutFlashSale, []any{})
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM products p`+conditions+` AND `+flashSaleCondition, args...).Scan(&facets.FlashSale)
+	if err != nil {
+		message = "Failed to count flash sale products"
+		return facets, message, err
+	}
+
+	withoutInStock := filter
+	withoutInStock.InStock = false
+	conditions, args, _ = publicProductConditions(withoutInStock, []any{})
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM products p`+conditions+` AND p.stock > 0`, args...).Scan(&facets.InStock)
+	if err != nil {
+		message = "Failed to count products in stock"
+		return facets, message, err
+	}
+
+	message = "Success get product facets"
+	return facets, message, nil
+}
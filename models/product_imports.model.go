@@ -0,0 +1,395 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"backend-daily-greens/utils"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProductCsvHeader is the column order used by both import and export
+var ProductCsvHeader = []string{"name", "description", "price", "discountPercent", "stock", "isActive", "categories", "sizes", "variants", "images"}
+
+// ProductCsvListSeparator separates multiple names or urls inside one csv cell
+const ProductCsvListSeparator = "|"
+
+type ProductImportRow struct {
+	Row             int      `json:"row"`
+	Name            string   `json:"name"`
+	Action          string   `json:"action"`
+	Errors          []string `json:"errors"`
+	ProductId       int      `json:"productId,omitempty"`
+	description     string
+	price           float64
+	discountPercent float64
+	stock           int
+	isActive        bool
+	categoryIds     []int
+	sizeIds         []int
+	variantIds      []int
+	images          []string
+}
+
+type ProductImportReport struct {
+	DryRun    bool               `json:"dryRun"`
+	TotalRows int                `json:"totalRows"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Failed    int                `json:"failed"`
+	Rows      []ProductImportRow `json:"rows"`
+	// columns are the optional columns the file has, an update leaves the others as they are
+	columns map[string]bool
+}
+
+type ProductExportRow struct {
+	Name            string  `db:"name"`
+	Description     string  `db:"description"`
+	Price           float64 `db:"price"`
+	DiscountPercent float64 `db:"discount_percent"`
+	Stock           int     `db:"stock"`
+	IsActive        bool    `db:"is_active"`
+	Categories      string  `db:"categories"`
+	Sizes           string  `db:"sizes"`
+	Variants        string  `db:"variants"`
+	Images          string  `db:"images"`
+}
+
+// getNameIdMap maps the lowercased names of a table to their ids, condition filters the rows
+func getNameIdMap(table string, condition string) (map[string]int, error) {
+	result := map[string]int{}
+	rows, err := config.DB.Query(context.Background(), fmt.Sprintf(`SELECT id, name FROM %s WHERE %s`, table, condition))
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return result, err
+		}
+		result[strings.ToLower(name)] = id
+	}
+
+	return result, rows.Err()
+}
+
+func splitCsvList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ProductCsvListSeparator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func lookupIds(names []string, ids map[string]int, label string, errs *[]string) []int {
+	result := []int{}
+	for _, name := range names {
+		id, ok := ids[strings.ToLower(name)]
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("Unknown %s '%s'", label, name))
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
+
+// ValidateProductImport parses csv records (first record is the header) and validates every row
+func ValidateProductImport(records [][]string) (ProductImportReport, string, error) {
+	report := ProductImportReport{Rows: []ProductImportRow{}}
+	message := ""
+
+	if len(records) < 2 {
+		message = "CSV file must contain a header and at least one row"
+		return report, message, fmt.Errorf("%s", message)
+	}
+
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"name", "price", "stock"} {
+		if _, ok := columns[strings.ToLower(required)]; !ok {
+			message = fmt.Sprintf("CSV header is missing required column '%s'", required)
+			return report, message, fmt.Errorf("%s", message)
+		}
+	}
+	cell := func(record []string, column string) string {
+		i, ok := columns[strings.ToLower(column)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return utils.UnescapeCsvFormula(strings.TrimSpace(record[i]))
+	}
+
+	categories, err := getNameIdMap("categories", "deleted_at IS NULL")
+	if err != nil {
+		message = "Failed to fetch categories from database"
+		return report, message, err
+	}
+	sizes, err := getNameIdMap("sizes", "true")
+	if err != nil {
+		message = "Failed to fetch sizes from database"
+		return report, message, err
+	}
+	variants, err := getNameIdMap("variants", "true")
+	if err != nil {
+		message = "Failed to fetch variants from database"
+		return report, message, err
+	}
+	products, err := getNameIdMap("products", "deleted_at IS NULL")
+	if err != nil {
+		message = "Failed to fetch products from database"
+		return report, message, err
+	}
+	// a name of a deleted product is not reused by an import, the product has to be restored first
+	deletedProducts, err := getNameIdMap("products", "deleted_at IS NOT NULL")
+	if err != nil {
+		message = "Failed to fetch products from database"
+		return report, message, err
+	}
+
+	report.columns = map[string]bool{}
+	for column := range columns {
+		report.columns[column] = true
+	}
+
+	seenNames := map[string]int{}
+	for i, record := range records[1:] {
+		row := ProductImportRow{Row: i + 2, Errors: []string{}}
+		row.Name = cell(record, "name")
+		row.description = cell(record, "description")
+
+		if row.Name == "" {
+			row.Errors = append(row.Errors, "Name is required")
+		} else if firstRow, ok := seenNames[strings.ToLower(row.Name)]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("Duplicate name, already used in row %d", firstRow))
+		} else {
+			seenNames[strings.ToLower(row.Name)] = row.Row
+		}
+
+		row.price, err = strconv.ParseFloat(cell(record, "price"), 64)
+		if err != nil || row.price <= 0 {
+			row.Errors = append(row.Errors, "Price must be a number greater than 0")
+		}
+
+		if value := cell(record, "discountPercent"); value != "" {
+			row.discountPercent, err = strconv.ParseFloat(value, 64)
+			if err != nil || row.discountPercent < 0 || row.discountPercent > 100 {
+				row.Errors = append(row.Errors, "Discount percent must be a number between 0 and 100")
+			}
+		}
+
+		row.stock, err = strconv.Atoi(cell(record, "stock"))
+		if err != nil || row.stock < 0 {
+			row.Errors = append(row.Errors, "Stock must be an integer greater than or equal to 0")
+		}
+
+		row.isActive = true
+		if value := cell(record, "isActive"); value != "" {
+			row.isActive, err = strconv.ParseBool(value)
+			if err != nil {
+				row.Errors = append(row.Errors, "isActive must be true or false")
+			}
+		}
+
+		row.categoryIds = lookupIds(splitCsvList(cell(record, "categories")), categories, "category", &row.Errors)
+		row.sizeIds = lookupIds(splitCsvList(cell(record, "sizes")), sizes, "size", &row.Errors)
+		row.variantIds = lookupIds(splitCsvList(cell(record, "variants")), variants, "variant", &row.Errors)
+
+		row.images = splitCsvList(cell(record, "images"))
+		for _, image := range row.images {
+			if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+				row.Errors = append(row.Errors, fmt.Sprintf("Invalid image URL '%s'", image))
+			}
+		}
+		if len(row.images) > 4 {
+			row.Errors = append(row.Errors, "There must be no more than 4 product images")
+		}
+
+		if id, ok := products[strings.ToLower(row.Name)]; ok {
+			row.Action = "update"
+			row.ProductId = id
+		} else if id, ok := deletedProducts[strings.ToLower(row.Name)]; ok {
+			row.Action = "update"
+			row.ProductId = id
+			row.Errors = append(row.Errors, fmt.Sprintf("Name matches deleted product %d, restore it before importing", id))
+		} else {
+			row.Action = "create"
+		}
+
+		if len(row.Errors) > 0 {
+			report.Failed++
+		} else if row.Action == "create" {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	report.TotalRows = len(report.Rows)
+
+	message = "CSV validated successfully"
+	return report, message, nil
+}
+
+// ImportProducts upserts every validated row by product name inside one transaction
+func ImportProducts(report *ProductImportReport, userId int) (string, error) {
+	message := ""
+	ctx := context.Background()
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return message, err
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		rating := 5.0
+		isFalse := false
+		body := ProductRequest{
+			Name:            row.Name,
+			Description:     row.description,
+			Price:           &row.price,
+			DiscountPercent: &row.discountPercent,
+			Stock:           &row.stock,
+			IsActive:        &row.isActive,
+		}
+		if row.Action == "update" {
+			if !report.columns["discountpercent"] {
+				body.DiscountPercent = nil
+			}
+			if !report.columns["isactive"] {
+				body.IsActive = nil
+			}
+		}
+
+		if row.Action == "create" {
+			body.Rating = &rating
+			body.IsFlashSale = &isFalse
+			body.IsFavourite = &isFalse
+			err = InsertDataProduct(tx, &body, userId)
+			if err != nil {
+				message = fmt.Sprintf("Failed to insert product in row %d", row.Row)
+				return message, err
+			}
+			row.ProductId = body.Id
+		} else {
+			_, err = UpdateDataProduct(tx, row.ProductId, &body, userId)
+			if err != nil {
+				message = fmt.Sprintf("Failed to update product in row %d", row.Row)
+				return message, err
+			}
+
+			// relations are only replaced when their column is in the file
+			if report.columns["sizes"] {
+				err = DeleteProductSizes(tx, row.ProductId)
+			}
+			if err == nil && report.columns["categories"] {
+				err = DeleteProductCategories(tx, row.ProductId)
+			}
+			if err == nil && report.columns["variants"] {
+				err = DeleteProductVariants(tx, row.ProductId)
+			}
+			if err != nil {
+				message = fmt.Sprintf("Failed to reset relations of product in row %d", row.Row)
+				return message, err
+			}
+
+			// imported images are external urls, only the rows are replaced
+			if len(row.images) > 0 {
+				_, err = tx.Exec(ctx, `DELETE FROM product_images WHERE product_id = $1`, row.ProductId)
+				if err != nil {
+					message = fmt.Sprintf("Failed to reset images of product in row %d", row.Row)
+					return message, err
+				}
+			}
+		}
+
+		isCreate := row.Action == "create"
+		if isCreate || report.columns["categories"] {
+			err = InsertProductCategories(tx, row.ProductId, row.categoryIds, userId)
+		}
+		if err == nil && (isCreate || report.columns["sizes"]) {
+			err = InsertProductSizes(tx, row.ProductId, row.sizeIds, userId)
+		}
+		if err == nil && (isCreate || report.columns["variants"]) {
+			err = InsertProductVariants(tx, row.ProductId, row.variantIds, userId)
+		}
+		if err == nil {
+			err = InsertProductImages(tx, row.ProductId, row.images, userId)
+		}
+		if err != nil {
+			message = fmt.Sprintf("Failed to insert relations of product in row %d", row.Row)
+			return message, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return message, err
+	}
+
+	message = "Products imported successfully"
+	return message, nil
+}
+
+// StreamProductExport runs the export query and calls start before the first row, so a failing
+// query returns its error before anything is written to the client
+func StreamProductExport(start func() error, writeRow func(row ProductExportRow) error) error {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			p.name,
+			p.description,
+			p.price,
+			COALESCE(p.discount_percent, 0) AS discount_percent,
+			COALESCE(p.stock, 0) AS stock,
+			COALESCE(p.is_active, true) AS is_active,
+			(SELECT COALESCE(STRING_AGG(c.name, '|' ORDER BY c.name), '')
+			 FROM product_categories pc JOIN categories c ON c.id = pc.category_id
+			 WHERE pc.product_id = p.id) AS categories,
+			(SELECT COALESCE(STRING_AGG(s.name, '|' ORDER BY s.id), '')
+			 FROM product_sizes ps JOIN sizes s ON s.id = ps.size_id
+			 WHERE ps.product_id = p.id) AS sizes,
+			(SELECT COALESCE(STRING_AGG(v.name, '|' ORDER BY v.id), '')
+			 FROM product_variants pv JOIN variants v ON v.id = pv.variant_id
+			 WHERE pv.product_id = p.id) AS variants,
+			(SELECT COALESCE(STRING_AGG(pi.product_image, '|' ORDER BY pi.is_primary DESC, pi.id), '')
+			 FROM product_images pi
+			 WHERE pi.product_id = p.id) AS images
+		FROM products p
+		WHERE p.deleted_at IS NULL
+		ORDER BY p.id ASC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err = start(); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		row, err := pgx.RowToStructByName[ProductExportRow](rows)
+		if err != nil {
+			return err
+		}
+		if err = writeRow(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
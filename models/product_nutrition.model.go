@@ -0,0 +1,311 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProductNutrition holds the nutrition facts of a product, per size when SizeId is set.
+// Unknown values are null, sodium and caffeine are in mg and the rest in grams except calories.
+type ProductNutrition struct {
+	SizeId       *int     `json:"sizeId" db:"size_id"`
+	Size         *string  `json:"size" db:"size"`
+	Calories     *float64 `json:"calories" db:"calories"`
+	Fat          *float64 `json:"fat" db:"fat"`
+	Carbohydrate *float64 `json:"carbohydrate" db:"carbohydrate"`
+	Sugar        *float64 `json:"sugar" db:"sugar"`
+	Fiber        *float64 `json:"fiber" db:"fiber"`
+	Protein      *float64 `json:"protein" db:"protein"`
+	Sodium       *float64 `json:"sodium" db:"sodium"`
+	Caffeine     *float64 `json:"caffeine" db:"caffeine"`
+}
+
+// ProductNutritionRequest sets the facts of a product, without sizeId it is the base every size falls back to
+type ProductNutritionRequest struct {
+	SizeId       *int     `json:"sizeId"`
+	Calories     *float64 `json:"calories"`
+	Fat          *float64 `json:"fat"`
+	Carbohydrate *float64 `json:"carbohydrate"`
+	Sugar        *float64 `json:"sugar"`
+	Fiber        *float64 `json:"fiber"`
+	Protein      *float64 `json:"protein"`
+	Sodium       *float64 `json:"sodium"`
+	Caffeine     *float64 `json:"caffeine"`
+}
+
+// ProductNutritionListRequest replaces the nutrition facts of a product, an empty list removes them
+type ProductNutritionListRequest struct {
+	Nutrition []ProductNutritionRequest `json:"nutrition"`
+}
+
+type Allergen struct {
+	Id   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Slug string `json:"slug" db:"slug"`
+}
+
+func GetProductNutrition(productId int) ([]ProductNutrition, string, error) {
+	nutrition := []ProductNutrition{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			n.size_id,
+			s.name AS size,
+			n.calories,
+			n.fat,
+			n.carbohydrate,
+			n.sugar,
+			n.fiber,
+			n.protein,
+			n.sodium,
+			n.caffeine
+		FROM product_nutrition n
+		LEFT JOIN sizes s ON s.id = n.size_id
+		WHERE n.product_id = $1
+		ORDER BY n.size_id NULLS FIRST`, productId)
+	if err != nil {
+		message = "Failed to fetch nutrition facts from database"
+		return nutrition, message, err
+	}
+	defer rows.Close()
+
+	nutrition, err = pgx.CollectRows(rows, pgx.RowToStructByName[ProductNutrition])
+	if err != nil {
+		message = "Failed to process nutrition facts data"
+		return nutrition, message, err
+	}
+
+	message = "Success get nutrition facts"
+	return nutrition, message, nil
+}
+
+// getPublicProductNutrition lists the base facts and the facts of every size the product is sold in,
+// a value missing on a size is taken from the base
+func getPublicProductNutrition(tx pgx.Tx, productId int) ([]ProductNutrition, error) {
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+			NULL::int AS size_id,
+			NULL::varchar AS size,
+			n.calories,
+			n.fat,
+			n.carbohydrate,
+			n.sugar,
+			n.fiber,
+			n.protein,
+			n.sodium,
+			n.caffeine
+		FROM product_nutrition n
+		WHERE n.product_id = $1 AND n.size_id IS NULL
+		UNION ALL
+		(SELECT
+			s.id AS size_id,
+			s.name AS size,
+			COALESCE(sn.calories, bn.calories),
+			COALESCE(sn.fat, bn.fat),
+			COALESCE(sn.carbohydrate, bn.carbohydrate),
+			COALESCE(sn.sugar, bn.sugar),
+			COALESCE(sn.fiber, bn.fiber),
+			COALESCE(sn.protein, bn.protein),
+			COALESCE(sn.sodium, bn.sodium),
+			COALESCE(sn.caffeine, bn.caffeine)
+		FROM product_sizes ps
+		JOIN sizes s ON s.id = ps.size_id
+		LEFT JOIN product_nutrition sn ON sn.product_id = ps.product_id AND sn.size_id = ps.size_id
+		LEFT JOIN product_nutrition bn ON bn.product_id = ps.product_id AND bn.size_id IS NULL
+		WHERE ps.product_id = $1 AND (sn.id IS NOT NULL OR bn.id IS NOT NULL)
+		ORDER BY s.id)`, productId)
+	if err != nil {
+		return []ProductNutrition{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[ProductNutrition])
+}
+
+// validateProductNutrition checks the values and that every size is sold for the product
+func validateProductNutrition(ctx context.Context, tx pgx.Tx, productId int, nutrition []ProductNutritionRequest) (string, error) {
+	seenSizes := map[int]bool{}
+	for _, facts := range nutrition {
+		for _, value := range []*float64{facts.Calories, facts.Fat, facts.Carbohydrate, facts.Sugar, facts.Fiber, facts.Protein, facts.Sodium, facts.Caffeine} {
+			if value != nil && *value < 0 {
+				return "Nutrition values cannot be negative", errors.New("negative nutrition value")
+			}
+		}
+
+		sizeId := 0
+		if facts.SizeId != nil {
+			sizeId = *facts.SizeId
+		}
+		if seenSizes[sizeId] {
+			return "Size is listed more than once", fmt.Errorf("duplicate nutrition for size %d", sizeId)
+		}
+		seenSizes[sizeId] = true
+
+		if facts.SizeId != nil {
+			var sizeIsOffered bool
+			err := tx.QueryRow(ctx,
+				`SELECT EXISTS(SELECT 1 FROM product_sizes WHERE product_id = $1 AND size_id = $2)`,
+				productId, *facts.SizeId).Scan(&sizeIsOffered)
+			if err != nil {
+				return "Internal server error while checking product size", err
+			}
+			if !sizeIsOffered {
+				return "Size is not available for the product", fmt.Errorf("product %d has no size %d", productId, *facts.SizeId)
+			}
+		}
+	}
+	return "", nil
+}
+
+// ReplaceProductNutrition swaps the nutrition facts of a product
+func ReplaceProductNutrition(productId int, userId int, nutrition []ProductNutritionRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, productId).Scan(&exists)
+	if err != nil {
+		message = "Internal server error while checking product"
+		return isSuccess, message, err
+	}
+	if !exists {
+		message = "Product not found"
+		return isSuccess, message, nil
+	}
+
+	if message, err := validateProductNutrition(ctx, tx, productId, nutrition); err != nil {
+		return isSuccess, message, err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM product_nutrition WHERE product_id = $1`, productId)
+	if err != nil {
+		message = "Internal server error while deleting old nutrition facts"
+		return isSuccess, message, err
+	}
+
+	for _, facts := range nutrition {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO product_nutrition (product_id, size_id, calories, fat, carbohydrate, sugar, fiber, protein, sodium, caffeine, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)`,
+			productId, facts.SizeId, facts.Calories, facts.Fat, facts.Carbohydrate, facts.Sugar, facts.Fiber, facts.Protein, facts.Sodium, facts.Caffeine, userId)
+		if err != nil {
+			message = "Internal server error while inserting nutrition facts"
+			return isSuccess, message, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Nutrition facts updated successfully"
+	return isSuccess, message, nil
+}
+
+func InsertProductAllergens(tx pgx.Tx, productId int, allergenIds []int, userId int) error {
+	for _, allergenId := range allergenIds {
+		_, err := tx.Exec(
+			context.Background(),
+			`INSERT INTO product_allergens (product_id, allergen_id, created_by, updated_by)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (product_id, allergen_id) DO NOTHING`,
+			productId,
+			allergenId,
+			userId,
+			userId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func DeleteProductAllergens(tx pgx.Tx, productId int) error {
+	_, err := tx.Exec(
+		context.Background(),
+		`DELETE FROM product_allergens WHERE product_id = $1`,
+		productId,
+	)
+	return err
+}
+
+const productAllergensSelect = `
+		SELECT a.id, a.name, a.slug
+		FROM product_allergens pa
+		JOIN allergens a ON a.id = pa.allergen_id
+		WHERE pa.product_id = $1
+		ORDER BY a.name ASC`
+
+func GetProductAllergens(productId int) ([]Allergen, string, error) {
+	allergens := []Allergen{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), productAllergensSelect, productId)
+	if err != nil {
+		message = "Failed to fetch allergens from database"
+		return allergens, message, err
+	}
+	defer rows.Close()
+
+	allergens, err = pgx.CollectRows(rows, pgx.RowToStructByName[Allergen])
+	if err != nil {
+		message = "Failed to process allergens data"
+		return allergens, message, err
+	}
+
+	message = "Success get allergens"
+	return allergens, message, nil
+}
+
+func getProductAllergens(tx pgx.Tx, productId int) ([]Allergen, error) {
+	rows, err := tx.Query(context.Background(), productAllergensSelect, productId)
+	if err != nil {
+		return []Allergen{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[Allergen])
+}
+
+// GetUnknownAllergens returns the names or slugs that match no allergen
+func GetUnknownAllergens(names []string) ([]string, string, error) {
+	unknown := []string{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT requested.value FROM unnest($1::text[]) AS requested(value)
+		WHERE NOT EXISTS (SELECT 1 FROM allergens a WHERE a.name = requested.value OR a.slug = requested.value)`,
+		names)
+	if err != nil {
+		message = "Failed to fetch allergens from database"
+		return unknown, message, err
+	}
+	defer rows.Close()
+
+	unknown, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		message = "Failed to process allergens data"
+		return unknown, message, err
+	}
+
+	message = "Success check allergens"
+	return unknown, message, nil
+}
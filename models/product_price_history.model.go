@@ -0,0 +1,93 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProductPriceChange is one row of product_price_history, written by a trigger on products
+type ProductPriceChange struct {
+	Id                 int       `json:"id" db:"id"`
+	OldPrice           *float64  `json:"oldPrice" db:"old_price"`
+	OldDiscountPercent *float64  `json:"oldDiscountPercent" db:"old_discount_percent"`
+	Price              float64   `json:"price" db:"price"`
+	DiscountPercent    float64   `json:"discountPercent" db:"discount_percent"`
+	EffectivePrice     float64   `json:"effectivePrice" db:"effective_price"`
+	ChangedBy          *int      `json:"changedBy" db:"changed_by"`
+	ChangedByEmail     *string   `json:"changedByEmail" db:"changed_by_email"`
+	ChangedAt          time.Time `json:"changedAt" db:"changed_at"`
+}
+
+// lowestPrice30DaysColumn is the lowest price after discount in effect during the last 30 days,
+// including the price that was already in effect when the window started and the flash sale prices
+// that ran in the window, a flash sale discounts the price in effect when it ended
+const lowestPrice30DaysColumn = `
+				COALESCE((
+					SELECT MIN(h.effective_price) FROM (
+						SELECT effective_price FROM product_price_history
+						WHERE product_id = p.id AND created_at >= NOW() - INTERVAL '30 days'
+						UNION ALL
+						(SELECT effective_price FROM product_price_history
+						WHERE product_id = p.id AND created_at < NOW() - INTERVAL '30 days'
+						ORDER BY created_at DESC, id DESC
+						LIMIT 1)
+						UNION ALL
+						SELECT COALESCE((
+							SELECT ph.price FROM product_price_history ph
+							WHERE ph.product_id = p.id AND ph.created_at <= LEAST(f.end_at, NOW())
+							ORDER BY ph.created_at DESC, ph.id DESC
+							LIMIT 1
+						), p.price) * (1 - fsp.discount_percent / 100.0)
+						FROM flash_sale_products fsp
+						JOIN flash_sales f ON f.id = fsp.flash_sale_id
+						WHERE fsp.product_id = p.id AND f.is_active = true
+							AND f.start_at <= NOW() AND f.end_at >= NOW() - INTERVAL '30 days'
+					) h
+				), p.price * (1 - COALESCE(p.discount_percent, 0) / 100.0)) AS lowest_price_30_days`
+
+func GetTotalDataProductPriceHistory(productId int) (int, error) {
+	totalData := 0
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM product_price_history WHERE product_id = $1`, productId).Scan(&totalData)
+	return totalData, err
+}
+
+func GetListProductPriceHistory(productId int, page int, limit int) ([]ProductPriceChange, string, error) {
+	offset := (page - 1) * limit
+	history := []ProductPriceChange{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			h.id,
+			h.old_price,
+			h.old_discount_percent,
+			h.price,
+			h.discount_percent,
+			h.effective_price,
+			h.created_by AS changed_by,
+			u.email AS changed_by_email,
+			h.created_at AS changed_at
+		FROM product_price_history h
+		LEFT JOIN users u ON u.id = h.created_by
+		WHERE h.product_id = $1
+		ORDER BY h.created_at DESC, h.id DESC
+		LIMIT $2 OFFSET $3`, productId, limit, offset)
+	if err != nil {
+		message = "Failed to fetch price history from database"
+		return history, message, err
+	}
+	defer rows.Close()
+
+	history, err = pgx.CollectRows(rows, pgx.RowToStructByName[ProductPriceChange])
+	if err != nil {
+		message = "Failed to process price history data from database"
+		return history, message, err
+	}
+
+	message = "Success get price history"
+	return history, message, nil
+}
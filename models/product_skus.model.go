@@ -0,0 +1,318 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ProductSku is a size and variant combination of a product with its own stock
+type ProductSku struct {
+	Id          int      `json:"id" db:"id"`
+	SizeId      *int     `json:"sizeId" db:"size_id"`
+	Size        *string  `json:"size" db:"size"`
+	VariantId   *int     `json:"variantId" db:"variant_id"`
+	Variant     *string  `json:"variant" db:"variant"`
+	SkuCode     *string  `json:"skuCode" db:"sku_code"`
+	Stock       int      `json:"stock" db:"stock"`
+	Price       *float64 `json:"price" db:"price"`
+	IsAvailable bool     `json:"isAvailable" db:"is_available"`
+}
+
+// ProductSkuRequest sets the SKU of a size and variant combination, price overrides the product price when sent
+type ProductSkuRequest struct {
+	SizeId      *int     `json:"sizeId"`
+	VariantId   *int     `json:"variantId"`
+	SkuCode     *string  `json:"skuCode"`
+	Stock       int      `json:"stock"`
+	Price       *float64 `json:"price"`
+	IsAvailable *bool    `json:"isAvailable"`
+}
+
+// ProductSkusRequest replaces the SKUs of a product, an empty list goes back to product stock
+type ProductSkusRequest struct {
+	Skus []ProductSkuRequest `json:"skus"`
+}
+
+// PublicProductSku tells the storefront which size and variant can be ordered and for how much
+type PublicProductSku struct {
+	SizeId        *int    `json:"sizeId" db:"size_id"`
+	VariantId     *int    `json:"variantId" db:"variant_id"`
+	Price         float64 `json:"price" db:"price"`
+	DiscountPrice float64 `json:"discountPrice" db:"-"`
+	InStock       bool    `json:"inStock" db:"in_stock"`
+}
+
+func GetProductSkus(productId int) ([]ProductSku, string, error) {
+	skus := []ProductSku{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			sk.id,
+			sk.size_id,
+			s.name AS size,
+			sk.variant_id,
+			v.name AS variant,
+			sk.sku_code,
+			sk.stock,
+			sk.price,
+			sk.is_available
+		FROM product_skus sk
+		LEFT JOIN sizes s ON s.id = sk.size_id
+		LEFT JOIN variants v ON v.id = sk.variant_id
+		WHERE sk.product_id = $1
+		ORDER BY sk.size_id NULLS FIRST, sk.variant_id NULLS FIRST`, productId)
+	if err != nil {
+		message = "Failed to fetch SKUs from database"
+		return skus, message, err
+	}
+	defer rows.Close()
+
+	skus, err = pgx.CollectRows(rows, pgx.RowToStructByName[ProductSku])
+	if err != nil {
+		message = "Failed to process SKU data"
+		return skus, message, err
+	}
+
+	message = "Success get SKUs"
+	return skus, message, nil
+}
+
+// getPublicProductSkus lists the combinations of a product, discountPercent is the running discount of the product
+func getPublicProductSkus(tx pgx.Tx, productId int, discountPercent float64) ([]PublicProductSku, error) {
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+			sk.size_id,
+			sk.variant_id,
+			COALESCE(sk.price, p.price) AS price,
+			(sk.is_available AND sk.stock > 0) AS in_stock
+		FROM product_skus sk
+		JOIN products p ON p.id = sk.product_id
+		WHERE sk.product_id = $1
+		ORDER BY sk.size_id NULLS FIRST, sk.variant_id NULLS FIRST`, productId)
+	if err != nil {
+		return []PublicProductSku{}, err
+	}
+	defer rows.Close()
+
+	skus, err := pgx.CollectRows(rows, pgx.RowToStructByName[PublicProductSku])
+	if err != nil {
+		return skus, err
+	}
+
+	for i := range skus {
+		if discountPercent > 0 {
+			skus[i].DiscountPrice = skus[i].Price * (1 - discountPercent/100)
+		}
+	}
+	return skus, nil
+}
+
+// validateProductSkus checks the SKU list against the sizes and variants the product offers
+func validateProductSkus(ctx context.Context, tx pgx.Tx, productId int, skus []ProductSkuRequest) (string, error) {
+	seenCombinations := map[[2]int]bool{}
+	seenCodes := map[string]bool{}
+	codes := []string{}
+	for _, sku := range skus {
+		if sku.Stock < 0 {
+			return "Stock cannot be negative", errors.New("invalid SKU stock")
+		}
+		if sku.Price != nil && *sku.Price <= 0 {
+			return "Price must be greater than 0", errors.New("invalid SKU price")
+		}
+
+		combination := [2]int{}
+		if sku.SizeId != nil {
+			combination[0] = *sku.SizeId
+		}
+		if sku.VariantId != nil {
+			combination[1] = *sku.VariantId
+		}
+		if seenCombinations[combination] {
+			return "Size and variant combination is listed more than once", fmt.Errorf("duplicate combination size %d variant %d", combination[0], combination[1])
+		}
+		seenCombinations[combination] = true
+
+		if sku.SkuCode != nil && strings.TrimSpace(*sku.SkuCode) != "" {
+			code := strings.TrimSpace(*sku.SkuCode)
+			if seenCodes[code] {
+				return "SKU code already exists", errors.New("duplicate SKU code " + code)
+			}
+			seenCodes[code] = true
+			codes = append(codes, code)
+		}
+
+		if sku.SizeId != nil {
+			var sizeIsOffered bool
+			err := tx.QueryRow(ctx,
+				`SELECT EXISTS(SELECT 1 FROM product_sizes WHERE product_id = $1 AND size_id = $2)`,
+				productId, *sku.SizeId).Scan(&sizeIsOffered)
+			if err != nil {
+				return "Internal server error while checking product size", err
+			}
+			if !sizeIsOffered {
+				return "Size is not available for the product", fmt.Errorf("product %d has no size %d", productId, *sku.SizeId)
+			}
+		}
+
+		if sku.VariantId != nil {
+			var variantIsOffered bool
+			err := tx.QueryRow(ctx,
+				`SELECT EXISTS(SELECT 1 FROM product_variants WHERE product_id = $1 AND variant_id = $2)`,
+				productId, *sku.VariantId).Scan(&variantIsOffered)
+			if err != nil {
+				return "Internal server error while checking product variant", err
+			}
+			if !variantIsOffered {
+				return "Variant is not available for the product", fmt.Errorf("product %d has no variant %d", productId, *sku.VariantId)
+			}
+		}
+	}
+
+	var codeIsUsed bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM product_skus WHERE sku_code = ANY($1::text[]) AND product_id <> $2)`,
+		codes, productId).Scan(&codeIsUsed)
+	if err != nil {
+		return "Internal server error while checking SKU code", err
+	}
+	if codeIsUsed {
+		return "SKU code already exists", errors.New("SKU code is used by another product")
+	}
+
+	return "", nil
+}
+
+// ReplaceProductSkus swaps the SKUs of a product, combinations that stay keep their id so cart lines stay valid
+func ReplaceProductSkus(productId int, userId int, skus []ProductSkuRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	var isBundle bool
+	err = tx.QueryRow(ctx,
+		`SELECT is_bundle FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, productId).Scan(&isBundle)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Product not found"
+			return isSuccess, message, nil
+		}
+		message = "Internal server error while checking product"
+		return isSuccess, message, err
+	}
+	if isBundle && len(skus) > 0 {
+		message = "Bundle stock follows its components"
+		return isSuccess, message, errors.New("bundles cannot have SKUs")
+	}
+
+	if message, err := validateProductSkus(ctx, tx, productId, skus); err != nil {
+		return isSuccess, message, err
+	}
+
+	sizeIds := make([]int, len(skus))
+	variantIds := make([]int, len(skus))
+	for i, sku := range skus {
+		if sku.SizeId != nil {
+			sizeIds[i] = *sku.SizeId
+		}
+		if sku.VariantId != nil {
+			variantIds[i] = *sku.VariantId
+		}
+	}
+
+	// codes of dropped SKUs are freed first so they can move to another combination
+	_, err = tx.Exec(ctx,
+		`DELETE FROM product_skus
+		WHERE product_id = $1
+		AND (COALESCE(size_id, 0), COALESCE(variant_id, 0)) NOT IN (
+			SELECT * FROM UNNEST($2::int[], $3::int[])
+		)`,
+		productId, sizeIds, variantIds)
+	if err != nil {
+		message = "Internal server error while deleting old SKUs"
+		return isSuccess, message, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE product_skus SET sku_code = NULL WHERE product_id = $1`, productId)
+	if err != nil {
+		message = "Internal server error while updating SKUs"
+		return isSuccess, message, err
+	}
+
+	for _, sku := range skus {
+		isAvailable := true
+		if sku.IsAvailable != nil {
+			isAvailable = *sku.IsAvailable
+		}
+		var skuCode *string
+		if sku.SkuCode != nil && strings.TrimSpace(*sku.SkuCode) != "" {
+			code := strings.TrimSpace(*sku.SkuCode)
+			skuCode = &code
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO product_skus (product_id, size_id, variant_id, sku_code, stock, price, is_available, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			ON CONFLICT (product_id, COALESCE(size_id, 0), COALESCE(variant_id, 0)) DO UPDATE
+			SET sku_code = EXCLUDED.sku_code,
+				stock = EXCLUDED.stock,
+				price = EXCLUDED.price,
+				is_available = EXCLUDED.is_available,
+				updated_by = EXCLUDED.updated_by,
+				updated_at = NOW()`,
+			productId, sku.SizeId, sku.VariantId, skuCode, sku.Stock, sku.Price, isAvailable, userId)
+		if err != nil {
+			message = "Internal server error while saving SKUs"
+			return isSuccess, message, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "SKUs updated successfully"
+	return isSuccess, message, nil
+}
+
+// bundleComponentStock is the stock a bundle takes from one component SKU at checkout
+type bundleComponentStock struct {
+	ProductId int  `db:"product_id"`
+	SkuId     *int `db:"sku_id"`
+	IsDeleted bool `db:"is_deleted"`
+	Quantity  int  `db:"quantity"`
+}
+
+// takeStock takes quantity from the SKU when there is one, otherwise from the product.
+// It reports false when there is not enough stock left, a product with SKUs cannot be taken without one.
+func takeStock(ctx context.Context, tx pgx.Tx, productId int, skuId *int, quantity int) (bool, error) {
+	if skuId != nil {
+		commandTag, err := tx.Exec(ctx,
+			`UPDATE product_skus SET stock = stock - $1, updated_at = NOW()
+			WHERE id = $2 AND is_available = true AND stock >= $1`,
+			quantity, *skuId)
+		return commandTag.RowsAffected() > 0, err
+	}
+
+	commandTag, err := tx.Exec(ctx,
+		`UPDATE products SET stock = stock - $1
+		WHERE id = $2 AND stock >= $1
+		AND NOT EXISTS (SELECT 1 FROM product_skus WHERE product_id = $2)`,
+		quantity, productId)
+	return commandTag.RowsAffected() > 0, err
+}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// suggestIndexKey holds every searchable term with score 0 so ZRANGEBYLEX can do prefix lookups.
+// Members are "<term>\x00<type>\x00<id>\x00<name>".
+const suggestIndexKey = "suggest:index"
+
+// suggestTrigramKey maps each trigram to the index members whose term has it, joined by "\x01",
+// so fuzzy lookups only read the members that share a trigram with the query
+const suggestTrigramKey = "suggest:trigrams"
+
+// suggestMinSimilarity is the same default threshold pg_trgm uses
+const suggestMinSimilarity = 0.3
+
+// suggestMaxFuzzyCandidates caps the members scored by the fuzzy lookup, the ones sharing the most trigrams go first
+const suggestMaxFuzzyCandidates = 500
+
+// suggestRebuild makes sure one background rebuild runs at a time, a change made while it runs
+// is picked up by one more rebuild after it
+var suggestRebuild struct {
+	sync.Mutex
+	isRunning bool
+	isPending bool
+}
+
+type ProductSuggestion struct {
+	Type  string  `json:"type"`
+	Id    int     `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// suggestTerms returns the full name plus every word suffix, so "green tea latte" is found by "tea" or "latte"
+func suggestTerms(name string) []string {
+	words := strings.Fields(strings.ToLower(name))
+	terms := []string{}
+	for i := range words {
+		terms = append(terms, strings.Join(words[i:], " "))
+	}
+	return terms
+}
+
+func parseSuggestMember(member string) (ProductSuggestion, bool) {
+	parts := strings.SplitN(member, "\x00", 4)
+	if len(parts) != 4 {
+		return ProductSuggestion{}, false
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return ProductSuggestion{}, false
+	}
+	return ProductSuggestion{Type: parts[1], Id: id, Name: parts[3]}, true
+}
+
+// RebuildSuggestionIndex reloads active product names and category names into redis
+func RebuildSuggestionIndex(ctx context.Context) error {
+	rows, err := config.DB.Query(ctx,
+		`SELECT 'product', id, name FROM products p WHERE is_active = true AND deleted_at IS NULL AND `+productPublishedCondition+`
+		UNION ALL
+		SELECT 'category', id, name FROM categories WHERE deleted_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	members := []redis.Z{}
+	postings := map[string][]string{}
+	for rows.Next() {
+		var itemType, name string
+		var id int
+		if err := rows.Scan(&itemType, &id, &name); err != nil {
+			return err
+		}
+		for _, term := range suggestTerms(name) {
+			member := fmt.Sprintf("%s\x00%s\x00%d\x00%s", term, itemType, id, name)
+			members = append(members, redis.Z{Score: 0, Member: member})
+			for gram := range trigrams(term) {
+				postings[gram] = append(postings[gram], member)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(members) == 0 {
+		return config.Rdb.Del(ctx, suggestIndexKey, suggestTrigramKey).Err()
+	}
+
+	grams := map[string]any{}
+	for gram, gramMembers := range postings {
+		grams[gram] = strings.Join(gramMembers, "\x01")
+	}
+
+	// build into temporary keys then swap, so readers never see a half built index
+	tmpKey := suggestIndexKey + ":building"
+	tmpTrigramKey := suggestTrigramKey + ":building"
+	pipe := config.Rdb.TxPipeline()
+	pipe.Del(ctx, tmpKey, tmpTrigramKey)
+	pipe.ZAdd(ctx, tmpKey, members...)
+	pipe.HSet(ctx, tmpTrigramKey, grams)
+	pipe.Rename(ctx, tmpKey, suggestIndexKey)
+	pipe.Rename(ctx, tmpTrigramKey, suggestTrigramKey)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// scheduleSuggestionIndexRebuild rebuilds the suggestion index in the background so writes do not wait for it
+func scheduleSuggestionIndexRebuild() {
+	suggestRebuild.Lock()
+	defer suggestRebuild.Unlock()
+	if suggestRebuild.isRunning {
+		suggestRebuild.isPending = true
+		return
+	}
+	suggestRebuild.isRunning = true
+
+	go func() {
+		for {
+			if err := RebuildSuggestionIndex(context.Background()); err != nil {
+				log.Printf("Failed to rebuild suggestion index: %v", err)
+			}
+
+			suggestRebuild.Lock()
+			if !suggestRebuild.isPending {
+				suggestRebuild.isRunning = false
+				suggestRebuild.Unlock()
+				return
+			}
+			suggestRebuild.isPending = false
+			suggestRebuild.Unlock()
+		}
+	}()
+}
+
+// trigrams splits text the way pg_trgm does: lowercase words padded with two spaces in front and one behind
+func trigrams(text string) map[string]struct{} {
+	result := map[string]struct{}{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return result
+}
+
+func trigramSimilarity(a map[string]struct{}, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for gram := range a {
+		if _, ok := b[gram]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// GetProductSuggestions looks up prefix matches first, then fills the rest with trigram matches for misspellings
+func GetProductSuggestions(q string, limit int) ([]ProductSuggestion, string, error) {
+	ctx := context.Background()
+	suggestions := []ProductSuggestion{}
+	message := ""
+	q = strings.ToLower(strings.TrimSpace(q))
+
+	exists, err := config.Rdb.Exists(ctx, suggestIndexKey, suggestTrigramKey).Result()
+	if err != nil {
+		message = "Failed to read suggestion index"
+		return suggestions, message, err
+	}
+	if exists < 2 {
+		if err := RebuildSuggestionIndex(ctx); err != nil {
+			message = "Failed to build suggestion index"
+			return suggestions, message, err
+		}
+	}
+
+	seen := map[string]bool{}
+	add := func(suggestion ProductSuggestion) {
+		key := fmt.Sprintf("%s:%d", suggestion.Type, suggestion.Id)
+		if !seen[key] && len(suggestions) < limit {
+			seen[key] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	// prefix matches, a product can appear once per word so read a few extra
+	members, err := config.Rdb.ZRangeByLex(ctx, suggestIndexKey, &redis.ZRangeBy{
+		Min:   "[" + q,
+		Max:   "[" + q + "\xff",
+		Count: int64(limit * 4),
+	}).Result()
+	if err != nil {
+		message = "Failed to read suggestion index"
+		return suggestions, message, err
+	}
+	for _, member := range members {
+		if suggestion, ok := parseSuggestMember(member); ok {
+			suggestion.Score = 1
+			add(suggestion)
+		}
+	}
+
+	// fuzzy matches, only worth it with at least one full trigram
+	if len(suggestions) < limit && len([]rune(q)) >= 3 {
+		queryGrams := trigrams(q)
+		gramList := []string{}
+		for gram := range queryGrams {
+			gramList = append(gramList, gram)
+		}
+		postings, err := config.Rdb.HMGet(ctx, suggestTrigramKey, gramList...).Result()
+		if err != nil {
+			message = "Failed to read suggestion index"
+			return suggestions, message, err
+		}
+
+		// only members sharing a trigram with the query can reach the threshold, score the closest ones
+		shared := map[string]int{}
+		for _, posting := range postings {
+			if value, ok := posting.(string); ok {
+				for _, member := range strings.Split(value, "\x01") {
+					shared[member]++
+				}
+			}
+		}
+		members = members[:0]
+		for member := range shared {
+			members = append(members, member)
+		}
+		sort.Slice(members, func(i, j int) bool {
+			if shared[members[i]] != shared[members[j]] {
+				return shared[members[i]] > shared[members[j]]
+			}
+			return members[i] < members[j]
+		})
+		if len(members) > suggestMaxFuzzyCandidates {
+			members = members[:suggestMaxFuzzyCandidates]
+		}
+
+		candidates := map[string]ProductSuggestion{}
+		for _, member := range members {
+			suggestion, ok := parseSuggestMember(member)
+			if !ok {
+				continue
+			}
+			term := member[:strings.IndexByte(member, 0)]
+			// compare against the first word of the term so long names are not penalized
+			word := strings.SplitN(term, " ", 2)[0]
+			score := max(trigramSimilarity(queryGrams, trigrams(word)), trigramSimilarity(queryGrams, trigrams(term)))
+			if score < suggestMinSimilarity {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", suggestion.Type, suggestion.Id)
+			if current, ok := candidates[key]; !ok || score > current.Score {
+				suggestion.Score = score
+				candidates[key] = suggestion
+			}
+		}
+
+		fuzzy := []ProductSuggestion{}
+		for _, suggestion := range candidates {
+			fuzzy = append(fuzzy, suggestion)
+		}
+		sort.Slice(fuzzy, func(i, j int) bool {
+			if fuzzy[i].Score != fuzzy[j].Score {
+				return fuzzy[i].Score > fuzzy[j].Score
+			}
+			return fuzzy[i].Name < fuzzy[j].Name
+		})
+		for _, suggestion := range fuzzy {
+			add(suggestion)
+		}
+	}
+
+	message = "Success get suggestions"
+	return suggestions, message, nil
+}
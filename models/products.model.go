@@ -8,26 +8,40 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type AdminProductResponse struct {
-	Id                int      `db:"id" json:"id"`
-	ProductImages     []string `db:"product_images" json:"productImages"`
-	Name              string   `db:"name" json:"name"`
-	Description       string   `db:"description" json:"description"`
-	Price             float64  `db:"price" json:"price"`
-	DiscountPercent   float64  `db:"discount_percent" json:"discountPercent"`
-	Rating            float64  `db:"rating" json:"rating"`
-	IsFlashSale       bool     `db:"is_flash_sale" json:"isFlashSale"`
-	Stock             int      `db:"stock" json:"stock"`
-	IsActive          bool     `db:"is_active" json:"isActive"`
-	IsFavourite       bool     `db:"is_favourite" json:"isFavourite"`
-	ProductSizes      []string `db:"product_sizes" json:"productSizes"`
-	ProductCategories []string `db:"product_categories" json:"productCategories"`
-	ProductVariants   []string `db:"product_variants" json:"productVariants"`
+	Id                int        `db:"id" json:"id"`
+	ProductImages     []string   `db:"product_images" json:"productImages"`
+	Name              string     `db:"name" json:"name"`
+	Slug              string     `db:"slug" json:"slug"`
+	Description       string     `db:"description" json:"description"`
+	Price             float64    `db:"price" json:"price"`
+	DiscountPercent   float64    `db:"discount_percent" json:"discountPercent"`
+	Rating            float64    `db:"rating" json:"rating"`
+	IsFlashSale       bool       `db:"is_flash_sale" json:"isFlashSale"`
+	Stock             int        `db:"stock" json:"stock"`
+	IsActive          bool       `db:"is_active" json:"isActive"`
+	IsFavourite       bool       `db:"is_favourite" json:"isFavourite"`
+	IsBundle          bool       `db:"is_bundle" json:"isBundle"`
+	PublishAt         *time.Time `db:"publish_at" json:"publishAt"`
+	UnpublishAt       *time.Time `db:"unpublish_at" json:"unpublishAt"`
+	AvailableFrom     *string    `db:"available_from" json:"availableFrom"`
+	AvailableUntil    *string    `db:"available_until" json:"availableUntil"`
+	ProductSizes      []string   `db:"product_sizes" json:"productSizes"`
+	ProductCategories []string   `db:"product_categories" json:"productCategories"`
+	ProductVariants   []string   `db:"product_variants" json:"productVariants"`
+	DeletedAt         *time.Time `db:"deleted_at" json:"deletedAt"`
+	// stock per size and variant, allergens and nutrition facts, only filled on the detail
+	Skus      []ProductSku       `db:"-" json:"skus,omitempty"`
+	Allergens []Allergen         `db:"-" json:"allergens,omitempty"`
+	Nutrition []ProductNutrition `db:"-" json:"nutrition,omitempty"`
 }
 
 type ProductRequest struct {
@@ -46,12 +60,15 @@ type ProductRequest struct {
 	SizeProducts      string   `form:"sizeProducts"`
 	ProductCategories string   `form:"productCategories"`
 	ProductVariants   string   `form:"productVariants"`
+	ModifierGroups    string   `form:"modifierGroups"`
+	Allergens         string   `form:"allergens"`
 }
 
 type PublicProductResponse struct {
 	Id              int     `db:"id" json:"id"`
 	ProductImage    string  `db:"product_image" json:"productImage"`
 	Name            string  `db:"name" json:"name"`
+	Slug            string  `db:"slug" json:"slug"`
 	Description     string  `db:"description" json:"description"`
 	Price           float64 `db:"price" json:"price"`
 	DiscountPercent float64 `db:"discount_percent" json:"discountPercent"`
@@ -74,16 +91,28 @@ type PublicProductDetailResponse struct {
 	Id                int                     `db:"id" json:"id"`
 	ProductImages     []string                `db:"product_images" json:"productImages"`
 	Name              string                  `db:"name" json:"name"`
+	Slug              string                  `db:"slug" json:"slug"`
 	Description       string                  `db:"description" json:"description"`
 	Price             float64                 `db:"price" json:"price"`
 	DiscountPercent   float64                 `db:"discount_percent" json:"discountPercent"`
 	DiscountPrice     float64                 `db:"discount_price" json:"discountPrice"`
 	Rating            float64                 `db:"rating" json:"rating"`
+	LowestPrice30Days float64                 `db:"lowest_price_30_days" json:"lowestPrice30Days"`
 	IsFlashSale       bool                    `db:"is_flash_sale" json:"isFlashSale"`
 	Stock             int                     `db:"stock" json:"stock"`
+	IsBundle          bool                    `db:"is_bundle" json:"isBundle"`
+	AvailableFrom     *string                 `db:"available_from" json:"availableFrom"`
+	AvailableUntil    *string                 `db:"available_until" json:"availableUntil"`
+	IsAvailableNow    bool                    `db:"is_available_now" json:"isAvailableNow"`
+	BundleItems       []BundleItem            `db:"-" json:"bundleItems"`
+	Skus              []PublicProductSku      `db:"-" json:"skus"`
+	Allergens         []Allergen              `db:"-" json:"allergens"`
+	Nutrition         []ProductNutrition      `db:"-" json:"nutrition"`
 	ProductCategories []string                `db:"product_categories" json:"productCategories"`
 	ProductSizes      []productSizes          `db:"product_sizes" json:"productSizes"`
 	ProductVariants   []productVariants       `db:"product_variants" json:"productVariants"`
+	ModifierGroups    []ProductModifierGroup  `db:"-" json:"modifierGroups"`
+	IsFavourite       bool                    `db:"-" json:"isFavourite"`
 	Recomendations    []PublicProductResponse `db:"-" json:"recomendations"`
 }
 
@@ -117,6 +146,7 @@ func GetListProductsAdmin(search string, page int, limit int) ([]AdminProductRes
 			`SELECT 
 				p.id,
 				p.name,
+				p.slug,
 				p.description,
 				p.price,
 				COALESCE(p.discount_percent, 0) AS discount_percent,
@@ -125,6 +155,12 @@ func GetListProductsAdmin(search string, page int, limit int) ([]AdminProductRes
 				COALESCE(p.stock, 0) AS stock,
 				p.is_active,
 				p.is_favourite,
+				p.is_bundle,
+				p.publish_at,
+				p.unpublish_at,
+				TO_CHAR(p.available_from, 'HH24:MI') AS available_from,
+				TO_CHAR(p.available_until, 'HH24:MI') AS available_until,
+				p.deleted_at,
 				COALESCE(ARRAY_AGG(DISTINCT pi.product_image) FILTER (WHERE pi.product_image IS NOT NULL), '{}') AS product_images,
 				COALESCE(ARRAY_AGG(DISTINCT s.name) FILTER (WHERE s.name IS NOT NULL), '{}') AS product_sizes,
 				COALESCE(ARRAY_AGG(DISTINCT c.name) FILTER (WHERE c.name IS NOT NULL), '{}') AS product_categories,
@@ -148,6 +184,7 @@ func GetListProductsAdmin(search string, page int, limit int) ([]AdminProductRes
 			`SELECT 
 				p.id,
 				p.name,
+				p.slug,
 				p.description,
 				p.price,
 				COALESCE(p.discount_percent, 0) AS discount_percent,
@@ -156,6 +193,12 @@ func GetListProductsAdmin(search string, page int, limit int) ([]AdminProductRes
 				COALESCE(p.stock, 0) AS stock,
 				p.is_active,
 				p.is_favourite,
+				p.is_bundle,
+				p.publish_at,
+				p.unpublish_at,
+				TO_CHAR(p.available_from, 'HH24:MI') AS available_from,
+				TO_CHAR(p.available_until, 'HH24:MI') AS available_until,
+				p.deleted_at,
 				COALESCE(ARRAY_AGG(DISTINCT pi.product_image) FILTER (WHERE pi.product_image IS NOT NULL), '{}') AS product_images,
 				COALESCE(ARRAY_AGG(DISTINCT s.name) FILTER (WHERE s.name IS NOT NULL), '{}') AS product_sizes,
 				COALESCE(ARRAY_AGG(DISTINCT c.name) FILTER (WHERE c.name IS NOT NULL), '{}') AS product_categories,
@@ -191,6 +234,7 @@ func GetDetailProductAdmin(id int) (AdminProductResponse, string, error) {
 	query := `SELECT 
 				p.id,
 				p.name,
+				p.slug,
 				p.description,
 				p.price,
 				COALESCE(p.discount_percent, 0) AS discount_percent,
@@ -199,6 +243,12 @@ func GetDetailProductAdmin(id int) (AdminProductResponse, string, error) {
 				COALESCE(p.stock, 0) AS stock,
 				p.is_active,
 				p.is_favourite,
+				p.is_bundle,
+				p.publish_at,
+				p.unpublish_at,
+				TO_CHAR(p.available_from, 'HH24:MI') AS available_from,
+				TO_CHAR(p.available_until, 'HH24:MI') AS available_until,
+				p.deleted_at,
 				COALESCE(ARRAY_AGG(DISTINCT pi.product_image) FILTER (WHERE pi.product_image IS NOT NULL), '{}') AS product_images,
 				COALESCE(ARRAY_AGG(DISTINCT s.name) FILTER (WHERE s.name IS NOT NULL), '{}') AS product_sizes,
 				COALESCE(ARRAY_AGG(DISTINCT c.name) FILTER (WHERE c.name IS NOT NULL), '{}') AS product_categories,
@@ -231,6 +281,21 @@ func GetDetailProductAdmin(id int) (AdminProductResponse, string, error) {
 		return product, message, err
 	}
 
+	product.Skus, message, err = GetProductSkus(id)
+	if err != nil {
+		return product, message, err
+	}
+
+	product.Allergens, message, err = GetProductAllergens(id)
+	if err != nil {
+		return product, message, err
+	}
+
+	product.Nutrition, message, err = GetProductNutrition(id)
+	if err != nil {
+		return product, message, err
+	}
+
 	return product, message, nil
 }
 
@@ -431,7 +496,8 @@ func UpdateDataProduct(tx pgx.Tx, productId int, bodyUpdate *ProductRequest, use
 	return commandTag, err
 }
 
-func DeleteDataProduct(productId int) (bool, string, error) {
+// DeleteDataProduct soft deletes a product. Its rows in carts are removed, order history keeps pointing at it.
+func DeleteDataProduct(productId int, userId int) (bool, string, error) {
 	isSuccess := false
 	message := ""
 
@@ -443,60 +509,125 @@ func DeleteDataProduct(productId int) (bool, string, error) {
 	}
 	defer tx.Rollback(ctx)
 
-	// check if product exists
-	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", productId).Scan(&exists)
+	commandTag, err := tx.Exec(ctx,
+		`UPDATE products
+		 SET deleted_at = NOW(),
+		     deleted_by = $2,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NULL`, productId, userId)
 	if err != nil {
-		message = "Internal server error while checking product existence"
+		message = "Internal server error while deleting product data"
 		return isSuccess, message, err
 	}
 
-	if !exists {
+	if commandTag.RowsAffected() == 0 {
 		message = "Product not found"
 		return isSuccess, message, nil
 	}
 
-	// get url product image
-	var images []string
-	err = tx.QueryRow(context.Background(), "SELECT ARRAY_AGG(product_image) FROM product_images WHERE product_id = $1", productId).Scan(&images)
+	_, err = tx.Exec(ctx, `DELETE FROM carts WHERE product_id = $1`, productId)
 	if err != nil {
-		message = "Failed to get url product images to delete"
+		message = "Failed to remove product from carts"
 		return isSuccess, message, err
 	}
 
-	// delete product image in cloudinary
-	for _, image := range images {
-		err = utils.DeleteFromSupabase(image, "products")
-		if err != nil {
-			message = "Failed to delete product image in cloudinary"
-			return isSuccess, message, err
-		}
+	// commit transaction
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
 	}
 
-	// delete product
-	commandTag, err := tx.Exec(ctx, `DELETE FROM products WHERE id = $1`, productId)
+	isSuccess = true
+	message = "Product deleted successfully"
+	return isSuccess, message, nil
+}
+
+func RestoreDataProduct(productId int, userId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE products
+		 SET deleted_at = NULL,
+		     deleted_by = NULL,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NOT NULL`, productId, userId)
 	if err != nil {
-		message = "Internal server error while deleting product data"
-		return isSuccess, message, err
+		return false, "Internal server error while restoring product data", err
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		message = "Product not found"
-		return isSuccess, message, nil
+		return false, "Deleted product not found", nil
+	}
+
+	return true, "Product restored successfully", nil
+}
+
+// PurgeDeletedProducts permanently removes products soft deleted at least olderThanDays ago
+// that no order references, then their images in storage
+func PurgeDeletedProducts(olderThanDays int) (PurgeResult, string, error) {
+	result := PurgeResult{}
+	message := ""
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return result, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := softDeletedIds(ctx, tx, "products", olderThanDays)
+	if err != nil {
+		message = "Failed to fetch deleted products"
+		return result, message, err
+	}
+
+	// image rows cascade with the product, read the urls first
+	images := map[int][]string{}
+	rows, err := tx.Query(ctx, `SELECT product_id, product_image FROM product_images WHERE product_id = ANY($1::int[])`, ids)
+	if err != nil {
+		message = "Failed to get url product images to delete"
+		return result, message, err
+	}
+	for rows.Next() {
+		var productId int
+		var image string
+		if err := rows.Scan(&productId, &image); err != nil {
+			rows.Close()
+			message = "Failed to get url product images to delete"
+			return result, message, err
+		}
+		images[productId] = append(images[productId], image)
+	}
+	rows.Close()
+
+	result, err = purgeRows(ctx, tx, "products", ids)
+	if err != nil {
+		message = "Internal server error while purging products"
+		return result, message, err
 	}
 
-	// commit transaction
 	err = tx.Commit(ctx)
 	if err != nil {
 		message = "Failed to commit transaction"
-		return isSuccess, message, err
+		return result, message, err
 	}
 
-	isSuccess = true
-	message = "Product deleted successfully"
-	return isSuccess, message, nil
+	// rows are gone already, a failed storage delete only leaves an orphaned file
+	for _, productId := range result.Purged {
+		for _, image := range images[productId] {
+			if err := utils.DeleteFromSupabase(image, "products"); err != nil {
+				log.Printf("Failed to delete image %s of purged product %d: %v", image, productId, err)
+			}
+		}
+	}
+
+	message = "Deleted products purged successfully"
+	return result, message, nil
 }
 
+// GetListFavouriteProducts returns the products favourited by the most users
 func GetListFavouriteProducts(limit int) ([]PublicProductResponse, error) {
 	var rows pgx.Rows
 	var err error
@@ -505,21 +636,23 @@ func GetListFavouriteProducts(limit int) ([]PublicProductResponse, error) {
 		`SELECT 
 			p.id,
 			p.name,
+			p.slug,
 			p.description,
 			p.price,
-			COALESCE(p.discount_percent, 0) AS discount_percent,
-			CASE 
-				WHEN p.discount_percent = 0 OR p.discount_percent IS NULL THEN 0
-				ELSE p.price * (1 - (p.discount_percent/100.0))
-			END AS discount_price,
-			p.is_flash_sale,
-			p.is_favourite,
+			`+productPriceColumns+`,
+			false AS is_favourite,
 			COALESCE(MAX(pi.product_image), '') AS product_image
 		FROM products p
+		JOIN (
+			SELECT product_id, COUNT(*) AS total_favourites
+			FROM user_favourites
+			GROUP BY product_id
+		) uf ON uf.product_id = p.id
 		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
-		WHERE p.is_favourite = true AND p.is_active = true
-		GROUP BY p.id
-		ORDER BY p.id ASC
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+		WHERE p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		GROUP BY p.id, uf.total_favourites
+		ORDER BY uf.total_favourites DESC, p.id ASC
 		LIMIT $1`, limit)
 
 	if err != nil {
@@ -535,126 +668,220 @@ func GetListFavouriteProducts(limit int) ([]PublicProductResponse, error) {
 	return products, nil
 }
 
-func TotalDataProductsPublic(q string, cat []string, maxPrice float64, minPrice float64) (int, error) {
-	var totalData int
+// productSearchQuery turns free text into a prefix tsquery, e.g. "green te" -> "green:* & te:*"
+func productSearchQuery(q string) string {
+	terms := []string{}
+	for _, word := range strings.Fields(strings.ToLower(q)) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if word != "" {
+			terms = append(terms, word+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
 
-	query := `
-		SELECT COUNT(DISTINCT p.id)
-		FROM products p
-		LEFT JOIN product_categories pc ON pc.product_id = p.id
-		LEFT JOIN categories c ON c.id = pc.category_id
-		WHERE p.is_active = true`
+// productSearchCondition matches the tsquery in $tsParam against the search vector,
+// or the raw text in $textParam against the name with trigram similarity for typos
+func productSearchCondition(tsParam int, textParam int) string {
+	return fmt.Sprintf(`(p.search_vector @@ to_tsquery('simple', $%d) OR $%d <%% p.name OR p.name ILIKE '%%' || $%d || '%%')`,
+		tsParam, textParam, textParam)
+}
+
+// productSearchRank orders full-text hits first, then the closest fuzzy matches
+func productSearchRank(tsParam int, textParam int) string {
+	return fmt.Sprintf(`(ts_rank(p.search_vector, to_tsquery('simple', $%d)) + word_similarity($%d, p.name))::float8`,
+		tsParam, textParam)
+}
+
+// flashSaleCondition matches products flagged by hand or in a running flash sale
+const flashSaleCondition = `(p.is_flash_sale = true OR EXISTS (
+			SELECT 1 FROM active_flash_sale_products fs WHERE fs.product_id = p.id))`
+
+// PublicProductFilter holds every filter accepted by the public product list
+type PublicProductFilter struct {
+	Q          string
+	Categories []string
+	Sizes      []string
+	Variants   []string
+	MinPrice   float64
+	MaxPrice   float64
+	MinRating  float64
+	FlashSale  bool
+	InStock    bool
+	// allergen names or slugs the product must not contain
+	ExcludeAllergens []string
+}
 
-	args := []any{}
-	paramCount := 1
+// publicProductConditions builds the WHERE clause shared by list, total and facets.
+// New params are appended to args, searchParam is the position of the tsquery param or 0.
+func publicProductConditions(filter PublicProductFilter, args []any) (string, []any, int) {
+	// scheduled and out of hours products are left out, the cache is dropped when they change by SyncFlashSaleCache
+	args = append(args, storeClock())
+	query := ` WHERE p.is_active = true AND p.deleted_at IS NULL AND ` + productPublishedCondition +
+		fmt.Sprintf(` AND %s`, productInHoursCondition(len(args)))
+	searchParam := 0
 
 	// search filter
-	if q != "" {
-		query += fmt.Sprintf(` AND p.name ILIKE $%d`, paramCount)
-		args = append(args, "%"+q+"%")
-		paramCount++
+	if filter.Q != "" {
+		searchParam = len(args) + 1
+		query += ` AND ` + productSearchCondition(searchParam, searchParam+1)
+		args = append(args, productSearchQuery(filter.Q), filter.Q)
 	}
 
-	// category filter
-	if len(cat) > 0 {
-		query += fmt.Sprintf(` AND c.name = ANY($%d)`, paramCount)
-		args = append(args, cat)
-		paramCount++
+	// category filter, by name or slug and including every subcategory
+	if len(filter.Categories) > 0 {
+		args = append(args, filter.Categories)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM product_categories pc
+			WHERE pc.product_id = p.id AND pc.category_id IN (
+				WITH RECURSIVE selected AS (
+					SELECT c.id FROM categories c
+					WHERE c.deleted_at IS NULL AND (c.name = ANY($%[1]d) OR c.slug = ANY($%[1]d))
+					UNION
+					SELECT c.id FROM categories c
+					JOIN selected s ON c.parent_id = s.id
+					WHERE c.deleted_at IS NULL
+				)
+				SELECT id FROM selected))`, len(args))
+	}
+
+	// size filter
+	if len(filter.Sizes) > 0 {
+		args = append(args, filter.Sizes)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM product_sizes ps
+			JOIN sizes s ON s.id = ps.size_id
+			WHERE ps.product_id = p.id AND s.name = ANY($%d))`, len(args))
+	}
+
+	// variant filter
+	if len(filter.Variants) > 0 {
+		args = append(args, filter.Variants)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM product_variants pv
+			JOIN variants v ON v.id = pv.variant_id
+			WHERE pv.product_id = p.id AND v.name = ANY($%d))`, len(args))
+	}
+
+	// allergen filter, products containing any of them, themselves or through a bundle component, are left out
+	if len(filter.ExcludeAllergens) > 0 {
+		args = append(args, filter.ExcludeAllergens)
+		query += fmt.Sprintf(` AND NOT EXISTS (
+			SELECT 1 FROM product_allergens pa
+			JOIN allergens a ON a.id = pa.allergen_id
+			WHERE (pa.product_id = p.id OR pa.product_id IN (
+				SELECT pbi.component_product_id FROM product_bundle_items pbi WHERE pbi.bundle_product_id = p.id))
+			AND (a.name = ANY($%[1]d) OR a.slug = ANY($%[1]d)))`, len(args))
 	}
 
 	// price range filter
-	if minPrice > 0 {
-		query += fmt.Sprintf(` AND p.price >= $%d`, paramCount)
-		args = append(args, minPrice)
-		paramCount++
+	if filter.MinPrice > 0 {
+		args = append(args, filter.MinPrice)
+		query += fmt.Sprintf(` AND p.price >= $%d`, len(args))
 	}
 
-	if maxPrice > 0 {
-		query += fmt.Sprintf(` AND p.price <= $%d`, paramCount)
-		args = append(args, maxPrice)
-		paramCount++
+	if filter.MaxPrice > 0 {
+		args = append(args, filter.MaxPrice)
+		query += fmt.Sprintf(` AND p.price <= $%d`, len(args))
 	}
 
+	// rating filter
+	if filter.MinRating > 0 {
+		args = append(args, filter.MinRating)
+		query += fmt.Sprintf(` AND p.rating >= $%d`, len(args))
+	}
+
+	if filter.FlashSale {
+		query += ` AND ` + flashSaleCondition
+	}
+
+	if filter.InStock {
+		query += ` AND p.stock > 0`
+	}
+
+	return query, args, searchParam
+}
+
+func TotalDataProductsPublic(filter PublicProductFilter) (int, error) {
+	var totalData int
+
+	conditions, args, _ := publicProductConditions(filter, []any{})
+	query := `SELECT COUNT(*) FROM products p` + conditions
+
 	err := config.DB.QueryRow(context.Background(), query, args...).Scan(&totalData)
 	return totalData, err
 }
 
-func GetListProductsPublic(q string, cat []string, sort string, maxPrice float64, minPrice float64, limit int, page int) ([]PublicProductResponse, error) {
-	products := []PublicProductResponse{}
-	offset := (page - 1) * limit
+// productPriceColumns prices p with its running flash sale (joined as "fs" from active_flash_sale_products),
+// falling back to the manual discount. fs has at most one row per product, MAX only satisfies GROUP BY p.id.
+const productPriceColumns = `
+			COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) AS discount_percent,
+			CASE
+				WHEN COALESCE(MAX(fs.discount_percent), p.discount_percent, 0) = 0 THEN 0
+				ELSE p.price * (1 - (COALESCE(MAX(fs.discount_percent), p.discount_percent) / 100.0))
+			END AS discount_price,
+			(COALESCE(p.is_flash_sale, false) OR COUNT(fs.product_id) > 0) AS is_flash_sale`
 
-	query := `
+// publicProductSelect is the list projection shared by offset and cursor pagination.
+// is_favourite is per user and filled in after the (shared, cached) list is fetched.
+const publicProductSelect = `
 		SELECT 
 			p.id,
 			p.name,
+			p.slug,
 			p.description,
 			p.price,
-			COALESCE(p.discount_percent, 0) AS discount_percent,
-			CASE 
-				WHEN p.discount_percent = 0 OR p.discount_percent IS NULL THEN 0
-				ELSE p.price * (1 - (p.discount_percent/100.0))
-			END AS discount_price,
-			p.is_flash_sale,
-			p.is_favourite,
-			COALESCE(MAX(pi.product_image), '') AS product_image
-		FROM products p
-		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
-		LEFT JOIN product_categories pc ON pc.product_id = p.id
-		LEFT JOIN categories c ON c.id = pc.category_id
-		WHERE p.is_active = true`
-
-	// Dynamic parameters
-	args := []any{}
-	paramCount := 1
-
-	// Search filter
-	if q != "" {
-		query += fmt.Sprintf(` AND p.name ILIKE $%d`, paramCount)
-		args = append(args, "%"+q+"%")
-		paramCount++
+			` + productPriceColumns + `,
+			false AS is_favourite,
+			COALESCE(MAX(pi.product_image), '') AS product_image`
+
+// publicProductSort resolves a sort name to its sort key expression, cast type and direction.
+// Searching without explicit sort orders by relevance.
+func publicProductSort(sort string, searchParam int) (string, string, string, bool) {
+	switch sort {
+	case "name_asc":
+		return sort, "p.name", "text", false
+	case "name_desc":
+		return sort, "p.name", "text", true
+	case "price_asc":
+		return sort, "p.price", "numeric", false
+	case "price_desc":
+		return sort, "p.price", "numeric", true
 	}
-
-	// Category filter
-	if len(cat) > 0 {
-		query += fmt.Sprintf(` AND c.name = ANY($%d)`, paramCount)
-		args = append(args, cat)
-		paramCount++
+	if searchParam > 0 {
+		return "relevance", productSearchRank(searchParam, searchParam+1), "float8", true
 	}
+	return "id_asc", "p.id", "int", false
+}
 
-	// Price range filter
-	if minPrice > 0 {
-		query += fmt.Sprintf(` AND p.price >= $%d`, paramCount)
-		args = append(args, minPrice)
-		paramCount++
-	}
+func GetListProductsPublic(filter PublicProductFilter, sort string, limit int, page int) ([]PublicProductResponse, error) {
+	products := []PublicProductResponse{}
+	offset := (page - 1) * limit
 
-	if maxPrice > 0 {
-		query += fmt.Sprintf(` AND p.price <= $%d`, paramCount)
-		args = append(args, maxPrice)
-		paramCount++
-	}
+	query := publicProductSelect + `
+		FROM products p
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id`
+
+	// Filters
+	conditions, args, searchParam := publicProductConditions(filter, []any{})
+	query += conditions
 
 	// Group by
 	query += ` GROUP BY p.id`
 
-	// Sorting
-	orderBy := "p.id ASC"
-	if sort != "" {
-		switch sort {
-		case "name_asc":
-			orderBy = "p.name ASC"
-		case "name_desc":
-			orderBy = "p.name DESC"
-		case "price_asc":
-			orderBy = "p.price ASC"
-		case "price_desc":
-			orderBy = "p.price DESC"
-		}
-	}
+	// Sorting, most relevant first when searching without explicit sort
+	_, sortExpr, _, desc := publicProductSort(sort, searchParam)
+	_, orderBy := utils.KeysetCondition(sortExpr, "p.id", "", desc, nil, 0)
 	query += fmt.Sprintf(` ORDER BY %s`, orderBy)
 
 	// Pagination
-	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, paramCount, paramCount+1)
+	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
 	args = append(args, limit, offset)
 
 	// Execute query
@@ -672,24 +899,112 @@ func GetListProductsPublic(q string, cat []string, sort string, maxPrice float64
 	return products, nil
 }
 
+// GetListProductsPublicCursor is GetListProductsPublic with keyset pagination on (sort key, id)
+func GetListProductsPublicCursor(filter PublicProductFilter, sort string, limit int, encodedCursor string) ([]PublicProductResponse, utils.CursorPage, string, error) {
+	type productRow struct {
+		PublicProductResponse
+		SortKey string `db:"sort_key"`
+	}
+	products := []PublicProductResponse{}
+	message := ""
+
+	conditions, args, searchParam := publicProductConditions(filter, []any{})
+	sortName, sortExpr, castType, desc := publicProductSort(sort, searchParam)
+
+	cursor, err := utils.DecodeCursor(encodedCursor, sortName)
+	if err != nil {
+		message = "Invalid cursor"
+		return products, utils.CursorPage{}, message, err
+	}
+
+	query := publicProductSelect + fmt.Sprintf(`,
+			(%s)::text AS sort_key
+		FROM products p
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id`, sortExpr)
+	query += conditions
+
+	condition, orderBy := utils.KeysetCondition(sortExpr, "p.id", castType, desc, cursor, len(args)+1)
+	if cursor != nil {
+		query += ` AND ` + condition
+		args = append(args, cursor.Value, cursor.Id)
+	}
+
+	query += ` GROUP BY p.id`
+	args = append(args, limit+1)
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, orderBy, len(args))
+
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		message = "Failed to fetch products from database"
+		return products, utils.CursorPage{}, message, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByName[productRow])
+	if err != nil {
+		message = "Failed to process product data from database"
+		return products, utils.CursorPage{}, message, err
+	}
+
+	result, cursors := utils.KeysetPage(result, limit, cursor, sortName, func(row productRow) (string, int) {
+		return row.SortKey, row.Id
+	})
+	for _, row := range result {
+		products = append(products, row.PublicProductResponse)
+	}
+
+	message = "Success get all product"
+	return products, cursors, message, nil
+}
+
+// GetProductIdBySlug resolves the current or an earlier slug of a published product to its id and current slug
+func GetProductIdBySlug(slug string) (int, string, string, error) {
+	productId := 0
+	currentSlug := ""
+	message := ""
+
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT p.id, p.slug
+		FROM products p
+		WHERE p.slug = $1 AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		UNION ALL
+		SELECT p.id, p.slug
+		FROM product_slug_redirects r
+		JOIN products p ON p.id = r.product_id
+		WHERE r.slug = $1 AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		LIMIT 1`, slug).Scan(&productId, &currentSlug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Product not found"
+			return productId, currentSlug, message, err
+		}
+		message = "Failed to fetch product from database"
+		return productId, currentSlug, message, err
+	}
+
+	message = "Success get product"
+	return productId, currentSlug, message, nil
+}
+
 func GetDetailProductPublic(id int) (PublicProductDetailResponse, string, error) {
 	product := PublicProductDetailResponse{}
 	message := ""
 	query := `SELECT 
 				p.id,
 				p.name,
+				p.slug,
 				p.description,
 				p.price,
-				COALESCE(p.discount_percent, 0) AS discount_percent,
-				CASE 
-					WHEN p.discount_percent = 0 OR p.discount_percent IS NULL THEN 0
-					ELSE p.price * (1 - (p.discount_percent / 100.0))
-				END AS discount_price,
-				COALESCE(p.rating, 0) AS rating,
-				p.is_flash_sale,
+				` + productPriceColumns + `,
+				COALESCE(p.rating, 0) AS rating,` + lowestPrice30DaysColumn + `,
 				COALESCE(p.stock, 0) AS stock,
+				p.is_bundle,
+				TO_CHAR(p.available_from, 'HH24:MI') AS available_from,
+				TO_CHAR(p.available_until, 'HH24:MI') AS available_until,
+				` + productAvailableNowColumn(2) + `,
 				COALESCE(ARRAY_AGG(DISTINCT pi.product_image) FILTER (WHERE pi.product_image IS NOT NULL), '{}') AS product_images,
-				COALESCE(ARRAY_AGG(DISTINCT c.name) FILTER (WHERE c.name IS NOT NULL), '{}') AS product_categories,
+				COALESCE(ARRAY_AGG(DISTINCT c.name) FILTER (WHERE c.name IS NOT NULL AND c.deleted_at IS NULL), '{}') AS product_categories,
 				COALESCE(
 					JSON_AGG(
 						DISTINCT JSONB_BUILD_OBJECT('id', s.id, 'size', s.name)
@@ -704,13 +1019,14 @@ func GetDetailProductPublic(id int) (PublicProductDetailResponse, string, error)
 				) AS product_variants
 			FROM products p
 			LEFT JOIN product_images pi ON pi.product_id = p.id
+			LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
 			LEFT JOIN product_sizes sp ON sp.product_id = p.id
 			LEFT JOIN sizes s ON s.id = sp.size_id
 			LEFT JOIN product_categories pc ON pc.product_id = p.id
 			LEFT JOIN categories c ON c.id = pc.category_id
 			LEFT JOIN product_variants pv ON pv.product_id = p.id
 			LEFT JOIN variants v ON v.id = pv.variant_id
-			WHERE p.id = $1
+			WHERE p.id = $1 AND p.deleted_at IS NULL AND ` + productPublishedCondition + `
 			GROUP BY p.id;`
 
 	tx, err := config.DB.Begin(context.Background())
@@ -720,7 +1036,7 @@ func GetDetailProductPublic(id int) (PublicProductDetailResponse, string, error)
 	}
 	defer tx.Rollback(context.Background())
 
-	rows, err := tx.Query(context.Background(), query, id)
+	rows, err := tx.Query(context.Background(), query, id, storeClock())
 	if err != nil {
 		message = "Failed to fetch detail product from database"
 		return product, message, err
@@ -737,46 +1053,41 @@ func GetDetailProductPublic(id int) (PublicProductDetailResponse, string, error)
 		return product, message, err
 	}
 
-	queryRecomendation := `
-						SELECT 
-							p.id,
-							p.name,
-							p.description,
-							p.price,
-							COALESCE(p.discount_percent, 0) AS discount_percent,
-							CASE 
-								WHEN p.discount_percent = 0 OR p.discount_percent IS NULL THEN 0
-								ELSE p.price * (1 - (p.discount_percent/100.0))
-							END AS discount_price,
-							p.is_flash_sale,
-							p.is_favourite,
-							COALESCE(MAX(pi.product_image), '') AS product_image
-						FROM products p
-						LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
-						WHERE p.is_active = true
-						AND p.id != $1
-						AND EXISTS (
-							SELECT 1
-							FROM product_categories pc1
-							WHERE pc1.product_id = p.id
-							AND pc1.category_id IN (
-								SELECT pc2.category_id
-								FROM product_categories pc2
-								WHERE pc2.product_id = $1
-							)
-						)
-						GROUP BY p.id
-						ORDER BY RANDOM()
-						LIMIT 5;`
-
-	rowsRec, err := tx.Query(context.Background(), queryRecomendation, id)
+	product.BundleItems, err = getBundleItems(tx, id)
 	if err != nil {
-		message = "Failed to get recomendation product from database"
+		message = "Failed to get bundle items of product from database"
+		return product, message, err
+	}
+
+	product.Skus, err = getPublicProductSkus(tx, id, product.DiscountPercent)
+	if err != nil {
+		message = "Failed to get SKUs of product from database"
+		return product, message, err
+	}
+
+	product.Allergens, err = getProductAllergens(tx, id)
+	if err != nil {
+		message = "Failed to get allergens of product from database"
+		return product, message, err
+	}
+
+	product.Nutrition, err = getPublicProductNutrition(tx, id)
+	if err != nil {
+		message = "Failed to get nutrition facts of product from database"
 		return product, message, err
 	}
-	defer rowsRec.Close()
 
-	product.Recomendations, _ = pgx.CollectRows(rowsRec, pgx.RowToStructByName[PublicProductResponse])
+	product.ModifierGroups, err = getProductModifierGroups(tx, id)
+	if err != nil {
+		message = "Failed to get modifier groups of product from database"
+		return product, message, err
+	}
+
+	product.Recomendations, err = getProductRecommendations(tx, id, 5)
+	if err != nil {
+		message = "Failed to get recomendation product from database"
+		return product, message, err
+	}
 
 	err = tx.Commit(context.Background())
 	if err != nil {
@@ -792,7 +1103,10 @@ func InvalidateProductCache(ctx context.Context) error {
 		"productsAdmin:total:*",
 		"/admin/products*",
 		"productsPublic:total:*",
+		"productsPublic:facets:*",
 		"/products*",
+		"/favourite-products*",
+		"catalog:*",
 	}
 
 	for _, pattern := range patterns {
@@ -812,5 +1126,8 @@ func InvalidateProductCache(ctx context.Context) error {
 		}
 	}
 
+	// product names may have changed, keep search suggestions in sync without holding up the write
+	scheduleSuggestionIndexRebuild()
+
 	return nil
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PurgeResult lists the soft deleted rows removed for good and the ones kept because they are still referenced
+type PurgeResult struct {
+	Purged  []int `json:"purged"`
+	Skipped []int `json:"skipped"`
+}
+
+// softDeletedIds returns the rows of table soft deleted at least olderThanDays ago
+func softDeletedIds(ctx context.Context, tx pgx.Tx, table string, olderThanDays int) ([]int, error) {
+	rows, err := tx.Query(ctx,
+		fmt.Sprintf(`SELECT id FROM %s WHERE deleted_at IS NOT NULL AND deleted_at <= NOW() - MAKE_INTERVAL(days => $1) ORDER BY id ASC`, table),
+		olderThanDays)
+	if err != nil {
+		return []int{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// purgeRows hard deletes ids from table, each in its own savepoint so a row that is
+// still referenced by a foreign key (e.g. transaction_items) is skipped instead of failing the purge
+func purgeRows(ctx context.Context, tx pgx.Tx, table string, ids []int) (PurgeResult, error) {
+	result := PurgeResult{Purged: []int{}, Skipped: []int{}}
+
+	for _, id := range ids {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		_, err = savepoint.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, table), id)
+		if err != nil {
+			savepoint.Rollback(ctx)
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				result.Skipped = append(result.Skipped, id)
+				continue
+			}
+			return result, err
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return result, err
+		}
+		result.Purged = append(result.Purged, id)
+	}
+
+	return result, nil
+}
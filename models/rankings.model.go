@@ -0,0 +1,304 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// Units sold are kept in one sorted set per hour ("rank:sales:2006010215", member product id).
+// Rankings for a window are a ZUNIONSTORE over the hourly buckets, so old orders fall out on their own.
+const (
+	salesBucketPrefix  = "rank:sales:"
+	salesBucketFormat  = "2006010215"
+	salesBuiltKey      = "rank:sales:built"
+	salesBucketHours   = 720
+	rankingCacheTTL    = 5 * time.Minute
+	RankingTrending    = "trending"
+	RankingBestSellers = "best-sellers"
+	// FinishedOrderStatus is the status an order counts towards the rankings from
+	FinishedOrderStatus = "Finish Order"
+)
+
+type rankingWindow struct {
+	hours    int
+	halfLife float64
+}
+
+// RankingWindows maps a window to its length and, for trending, the decay half life in hours
+var RankingWindows = map[string]rankingWindow{
+	"24h": {hours: 24, halfLife: 6},
+	"7d":  {hours: 168, halfLife: 48},
+	"30d": {hours: 720, halfLife: 168},
+}
+
+type RankedProduct struct {
+	PublicProductResponse
+	Score float64 `db:"-" json:"score"`
+}
+
+func salesBucketKey(t time.Time) string {
+	return salesBucketPrefix + t.Format(salesBucketFormat)
+}
+
+// salesBucketExpiry keeps a bucket until it leaves the longest window
+func salesBucketExpiry(bucket time.Time) time.Duration {
+	return time.Until(bucket.Add(time.Duration(salesBucketHours+1) * time.Hour))
+}
+
+func clearRankingCache(ctx context.Context, pipe redis.Pipeliner) {
+	for window := range RankingWindows {
+		pipe.Del(ctx, "rank:"+RankingTrending+":"+window, "rank:"+RankingBestSellers+":"+window)
+	}
+}
+
+// RecordSales adds the units of a finished order to the bucket of the hour it was placed
+func RecordSales(ctx context.Context, orderedAt time.Time, units map[int]int) error {
+	bucket := orderedAt.Truncate(time.Hour)
+	key := salesBucketKey(bucket)
+
+	pipe := config.Rdb.TxPipeline()
+	for productId, amount := range units {
+		pipe.ZIncrBy(ctx, key, float64(amount), strconv.Itoa(productId))
+	}
+	pipe.Expire(ctx, key, salesBucketExpiry(bucket))
+	clearRankingCache(ctx, pipe)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RecordFinishedOrderSales adds the units of an order that just moved to the finished status
+func RecordFinishedOrderSales(ctx context.Context, transactionId int, orderedAt time.Time) error {
+	rows, err := config.DB.Query(ctx,
+		`SELECT product_id, SUM(amount)::int
+		FROM transaction_items
+		WHERE transaction_id = $1 AND product_id IS NOT NULL
+		GROUP BY product_id`, transactionId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	units := map[int]int{}
+	for rows.Next() {
+		var productId, amount int
+		if err := rows.Scan(&productId, &amount); err != nil {
+			return err
+		}
+		units[productId] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// timestamp columns come back as UTC with the stored wall clock
+	orderedAt = time.Date(orderedAt.Year(), orderedAt.Month(), orderedAt.Day(), orderedAt.Hour(), orderedAt.Minute(), 0, 0, time.Local)
+	return RecordSales(ctx, orderedAt, units)
+}
+
+// InvalidateSalesRankings makes the next ranking read rebuild from postgres.
+// Used for refunds, which are rare and would otherwise need the hour of the original order.
+func InvalidateSalesRankings(ctx context.Context) error {
+	return config.Rdb.Del(ctx, salesBuiltKey).Err()
+}
+
+// RebuildSalesRankings reloads the hourly buckets of the last 30 days from postgres, finished orders only and net of refunds
+func RebuildSalesRankings(ctx context.Context) (int, string, error) {
+	message := ""
+	since := time.Now().Add(-salesBucketHours * time.Hour).Truncate(time.Hour)
+
+	rows, err := config.DB.Query(ctx,
+		`SELECT
+			DATE_TRUNC('hour', t.date_transaction) AS bucket,
+			ti.product_id,
+			SUM(ti.amount - COALESCE(r.refunded_amount, 0))::int AS units
+		FROM transaction_items ti
+		JOIN transactions t ON t.id = ti.transaction_id
+		JOIN status s ON s.id = t.status_id AND s.name = $2
+		LEFT JOIN (
+			SELECT transaction_item_id, SUM(amount) AS refunded_amount
+			FROM refund_items
+			GROUP BY transaction_item_id
+		) r ON r.transaction_item_id = ti.id
+		WHERE t.date_transaction >= $1 AND ti.product_id IS NOT NULL
+		GROUP BY 1, 2
+		HAVING SUM(ti.amount - COALESCE(r.refunded_amount, 0)) > 0`, since, FinishedOrderStatus)
+	if err != nil {
+		message = "Failed to fetch sales from database"
+		return 0, message, err
+	}
+	defer rows.Close()
+
+	buckets := map[time.Time]map[int]int{}
+	for rows.Next() {
+		var bucket time.Time
+		var productId, units int
+		if err := rows.Scan(&bucket, &productId, &units); err != nil {
+			message = "Failed to process sales data"
+			return 0, message, err
+		}
+		// timestamp columns come back as UTC with the stored wall clock
+		bucket = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), 0, 0, 0, time.Local)
+		if buckets[bucket] == nil {
+			buckets[bucket] = map[int]int{}
+		}
+		buckets[bucket][productId] = units
+	}
+	if err := rows.Err(); err != nil {
+		message = "Failed to process sales data"
+		return 0, message, err
+	}
+
+	oldKeys, err := config.Rdb.Keys(ctx, salesBucketPrefix+"*").Result()
+	if err != nil {
+		message = "Failed to read sales buckets"
+		return 0, message, err
+	}
+
+	pipe := config.Rdb.TxPipeline()
+	if len(oldKeys) > 0 {
+		pipe.Del(ctx, oldKeys...)
+	}
+	for bucket, units := range buckets {
+		key := salesBucketKey(bucket)
+		for productId, amount := range units {
+			pipe.ZAdd(ctx, key, redis.Z{Score: float64(amount), Member: strconv.Itoa(productId)})
+		}
+		pipe.Expire(ctx, key, salesBucketExpiry(bucket))
+	}
+	pipe.Set(ctx, salesBuiltKey, time.Now().Format(time.RFC3339), 0)
+	clearRankingCache(ctx, pipe)
+	if _, err := pipe.Exec(ctx); err != nil {
+		message = "Failed to write sales buckets"
+		return 0, message, err
+	}
+
+	message = "Sales rankings rebuilt successfully"
+	return len(buckets), message, nil
+}
+
+// computeRanking unions the hourly buckets of the window. Best sellers weigh every unit the same,
+// trending halves the weight of a unit every halfLife hours.
+func computeRanking(ctx context.Context, kind string, window rankingWindow, dest string) error {
+	now := time.Now().Truncate(time.Hour)
+	keys := make([]string, 0, window.hours)
+	weights := make([]float64, 0, window.hours)
+	for age := 0; age < window.hours; age++ {
+		keys = append(keys, salesBucketKey(now.Add(-time.Duration(age)*time.Hour)))
+		weight := 1.0
+		if kind == RankingTrending {
+			weight = math.Pow(0.5, float64(age)/window.halfLife)
+		}
+		weights = append(weights, weight)
+	}
+
+	pipe := config.Rdb.TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys, Weights: weights, Aggregate: "SUM"})
+	pipe.Expire(ctx, dest, rankingCacheTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func GetRankedProducts(kind string, windowName string, limit int) ([]RankedProduct, string, error) {
+	ctx := context.Background()
+	ranked := []RankedProduct{}
+	message := ""
+
+	window, ok := RankingWindows[windowName]
+	if !ok {
+		message = "Invalid window. Allowed values: 24h, 7d, 30d"
+		return ranked, message, fmt.Errorf("unknown window %s", windowName)
+	}
+
+	// buckets are gone after a redis flush or on a fresh deploy
+	built, err := config.Rdb.Exists(ctx, salesBuiltKey).Result()
+	if err != nil {
+		message = "Failed to read sales rankings"
+		return ranked, message, err
+	}
+	if built == 0 {
+		if _, message, err := RebuildSalesRankings(ctx); err != nil {
+			return ranked, message, err
+		}
+	}
+
+	dest := "rank:" + kind + ":" + windowName
+	exists, err := config.Rdb.Exists(ctx, dest).Result()
+	if err != nil {
+		message = "Failed to read sales rankings"
+		return ranked, message, err
+	}
+	if exists == 0 {
+		if err := computeRanking(ctx, kind, window, dest); err != nil {
+			message = "Failed to compute sales rankings"
+			return ranked, message, err
+		}
+	}
+
+	// read extra in case some products are inactive now
+	scores, err := config.Rdb.ZRevRangeWithScores(ctx, dest, 0, int64(limit*2-1)).Result()
+	if err != nil {
+		message = "Failed to read sales rankings"
+		return ranked, message, err
+	}
+	if len(scores) == 0 {
+		message = "Success get ranked products"
+		return ranked, message, nil
+	}
+
+	productIds := []int{}
+	scoreById := map[int]float64{}
+	for _, z := range scores {
+		productId, err := strconv.Atoi(fmt.Sprint(z.Member))
+		if err != nil || z.Score <= 0 {
+			continue
+		}
+		productIds = append(productIds, productId)
+		scoreById[productId] = z.Score
+	}
+
+	rows, err := config.DB.Query(ctx,
+		`SELECT
+			p.id,
+			p.name,
+			p.slug,
+			p.description,
+			p.price,
+			`+productPriceColumns+`,
+			false AS is_favourite,
+			COALESCE(MAX(pi.product_image), '') AS product_image
+		FROM products p
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+		WHERE p.id = ANY($1::int[]) AND p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		GROUP BY p.id
+		ORDER BY ARRAY_POSITION($1::int[], p.id)
+		LIMIT $2`, productIds, limit)
+	if err != nil {
+		message = "Failed to fetch ranked products from database"
+		return ranked, message, err
+	}
+	defer rows.Close()
+
+	products, err := pgx.CollectRows(rows, pgx.RowToStructByName[PublicProductResponse])
+	if err != nil {
+		message = "Failed to process ranked products"
+		return ranked, message, err
+	}
+
+	for _, product := range products {
+		ranked = append(ranked, RankedProduct{
+			PublicProductResponse: product,
+			Score:                 math.Round(scoreById[product.Id]*1000) / 1000,
+		})
+	}
+
+	message = "Success get ranked products"
+	return ranked, message, nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// recommendationsPerProduct is how many co-purchased products are kept per product
+const recommendationsPerProduct = 10
+
+type RecommendationJobResult struct {
+	Products        int       `json:"products"`
+	Recommendations int       `json:"recommendations"`
+	Duration        string    `json:"duration"`
+	FinishedAt      time.Time `json:"finishedAt"`
+}
+
+// RebuildProductRecommendations recomputes "frequently bought together" from transaction_items.
+// Score is the cosine similarity of two products' order sets: co-orders / sqrt(orders a * orders b),
+// so best sellers do not end up recommended for everything.
+func RebuildProductRecommendations(userId *int) (RecommendationJobResult, string, error) {
+	result := RecommendationJobResult{}
+	message := ""
+	start := time.Now()
+	ctx := context.Background()
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return result, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM product_recommendations`)
+	if err != nil {
+		message = "Failed to clear product recommendations"
+		return result, message, err
+	}
+
+	commandTag, err := tx.Exec(ctx,
+		`WITH orders AS (
+			SELECT DISTINCT transaction_id, product_id FROM transaction_items
+		),
+		totals AS (
+			SELECT product_id, COUNT(*) AS total_orders FROM orders GROUP BY product_id
+		),
+		pairs AS (
+			SELECT a.product_id, b.product_id AS recommended_product_id, COUNT(*) AS co_purchase_count
+			FROM orders a
+			JOIN orders b ON b.transaction_id = a.transaction_id AND b.product_id <> a.product_id
+			GROUP BY a.product_id, b.product_id
+		),
+		ranked AS (
+			SELECT
+				pr.product_id,
+				pr.recommended_product_id,
+				pr.co_purchase_count,
+				pr.co_purchase_count / SQRT(ta.total_orders * tb.total_orders) AS score,
+				ROW_NUMBER() OVER (
+					PARTITION BY pr.product_id
+					ORDER BY pr.co_purchase_count / SQRT(ta.total_orders * tb.total_orders) DESC, pr.co_purchase_count DESC, pr.recommended_product_id ASC
+				) AS position
+			FROM pairs pr
+			JOIN totals ta ON ta.product_id = pr.product_id
+			JOIN totals tb ON tb.product_id = pr.recommended_product_id
+		)
+		INSERT INTO product_recommendations (product_id, recommended_product_id, co_purchase_count, score, created_by, updated_by)
+		SELECT product_id, recommended_product_id, co_purchase_count, score, $2, $2
+		FROM ranked
+		WHERE position <= $1`, recommendationsPerProduct, userId)
+	if err != nil {
+		message = "Failed to compute product recommendations"
+		return result, message, err
+	}
+	result.Recommendations = int(commandTag.RowsAffected())
+
+	err = tx.QueryRow(ctx, `SELECT COUNT(DISTINCT product_id) FROM product_recommendations`).Scan(&result.Products)
+	if err != nil {
+		message = "Failed to count product recommendations"
+		return result, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return result, message, err
+	}
+
+	result.FinishedAt = time.Now()
+	result.Duration = time.Since(start).String()
+	message = "Product recommendations rebuilt successfully"
+	return result, message, nil
+}
+
+// RunRecommendationJob rebuilds recommendations every interval, meant to run in its own goroutine
+func RunRecommendationJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, message, err := RebuildProductRecommendations(nil)
+		if err != nil {
+			log.Printf("Recommendation job: %s: %v", message, err)
+		} else {
+			log.Printf("Recommendation job: %d recommendations for %d products in %s", result.Recommendations, result.Products, result.Duration)
+			if err := InvalidateProductCache(context.Background()); err != nil {
+				log.Printf("Recommendation job: failed to invalidate cache: %v", err)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// getProductRecommendations returns co-purchased products first, then fills up with products
+// sharing the most categories for products that have no order history yet
+func getProductRecommendations(tx pgx.Tx, productId int, limit int) ([]PublicProductResponse, error) {
+	rows, err := tx.Query(context.Background(),
+		`WITH bought_together AS (
+			SELECT recommended_product_id AS id, score, 0 AS shared_categories
+			FROM product_recommendations
+			WHERE product_id = $1
+		),
+		same_category AS (
+			SELECT pc1.product_id AS id, 0::numeric AS score, COUNT(*) AS shared_categories
+			FROM product_categories pc1
+			JOIN product_categories pc2 ON pc2.category_id = pc1.category_id AND pc2.product_id = $1
+			WHERE pc1.product_id <> $1
+			AND pc1.product_id NOT IN (SELECT id FROM bought_together)
+			GROUP BY pc1.product_id
+		),
+		candidates AS (
+			SELECT id, score, shared_categories, 0 AS source FROM bought_together
+			UNION ALL
+			SELECT id, score, shared_categories, 1 AS source FROM same_category
+		)
+		SELECT
+			p.id,
+			p.name,
+			p.slug,
+			p.description,
+			p.price,
+			`+productPriceColumns+`,
+			false AS is_favourite,
+			COALESCE(MAX(pi.product_image), '') AS product_image
+		FROM candidates r
+		JOIN products p ON p.id = r.id
+		LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
+		LEFT JOIN active_flash_sale_products fs ON fs.product_id = p.id
+		WHERE p.is_active = true AND p.deleted_at IS NULL AND `+productPublishedCondition+`
+		GROUP BY p.id, r.source, r.score, r.shared_categories
+		ORDER BY r.source ASC, r.score DESC, r.shared_categories DESC, p.rating DESC NULLS LAST, p.id ASC
+		LIMIT $2`, productId, limit)
+	if err != nil {
+		return []PublicProductResponse{}, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[PublicProductResponse])
+}
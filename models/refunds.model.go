@@ -0,0 +1,358 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Refund struct {
+	Id            int          `json:"id" db:"id"`
+	TransactionId int          `json:"transactionId" db:"transaction_id"`
+	Reason        string       `json:"reason" db:"reason"`
+	RefundAmount  float64      `json:"refundAmount" db:"refund_amount"`
+	IsFullRefund  bool         `json:"isFullRefund" db:"is_full_refund"`
+	IsRestock     bool         `json:"isRestock" db:"is_restock"`
+	CreatedAt     time.Time    `json:"createdAt" db:"created_at"`
+	RefundItems   []RefundItem `json:"refundItems" db:"-"`
+}
+
+type RefundItem struct {
+	Id                int     `json:"id" db:"id"`
+	RefundId          int     `json:"refundId" db:"refund_id"`
+	TransactionItemId int     `json:"transactionItemId" db:"transaction_item_id"`
+	ProductName       string  `json:"productName" db:"product_name"`
+	Amount            int     `json:"amount" db:"amount"`
+	RefundAmount      float64 `json:"refundAmount" db:"refund_amount"`
+}
+
+type RefundRequest struct {
+	Reason  string              `json:"reason"`
+	Restock bool                `json:"restock"`
+	Items   []RefundItemRequest `json:"items"`
+}
+
+type RefundItemRequest struct {
+	TransactionItemId int `json:"transactionItemId"`
+	Amount            int `json:"amount"`
+}
+
+type refundableItem struct {
+	Id             int     `db:"id"`
+	ProductId      int     `db:"product_id"`
+	Amount         int     `db:"amount"`
+	Subtotal       float64 `db:"subtotal"`
+	RefundedAmount int     `db:"refunded_amount"`
+}
+
+func GetListRefunds(transactionId int) ([]Refund, string, error) {
+	refunds := []Refund{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			id,
+			transaction_id,
+			COALESCE(reason, '') AS reason,
+			refund_amount,
+			is_full_refund,
+			is_restock,
+			created_at
+		FROM refunds
+		WHERE transaction_id = $1
+		ORDER BY id ASC`, transactionId)
+	if err != nil {
+		message = "Failed to fetch refunds from database"
+		return refunds, message, err
+	}
+	defer rows.Close()
+
+	refunds, err = pgx.CollectRows(rows, pgx.RowToStructByName[Refund])
+	if err != nil {
+		message = "Failed to process refunds data"
+		return refunds, message, err
+	}
+
+	itemRows, err := config.DB.Query(context.Background(),
+		`SELECT
+			ri.id,
+			ri.refund_id,
+			ri.transaction_item_id,
+			ti.product_name,
+			ri.amount,
+			ri.refund_amount
+		FROM refund_items ri
+		JOIN refunds r ON r.id = ri.refund_id
+		JOIN transaction_items ti ON ti.id = ri.transaction_item_id
+		WHERE r.transaction_id = $1
+		ORDER BY ri.id ASC`, transactionId)
+	if err != nil {
+		message = "Failed to fetch refund items from database"
+		return refunds, message, err
+	}
+	defer itemRows.Close()
+
+	refundItems, err := pgx.CollectRows(itemRows, pgx.RowToStructByName[RefundItem])
+	if err != nil {
+		message = "Failed to process refund items data"
+		return refunds, message, err
+	}
+
+	for i := range refunds {
+		refunds[i].RefundItems = []RefundItem{}
+		for _, item := range refundItems {
+			if item.RefundId == refunds[i].Id {
+				refunds[i].RefundItems = append(refunds[i].RefundItems, item)
+			}
+		}
+	}
+
+	message = "Success get list refunds"
+	return refunds, message, nil
+}
+
+func MakeRefund(transactionId int, userId int, bodyRefund RefundRequest) (Refund, string, error) {
+	refund := Refund{}
+	message := ""
+	ctx := context.Background()
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return refund, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	// lock transaction so concurrent refunds cannot exceed the paid total
+	var totalTransaction, tax float64
+	err = tx.QueryRow(ctx,
+		`SELECT total_transaction, COALESCE(tax, 0) FROM transactions WHERE id = $1 FOR UPDATE`,
+		transactionId,
+	).Scan(&totalTransaction, &tax)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Transaction not found"
+			return refund, message, err
+		}
+		message = "Internal server error while fetching transaction"
+		return refund, message, err
+	}
+
+	var refundedTotal float64
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(SUM(refund_amount), 0) FROM refunds WHERE transaction_id = $1`,
+		transactionId,
+	).Scan(&refundedTotal)
+	if err != nil {
+		message = "Internal server error while calculating refunded total"
+		return refund, message, err
+	}
+
+	remainingTotal := totalTransaction - refundedTotal
+	if remainingTotal <= 0 {
+		message = "Transaction has already been fully refunded"
+		return refund, message, errors.New(message)
+	}
+
+	// get transaction items with quantity already refunded
+	rows, err := tx.Query(ctx,
+		`SELECT
+			ti.id,
+			ti.product_id,
+			ti.amount,
+			ti.subtotal,
+			COALESCE(SUM(ri.amount), 0)::int AS refunded_amount
+		FROM transaction_items ti
+		LEFT JOIN refund_items ri ON ri.transaction_item_id = ti.id
+		WHERE ti.transaction_id = $1
+		GROUP BY ti.id
+		ORDER BY ti.id ASC`, transactionId)
+	if err != nil {
+		message = "Failed to fetch transaction items from database"
+		return refund, message, err
+	}
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByName[refundableItem])
+	if err != nil {
+		message = "Failed to process transaction items data"
+		return refund, message, err
+	}
+
+	itemsById := map[int]refundableItem{}
+	var itemsSubtotal float64
+	for _, item := range items {
+		itemsById[item.Id] = item
+		itemsSubtotal += item.Subtotal
+	}
+
+	refund.IsFullRefund = len(bodyRefund.Items) == 0
+	requested := bodyRefund.Items
+	if refund.IsFullRefund {
+		requested = []RefundItemRequest{}
+		for _, item := range items {
+			if item.Amount-item.RefundedAmount > 0 {
+				requested = append(requested, RefundItemRequest{
+					TransactionItemId: item.Id,
+					Amount:            item.Amount - item.RefundedAmount,
+				})
+			}
+		}
+	}
+
+	// validate requested items and calculate refund per line
+	refund.RefundItems = []RefundItem{}
+	seen := map[int]bool{}
+	for _, req := range requested {
+		item, ok := itemsById[req.TransactionItemId]
+		if !ok {
+			message = "Transaction item does not belong to this transaction"
+			return refund, message, errors.New(message)
+		}
+		if seen[req.TransactionItemId] {
+			message = "Transaction item is listed more than once"
+			return refund, message, errors.New(message)
+		}
+		seen[req.TransactionItemId] = true
+
+		if req.Amount <= 0 {
+			message = "Refund amount must be greater than 0"
+			return refund, message, errors.New(message)
+		}
+		if req.Amount > item.Amount-item.RefundedAmount {
+			message = "Refund amount exceeds refundable quantity"
+			return refund, message, errors.New(message)
+		}
+
+		// line value plus its proportional share of tax
+		lineRefund := item.Subtotal / float64(item.Amount) * float64(req.Amount)
+		if itemsSubtotal > 0 {
+			lineRefund += tax * (lineRefund / itemsSubtotal)
+		}
+		lineRefund = math.Round(lineRefund*100) / 100
+
+		refund.RefundItems = append(refund.RefundItems, RefundItem{
+			TransactionItemId: item.Id,
+			Amount:            req.Amount,
+			RefundAmount:      lineRefund,
+		})
+		refund.RefundAmount += lineRefund
+	}
+
+	if refund.IsFullRefund {
+		// full refund returns everything still held, including fees
+		refund.RefundAmount = remainingTotal
+	}
+	refund.RefundAmount = math.Min(math.Round(refund.RefundAmount*100)/100, remainingTotal)
+
+	refund.TransactionId = transactionId
+	refund.Reason = bodyRefund.Reason
+	refund.IsRestock = bodyRefund.Restock
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO refunds (transaction_id, reason, refund_amount, is_full_refund, is_restock, created_by, updated_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
+		transactionId,
+		bodyRefund.Reason,
+		refund.RefundAmount,
+		refund.IsFullRefund,
+		bodyRefund.Restock,
+		userId,
+		userId,
+	).Scan(&refund.Id, &refund.CreatedAt)
+	if err != nil {
+		message = "Failed to insert refund"
+		return refund, message, err
+	}
+
+	for i, refundItem := range refund.RefundItems {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO refund_items (refund_id, transaction_item_id, amount, refund_amount, created_by, updated_by)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 RETURNING id`,
+			refund.Id,
+			refundItem.TransactionItemId,
+			refundItem.Amount,
+			refundItem.RefundAmount,
+			userId,
+			userId,
+		).Scan(&refund.RefundItems[i].Id)
+		if err != nil {
+			message = "Failed to insert refund item"
+			return refund, message, err
+		}
+		refund.RefundItems[i].RefundId = refund.Id
+
+		// return stock of refunded product, a bundle returns it to the components it was taken from,
+		// and stock taken from a SKU goes back to that SKU
+		if bodyRefund.Restock {
+			_, err = tx.Exec(ctx,
+				`UPDATE product_skus sk
+				SET stock = sk.stock + c.quantity * $1, updated_at = NOW()
+				FROM (
+					SELECT sku_id, SUM(quantity) AS quantity
+					FROM transaction_item_components
+					WHERE transaction_item_id = $2 AND sku_id IS NOT NULL
+					GROUP BY sku_id
+				) c
+				WHERE sk.id = c.sku_id`,
+				refundItem.Amount, refundItem.TransactionItemId,
+			)
+			if err != nil {
+				message = "Failed to restock bundle components"
+				return refund, message, err
+			}
+
+			_, err = tx.Exec(ctx,
+				`UPDATE products p
+				SET stock = p.stock + c.quantity * $1
+				FROM (
+					SELECT product_id, SUM(quantity) AS quantity
+					FROM transaction_item_components
+					WHERE transaction_item_id = $2 AND product_id IS NOT NULL AND sku_id IS NULL
+					GROUP BY product_id
+				) c
+				WHERE p.id = c.product_id`,
+				refundItem.Amount, refundItem.TransactionItemId,
+			)
+			if err != nil {
+				message = "Failed to restock bundle components"
+				return refund, message, err
+			}
+
+			_, err = tx.Exec(ctx,
+				`UPDATE product_skus SET stock = stock + $1, updated_at = NOW()
+				WHERE id = (SELECT sku_id FROM transaction_items WHERE id = $2)`,
+				refundItem.Amount, refundItem.TransactionItemId,
+			)
+			if err != nil {
+				message = "Failed to restock product"
+				return refund, message, err
+			}
+
+			_, err = tx.Exec(ctx,
+				`UPDATE products SET stock = stock + $1
+				WHERE id = $2
+				AND NOT EXISTS (SELECT 1 FROM transaction_item_components WHERE transaction_item_id = $3)
+				AND NOT EXISTS (SELECT 1 FROM transaction_items WHERE id = $3 AND sku_id IS NOT NULL)`,
+				refundItem.Amount, itemsById[refundItem.TransactionItemId].ProductId, refundItem.TransactionItemId,
+			)
+			if err != nil {
+				message = "Failed to restock product"
+				return refund, message, err
+			}
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return refund, message, err
+	}
+
+	message = "Refund created successfully"
+	return refund, message, nil
+}
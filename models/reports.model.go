@@ -0,0 +1,303 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ReportFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	GroupBy   string
+	Limit     int
+}
+
+type SalesSummary struct {
+	OrderCount        int     `json:"orderCount" db:"order_count"`
+	Revenue           float64 `json:"revenue" db:"revenue"`
+	Refunded          float64 `json:"refunded" db:"refunded"`
+	NetRevenue        float64 `json:"netRevenue" db:"net_revenue"`
+	AverageOrderValue float64 `json:"averageOrderValue" db:"average_order_value"`
+	ItemsSold         int     `json:"itemsSold" db:"items_sold"`
+}
+
+type SalesPeriod struct {
+	Period            time.Time `json:"period" db:"period"`
+	OrderCount        int       `json:"orderCount" db:"order_count"`
+	Revenue           float64   `json:"revenue" db:"revenue"`
+	Refunded          float64   `json:"refunded" db:"refunded"`
+	NetRevenue        float64   `json:"netRevenue" db:"net_revenue"`
+	AverageOrderValue float64   `json:"averageOrderValue" db:"average_order_value"`
+}
+
+type TopProduct struct {
+	ProductId    int     `json:"productId" db:"product_id"`
+	ProductName  string  `json:"productName" db:"product_name"`
+	QuantitySold int     `json:"quantitySold" db:"quantity_sold"`
+	OrderCount   int     `json:"orderCount" db:"order_count"`
+	Revenue      float64 `json:"revenue" db:"revenue"`
+	Refunded     float64 `json:"refunded" db:"refunded"`
+	NetRevenue   float64 `json:"netRevenue" db:"net_revenue"`
+}
+
+type TopCategory struct {
+	CategoryId   int     `json:"categoryId" db:"category_id"`
+	CategoryName string  `json:"categoryName" db:"category_name"`
+	QuantitySold int     `json:"quantitySold" db:"quantity_sold"`
+	Revenue      float64 `json:"revenue" db:"revenue"`
+	Refunded     float64 `json:"refunded" db:"refunded"`
+	NetRevenue   float64 `json:"netRevenue" db:"net_revenue"`
+}
+
+type MethodBreakdown struct {
+	Id         int     `json:"id" db:"id"`
+	Name       string  `json:"name" db:"name"`
+	OrderCount int     `json:"orderCount" db:"order_count"`
+	Revenue    float64 `json:"revenue" db:"revenue"`
+	Refunded   float64 `json:"refunded" db:"refunded"`
+	NetRevenue float64 `json:"netRevenue" db:"net_revenue"`
+}
+
+type SalesBreakdown struct {
+	OrderMethods   []MethodBreakdown `json:"orderMethods"`
+	PaymentMethods []MethodBreakdown `json:"paymentMethods"`
+}
+
+// refundedPerTransaction joins the refunded amount of each transaction t as r.total_refunded
+const refundedPerTransaction = `
+		LEFT JOIN (
+			SELECT transaction_id, SUM(refund_amount) AS total_refunded
+			FROM refunds
+			GROUP BY transaction_id
+		) r ON r.transaction_id = t.id`
+
+// refundedPerTransactionItem joins the refunded value of each transaction item ti as ri.total_refunded,
+// valued at the item price like its subtotal so it can be taken off product and category revenue
+const refundedPerTransactionItem = `
+		LEFT JOIN (
+			SELECT ri.transaction_item_id, SUM(ri.amount * ti.subtotal / ti.amount) AS total_refunded
+			FROM refund_items ri
+			JOIN transaction_items ti ON ti.id = ri.transaction_item_id
+			GROUP BY ri.transaction_item_id
+		) ri ON ri.transaction_item_id = ti.id`
+
+func GetSalesSummary(filter ReportFilter) (SalesSummary, string, error) {
+	summary := SalesSummary{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			COUNT(*)::int AS order_count,
+			COALESCE(SUM(t.total_transaction), 0) AS revenue,
+			COALESCE(SUM(r.total_refunded), 0) AS refunded,
+			COALESCE(SUM(t.total_transaction), 0) - COALESCE(SUM(r.total_refunded), 0) AS net_revenue,
+			COALESCE(AVG(t.total_transaction), 0) AS average_order_value,
+			COALESCE(SUM(ti.items_sold), 0)::int AS items_sold
+		FROM transactions t`+refundedPerTransaction+`
+		LEFT JOIN (
+			SELECT transaction_id, SUM(amount) AS items_sold
+			FROM transaction_items
+			GROUP BY transaction_id
+		) ti ON ti.transaction_id = t.id
+		WHERE t.date_transaction >= $1 AND t.date_transaction < $2`,
+		filter.StartDate, filter.EndDate)
+	if err != nil {
+		message = "Failed to fetch sales summary from database"
+		return summary, message, err
+	}
+	defer rows.Close()
+
+	summary, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[SalesSummary])
+	if err != nil {
+		message = "Failed to process sales summary data"
+		return summary, message, err
+	}
+
+	message = "Success get sales summary"
+	return summary, message, nil
+}
+
+func GetSalesByPeriod(filter ReportFilter) ([]SalesPeriod, string, error) {
+	periods := []SalesPeriod{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			DATE_TRUNC($3, t.date_transaction) AS period,
+			COUNT(*)::int AS order_count,
+			COALESCE(SUM(t.total_transaction), 0) AS revenue,
+			COALESCE(SUM(r.total_refunded), 0) AS refunded,
+			COALESCE(SUM(t.total_transaction), 0) - COALESCE(SUM(r.total_refunded), 0) AS net_revenue,
+			COALESCE(AVG(t.total_transaction), 0) AS average_order_value
+		FROM transactions t`+refundedPerTransaction+`
+		WHERE t.date_transaction >= $1 AND t.date_transaction < $2
+		GROUP BY period
+		ORDER BY period ASC`,
+		filter.StartDate, filter.EndDate, filter.GroupBy)
+	if err != nil {
+		message = "Failed to fetch sales per period from database"
+		return periods, message, err
+	}
+	defer rows.Close()
+
+	periods, err = pgx.CollectRows(rows, pgx.RowToStructByName[SalesPeriod])
+	if err != nil {
+		message = "Failed to process sales per period data"
+		return periods, message, err
+	}
+
+	message = "Success get sales per period"
+	return periods, message, nil
+}
+
+func GetTopProducts(filter ReportFilter) ([]TopProduct, string, error) {
+	products := []TopProduct{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			ti.product_id,
+			MAX(ti.product_name) AS product_name,
+			SUM(ti.amount)::int AS quantity_sold,
+			COUNT(DISTINCT ti.transaction_id)::int AS order_count,
+			COALESCE(SUM(ti.subtotal), 0) AS revenue,
+			COALESCE(SUM(ri.total_refunded), 0) AS refunded,
+			COALESCE(SUM(ti.subtotal), 0) - COALESCE(SUM(ri.total_refunded), 0) AS net_revenue
+		FROM transaction_items ti
+		JOIN transactions t ON t.id = ti.transaction_id`+refundedPerTransactionItem+`
+		WHERE t.date_transaction >= $1 AND t.date_transaction < $2
+		GROUP BY ti.product_id
+		ORDER BY quantity_sold DESC, net_revenue DESC
+		LIMIT $3`,
+		filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		message = "Failed to fetch top products from database"
+		return products, message, err
+	}
+	defer rows.Close()
+
+	products, err = pgx.CollectRows(rows, pgx.RowToStructByName[TopProduct])
+	if err != nil {
+		message = "Failed to process top products data"
+		return products, message, err
+	}
+
+	message = "Success get top products"
+	return products, message, nil
+}
+
+func GetTopCategories(filter ReportFilter) ([]TopCategory, string, error) {
+	categories := []TopCategory{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			c.id AS category_id,
+			c.name AS category_name,
+			SUM(ti.amount)::int AS quantity_sold,
+			COALESCE(SUM(ti.subtotal), 0) AS revenue,
+			COALESCE(SUM(ri.total_refunded), 0) AS refunded,
+			COALESCE(SUM(ti.subtotal), 0) - COALESCE(SUM(ri.total_refunded), 0) AS net_revenue
+		FROM transaction_items ti
+		JOIN transactions t ON t.id = ti.transaction_id`+refundedPerTransactionItem+`
+		JOIN product_categories pc ON pc.product_id = ti.product_id
+		JOIN categories c ON c.id = pc.category_id
+		WHERE t.date_transaction >= $1 AND t.date_transaction < $2
+		GROUP BY c.id, c.name
+		ORDER BY quantity_sold DESC, net_revenue DESC
+		LIMIT $3`,
+		filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		message = "Failed to fetch top categories from database"
+		return categories, message, err
+	}
+	defer rows.Close()
+
+	categories, err = pgx.CollectRows(rows, pgx.RowToStructByName[TopCategory])
+	if err != nil {
+		message = "Failed to process top categories data"
+		return categories, message, err
+	}
+
+	message = "Success get top categories"
+	return categories, message, nil
+}
+
+func GetSalesBreakdown(filter ReportFilter) (SalesBreakdown, string, error) {
+	breakdown := SalesBreakdown{}
+	message := ""
+
+	orderRows, err := config.DB.Query(context.Background(),
+		`SELECT
+			om.id,
+			om.name,
+			COUNT(t.id)::int AS order_count,
+			COALESCE(SUM(t.total_transaction), 0) AS revenue,
+			COALESCE(SUM(r.total_refunded), 0) AS refunded,
+			COALESCE(SUM(t.total_transaction), 0) - COALESCE(SUM(r.total_refunded), 0) AS net_revenue
+		FROM order_methods om
+		LEFT JOIN transactions t ON t.order_method_id = om.id
+			AND t.date_transaction >= $1 AND t.date_transaction < $2`+refundedPerTransaction+`
+		GROUP BY om.id, om.name
+		ORDER BY om.id ASC`,
+		filter.StartDate, filter.EndDate)
+	if err != nil {
+		message = "Failed to fetch order method breakdown from database"
+		return breakdown, message, err
+	}
+	breakdown.OrderMethods, err = pgx.CollectRows(orderRows, pgx.RowToStructByName[MethodBreakdown])
+	if err != nil {
+		message = "Failed to process order method breakdown data"
+		return breakdown, message, err
+	}
+
+	paymentRows, err := config.DB.Query(context.Background(),
+		`SELECT
+			pm.id,
+			pm.name,
+			COUNT(t.id)::int AS order_count,
+			COALESCE(SUM(t.total_transaction), 0) AS revenue,
+			COALESCE(SUM(r.total_refunded), 0) AS refunded,
+			COALESCE(SUM(t.total_transaction), 0) - COALESCE(SUM(r.total_refunded), 0) AS net_revenue
+		FROM payment_methods pm
+		LEFT JOIN transactions t ON t.payment_method_id = pm.id
+			AND t.date_transaction >= $1 AND t.date_transaction < $2`+refundedPerTransaction+`
+		GROUP BY pm.id, pm.name
+		ORDER BY pm.id ASC`,
+		filter.StartDate, filter.EndDate)
+	if err != nil {
+		message = "Failed to fetch payment method breakdown from database"
+		return breakdown, message, err
+	}
+	breakdown.PaymentMethods, err = pgx.CollectRows(paymentRows, pgx.RowToStructByName[MethodBreakdown])
+	if err != nil {
+		message = "Failed to process payment method breakdown data"
+		return breakdown, message, err
+	}
+
+	message = "Success get sales breakdown"
+	return breakdown, message, nil
+}
+
+func InvalidateReportCache(ctx context.Context) error {
+	keys, err := config.Rdb.Keys(ctx, "/admin/reports*").Result()
+	if err != nil {
+		log.Printf("Failed to get keys for pattern /admin/reports*: %v", err)
+		return err
+	}
+
+	if len(keys) > 0 {
+		err = config.Rdb.Del(ctx, keys...).Err()
+		if err != nil {
+			log.Printf("Failed to delete report cache keys: %v", err)
+			return err
+		}
+		log.Printf("Invalidated %d report cache keys", len(keys))
+	}
+
+	return nil
+}
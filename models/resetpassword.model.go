@@ -37,7 +37,7 @@ func GetUserIdByEmail(email string) (int, string, error) {
 
 	err := config.DB.QueryRow(
 		context.Background(),
-		"SELECT id FROM users WHERE email = $1",
+		"SELECT id FROM users WHERE email = $1 AND deleted_at IS NULL",
 		email,
 	).Scan(&userId)
 
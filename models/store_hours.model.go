@@ -0,0 +1,492 @@
+package models
+
+import (
+	"backend-daily-greens/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// storeLookaheadDays is how far ahead the next opening is searched
+const storeLookaheadDays = 14
+
+// StoreHour is the opening window of a weekday in the store timezone, weekday 0 is Sunday
+type StoreHour struct {
+	Weekday   int     `json:"weekday" db:"weekday"`
+	OpenTime  *string `json:"openTime" db:"open_time"`
+	CloseTime *string `json:"closeTime" db:"close_time"`
+	IsClosed  bool    `json:"isClosed" db:"is_closed"`
+}
+
+// StoreHourRequest sets the hours of a weekday, a close time before the open time runs past midnight
+type StoreHourRequest struct {
+	Weekday   int     `json:"weekday" example:"1"`
+	OpenTime  *string `json:"openTime" example:"07:00"`
+	CloseTime *string `json:"closeTime" example:"21:00"`
+	IsClosed  bool    `json:"isClosed"`
+}
+
+// StoreHoursRequest replaces the weekly hours, a weekday left out is open all day
+type StoreHoursRequest struct {
+	Hours []StoreHourRequest `json:"hours"`
+}
+
+// StoreHoliday replaces the weekly hours of its date
+type StoreHoliday struct {
+	Id        int     `json:"id" db:"id"`
+	Date      string  `json:"date" db:"date"`
+	Name      string  `json:"name" db:"name"`
+	OpenTime  *string `json:"openTime" db:"open_time"`
+	CloseTime *string `json:"closeTime" db:"close_time"`
+	IsClosed  bool    `json:"isClosed" db:"is_closed"`
+}
+
+// StoreHolidayRequest creates or updates a holiday, on update only the fields sent are changed
+type StoreHolidayRequest struct {
+	Date      *string `json:"date" example:"2026-12-25"`
+	Name      *string `json:"name" example:"Christmas"`
+	OpenTime  *string `json:"openTime" example:"10:00"`
+	CloseTime *string `json:"closeTime" example:"15:00"`
+	IsClosed  *bool   `json:"isClosed"`
+}
+
+// StoreStatus tells whether the store takes orders right now, opensAt is the next opening when closed
+type StoreStatus struct {
+	IsOpen       bool       `json:"isOpen"`
+	OpensAt      *time.Time `json:"opensAt"`
+	ClosesAt     *time.Time `json:"closesAt"`
+	Timezone     string     `json:"timezone"`
+	ClosedPolicy string     `json:"closedPolicy"`
+}
+
+const storeHolidaySelect = `
+		SELECT
+			id,
+			TO_CHAR(date, 'YYYY-MM-DD') AS date,
+			name,
+			TO_CHAR(open_time, 'HH24:MI') AS open_time,
+			TO_CHAR(close_time, 'HH24:MI') AS close_time,
+			is_closed
+		FROM store_holidays`
+
+// validateStoreWindow checks the hours of a day and returns them normalized to HH:MM
+func validateStoreWindow(isClosed bool, openTime *string, closeTime *string) (*string, *string, string, error) {
+	if isClosed {
+		return nil, nil, "", nil
+	}
+	if openTime == nil || closeTime == nil {
+		return nil, nil, "Open and close time are required unless closed", errors.New("incomplete opening hours")
+	}
+
+	openClock, err := parseAvailableHour(openTime)
+	if err != nil {
+		return nil, nil, "Opening hours must be in HH:MM format", err
+	}
+	closeClock, err := parseAvailableHour(closeTime)
+	if err != nil {
+		return nil, nil, "Opening hours must be in HH:MM format", err
+	}
+	if *openClock == *closeClock {
+		return nil, nil, "Open and close time cannot be the same", errors.New("empty opening hours")
+	}
+	return openClock, closeClock, "", nil
+}
+
+func GetStoreHours() ([]StoreHour, string, error) {
+	hours := []StoreHour{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT
+			weekday,
+			TO_CHAR(open_time, 'HH24:MI') AS open_time,
+			TO_CHAR(close_time, 'HH24:MI') AS close_time,
+			is_closed
+		FROM store_hours
+		ORDER BY weekday ASC`)
+	if err != nil {
+		message = "Failed to fetch store hours from database"
+		return hours, message, err
+	}
+	defer rows.Close()
+
+	hours, err = pgx.CollectRows(rows, pgx.RowToStructByName[StoreHour])
+	if err != nil {
+		message = "Failed to process store hours data"
+		return hours, message, err
+	}
+
+	message = "Success get store hours"
+	return hours, message, nil
+}
+
+// ReplaceStoreHours swaps the weekly hours
+func ReplaceStoreHours(userId int, hours []StoreHourRequest) (string, error) {
+	ctx := context.Background()
+
+	seen := map[int]bool{}
+	openTimes := make([]*string, len(hours))
+	closeTimes := make([]*string, len(hours))
+	for i, hour := range hours {
+		if hour.Weekday < 0 || hour.Weekday > 6 {
+			return "Weekday must be between 0 and 6", fmt.Errorf("invalid weekday %d", hour.Weekday)
+		}
+		if seen[hour.Weekday] {
+			return "Weekday is listed more than once", fmt.Errorf("duplicate weekday %d", hour.Weekday)
+		}
+		seen[hour.Weekday] = true
+
+		openClock, closeClock, message, err := validateStoreWindow(hour.IsClosed, hour.OpenTime, hour.CloseTime)
+		if err != nil {
+			return message, err
+		}
+		openTimes[i], closeTimes[i] = openClock, closeClock
+	}
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return "Failed to start database transaction", err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM store_hours`)
+	if err != nil {
+		return "Internal server error while deleting old store hours", err
+	}
+
+	for i, hour := range hours {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO store_hours (weekday, open_time, close_time, is_closed, created_by, updated_by)
+			VALUES ($1, $2::time, $3::time, $4, $5, $5)`,
+			hour.Weekday, openTimes[i], closeTimes[i], hour.IsClosed, userId)
+		if err != nil {
+			return "Internal server error while saving store hours", err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "Failed to commit transaction", err
+	}
+
+	return "Store hours updated successfully", nil
+}
+
+func GetListStoreHolidays() ([]StoreHoliday, string, error) {
+	holidays := []StoreHoliday{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), storeHolidaySelect+` ORDER BY date ASC`)
+	if err != nil {
+		message = "Failed to fetch store holidays from database"
+		return holidays, message, err
+	}
+	defer rows.Close()
+
+	holidays, err = pgx.CollectRows(rows, pgx.RowToStructByName[StoreHoliday])
+	if err != nil {
+		message = "Failed to process store holiday data"
+		return holidays, message, err
+	}
+
+	message = "Success get store holidays"
+	return holidays, message, nil
+}
+
+func GetStoreHolidayById(id int) (StoreHoliday, string, error) {
+	holiday := StoreHoliday{}
+	message := ""
+
+	rows, err := config.DB.Query(context.Background(), storeHolidaySelect+` WHERE id = $1`, id)
+	if err != nil {
+		message = "Failed to fetch store holiday from database"
+		return holiday, message, err
+	}
+	defer rows.Close()
+
+	holiday, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[StoreHoliday])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Store holiday not found"
+			return holiday, message, err
+		}
+		message = "Failed to process store holiday data"
+		return holiday, message, err
+	}
+
+	message = "Success get store holiday"
+	return holiday, message, nil
+}
+
+// validateStoreHoliday checks a holiday as it will be saved, it is closed all day unless isClosed is false
+func validateStoreHoliday(ctx context.Context, tx pgx.Tx, holidayId int, holiday StoreHolidayRequest) (StoreHolidayRequest, string, error) {
+	if holiday.Date == nil {
+		return holiday, "Date and name are required", errors.New("missing holiday date")
+	}
+	date, err := time.Parse("2006-01-02", *holiday.Date)
+	if err != nil {
+		return holiday, "Date must be in YYYY-MM-DD format", err
+	}
+	formatted := date.Format("2006-01-02")
+	holiday.Date = &formatted
+
+	if holiday.Name == nil || strings.TrimSpace(*holiday.Name) == "" {
+		return holiday, "Date and name are required", errors.New("missing holiday name")
+	}
+	name := strings.TrimSpace(*holiday.Name)
+	holiday.Name = &name
+
+	isClosed := holiday.IsClosed == nil || *holiday.IsClosed
+	holiday.IsClosed = &isClosed
+	openClock, closeClock, message, err := validateStoreWindow(isClosed, holiday.OpenTime, holiday.CloseTime)
+	if err != nil {
+		return holiday, message, err
+	}
+	holiday.OpenTime, holiday.CloseTime = openClock, closeClock
+
+	var dateIsUsed bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM store_holidays WHERE date = $1::date AND id <> $2)`,
+		formatted, holidayId).Scan(&dateIsUsed)
+	if err != nil {
+		return holiday, "Internal server error while checking store holiday", err
+	}
+	if dateIsUsed {
+		return holiday, "Store holiday already exists for this date", errors.New("duplicate holiday date " + formatted)
+	}
+
+	return holiday, "", nil
+}
+
+func InsertStoreHoliday(userId int, bodyCreate StoreHolidayRequest) (int, string, error) {
+	ctx := context.Background()
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return 0, "Failed to start database transaction", err
+	}
+	defer tx.Rollback(ctx)
+
+	holiday, message, err := validateStoreHoliday(ctx, tx, 0, bodyCreate)
+	if err != nil {
+		return 0, message, err
+	}
+
+	var holidayId int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO store_holidays (date, name, open_time, close_time, is_closed, created_by, updated_by)
+		VALUES ($1::date, $2, $3::time, $4::time, $5, $6, $6)
+		RETURNING id`,
+		holiday.Date, holiday.Name, holiday.OpenTime, holiday.CloseTime, holiday.IsClosed, userId).Scan(&holidayId)
+	if err != nil {
+		return 0, "Internal server error while creating store holiday", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, "Failed to commit transaction", err
+	}
+
+	return holidayId, "Store holiday created successfully", nil
+}
+
+func UpdateStoreHoliday(holidayId int, userId int, bodyUpdate StoreHolidayRequest) (bool, string, error) {
+	ctx := context.Background()
+	isSuccess := false
+	message := ""
+
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return isSuccess, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, storeHolidaySelect+` WHERE id = $1 FOR UPDATE`, holidayId)
+	if err != nil {
+		message = "Internal server error while checking store holiday"
+		return isSuccess, message, err
+	}
+	current, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[StoreHoliday])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			message = "Store holiday not found"
+			return isSuccess, message, nil
+		}
+		message = "Internal server error while checking store holiday"
+		return isSuccess, message, err
+	}
+
+	// fields that are not sent keep their saved value
+	if bodyUpdate.Date == nil {
+		bodyUpdate.Date = &current.Date
+	}
+	if bodyUpdate.Name == nil {
+		bodyUpdate.Name = &current.Name
+	}
+	if bodyUpdate.IsClosed == nil {
+		bodyUpdate.IsClosed = &current.IsClosed
+	}
+	if bodyUpdate.OpenTime == nil {
+		bodyUpdate.OpenTime = current.OpenTime
+	}
+	if bodyUpdate.CloseTime == nil {
+		bodyUpdate.CloseTime = current.CloseTime
+	}
+
+	holiday, message, err := validateStoreHoliday(ctx, tx, holidayId, bodyUpdate)
+	if err != nil {
+		return isSuccess, message, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE store_holidays
+		SET date = $1::date,
+			name = $2,
+			open_time = $3::time,
+			close_time = $4::time,
+			is_closed = $5,
+			updated_by = $6,
+			updated_at = NOW()
+		WHERE id = $7`,
+		holiday.Date, holiday.Name, holiday.OpenTime, holiday.CloseTime, holiday.IsClosed, userId, holidayId)
+	if err != nil {
+		message = "Internal server error while updating store holiday"
+		return isSuccess, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return isSuccess, message, err
+	}
+
+	isSuccess = true
+	message = "Store holiday updated successfully"
+	return isSuccess, message, nil
+}
+
+func DeleteStoreHoliday(holidayId int) (bool, string, error) {
+	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM store_holidays WHERE id = $1`, holidayId)
+	if err != nil {
+		return false, "Internal server error while deleting store holiday", err
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return false, "Store holiday not found", nil
+	}
+
+	return true, "Store holiday deleted successfully", nil
+}
+
+// storeSchedule holds the weekly hours and the holidays of the days a status is computed for
+type storeSchedule struct {
+	hours    map[time.Weekday]StoreHour
+	holidays map[string]StoreHoliday
+}
+
+func getStoreSchedule(ctx context.Context, from time.Time, to time.Time) (storeSchedule, error) {
+	schedule := storeSchedule{
+		hours:    map[time.Weekday]StoreHour{},
+		holidays: map[string]StoreHoliday{},
+	}
+
+	hours, _, err := GetStoreHours()
+	if err != nil {
+		return schedule, err
+	}
+	for _, hour := range hours {
+		schedule.hours[time.Weekday(hour.Weekday)] = hour
+	}
+
+	rows, err := config.DB.Query(ctx,
+		storeHolidaySelect+` WHERE date BETWEEN $1::date AND $2::date`,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return schedule, err
+	}
+	defer rows.Close()
+
+	holidays, err := pgx.CollectRows(rows, pgx.RowToStructByName[StoreHoliday])
+	if err != nil {
+		return schedule, err
+	}
+	for _, holiday := range holidays {
+		schedule.holidays[holiday.Date] = holiday
+	}
+
+	return schedule, nil
+}
+
+// window is the opening window that starts on day, false when the store is closed that day.
+// A holiday replaces the weekday hours, a day without hours is open all day.
+func (schedule storeSchedule) window(day time.Time) (time.Time, time.Time, bool) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, config.StoreLocation)
+
+	var openTime, closeTime *string
+	if holiday, ok := schedule.holidays[start.Format("2006-01-02")]; ok {
+		if holiday.IsClosed {
+			return start, start, false
+		}
+		openTime, closeTime = holiday.OpenTime, holiday.CloseTime
+	} else if hour, ok := schedule.hours[start.Weekday()]; ok {
+		if hour.IsClosed {
+			return start, start, false
+		}
+		openTime, closeTime = hour.OpenTime, hour.CloseTime
+	} else {
+		return start, start.AddDate(0, 0, 1), true
+	}
+
+	opensAt := storeClockOn(start, *openTime)
+	closesAt := storeClockOn(start, *closeTime)
+	if !closesAt.After(opensAt) {
+		closesAt = closesAt.AddDate(0, 0, 1)
+	}
+	return opensAt, closesAt, true
+}
+
+// storeClockOn is the HH:MM clock on the date of day in the store timezone
+func storeClockOn(day time.Time, clock string) time.Time {
+	parsed, _ := time.Parse("15:04", clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, config.StoreLocation)
+}
+
+// GetStoreStatus tells whether the store is open now, and when it closes or opens next
+func GetStoreStatus() (StoreStatus, string, error) {
+	now := time.Now().In(config.StoreLocation)
+	status := StoreStatus{
+		Timezone:     config.StoreLocation.String(),
+		ClosedPolicy: config.StoreClosedPolicy,
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.StoreLocation)
+	schedule, err := getStoreSchedule(context.Background(), today.AddDate(0, 0, -1), today.AddDate(0, 0, storeLookaheadDays))
+	if err != nil {
+		return status, "Failed to fetch store hours from database", err
+	}
+
+	// a window of yesterday can still be running past midnight
+	for offset := -1; offset <= 0; offset++ {
+		opensAt, closesAt, isOpenDay := schedule.window(today.AddDate(0, 0, offset))
+		if isOpenDay && !now.Before(opensAt) && now.Before(closesAt) {
+			status.IsOpen = true
+			status.ClosesAt = &closesAt
+			return status, "Store is open", nil
+		}
+	}
+
+	for offset := 0; offset <= storeLookaheadDays; offset++ {
+		opensAt, _, isOpenDay := schedule.window(today.AddDate(0, 0, offset))
+		if isOpenDay && opensAt.After(now) {
+			status.OpensAt = &opensAt
+			break
+		}
+	}
+
+	return status, "Store is closed", nil
+}
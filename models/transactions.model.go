@@ -2,8 +2,10 @@ package models
 
 import (
 	"backend-daily-greens/config"
+	"backend-daily-greens/utils"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -22,49 +24,62 @@ type TransactionDetail struct {
 	UserId           int                `json:"userId" db:"user_id"`
 	NoInvoice        string             `json:"noInvoice" db:"no_invoice"`
 	DateTransaction  time.Time          `json:"dateOrder" db:"date_transaction"`
+	ScheduledAt      *time.Time         `json:"scheduledAt" db:"scheduled_at"`
 	FullName         string             `json:"fullName" db:"full_name"`
 	Email            string             `json:"email" db:"email"`
 	Address          string             `json:"address" db:"address"`
 	Phone            string             `json:"phone" db:"phone"`
 	PaymentMethod    string             `json:"payment_method" db:"payment_method"`
 	OrderMethod      string             `json:"orderMethod" db:"order_method"`
+	DeliveryZone     *string            `json:"deliveryZone" db:"delivery_zone"`
 	Status           string             `json:"status" db:"status"`
 	DeliveryFee      float64            `json:"delivery_fee" db:"delivery_fee"`
 	AdminFee         float64            `json:"adminFee" db:"admin_fee"`
 	Tax              float64            `json:"tax" db:"tax"`
 	TotalTransaction float64            `json:"totalTransaction" db:"total_transaction"`
+	TotalRefunded    float64            `json:"totalRefunded" db:"total_refunded"`
+	NetTotal         float64            `json:"netTotal" db:"net_total"`
 	TransactionItems []TransactionItems `json:"transactionItems" db:"-"`
 }
 
 type TransactionItems struct {
-	Id              int     `json:"id" db:"id"`
-	TransactionId   int     `json:"transactionId" db:"transaction_id"`
-	ProductId       int     `json:"product_id" db:"product_id"`
-	ProductName     string  `json:"product_name" db:"product_name"`
-	ProductPrice    float64 `json:"product_price" db:"product_price"`
-	DiscountPercent float64 `json:"discount_percent" db:"discount_percent"`
-	DiscountPrice   float64 `json:"discount_price" db:"discount_price"`
-	Size            string  `json:"size" db:"size"`
-	SizeCost        float64 `json:"sizeCost" db:"size_cost"`
-	Variant         string  `json:"variant" db:"variant"`
-	VariantCost     float64 `json:"variantCost" db:"variant_cost"`
-	Amount          int     `json:"amount" db:"amount"`
-	Subtotal        float64 `json:"subtotal" db:"subtotal"`
+	Id              int                `json:"id" db:"id"`
+	TransactionId   int                `json:"transactionId" db:"transaction_id"`
+	ProductId       int                `json:"product_id" db:"product_id"`
+	ProductName     string             `json:"product_name" db:"product_name"`
+	ProductPrice    float64            `json:"product_price" db:"product_price"`
+	DiscountPercent float64            `json:"discount_percent" db:"discount_percent"`
+	DiscountPrice   float64            `json:"discount_price" db:"discount_price"`
+	Size            string             `json:"size" db:"size"`
+	SizeCost        float64            `json:"sizeCost" db:"size_cost"`
+	Variant         string             `json:"variant" db:"variant"`
+	VariantCost     float64            `json:"variantCost" db:"variant_cost"`
+	ModifiersCost   float64            `json:"modifiersCost" db:"modifiers_cost"`
+	Modifiers       []SelectedModifier `json:"modifiers" db:"modifiers"`
+	Components      []BundleComponent  `json:"components" db:"components"`
+	Amount          int                `json:"amount" db:"amount"`
+	Subtotal        float64            `json:"subtotal" db:"subtotal"`
+	RefundedAmount  int                `json:"refundedAmount" db:"refunded_amount"`
+	RefundedTotal   float64            `json:"refundedTotal" db:"refunded_total"`
 }
 
 type TransactionRequest struct {
-	NoInvoice        string    `json:"-" swaggerignore:"true"`
-	DateTransaction  time.Time `json:"-" swaggerignore:"true"`
-	FullName         string    `json:"fullName"`
-	Email            string    `json:"email"`
-	Address          string    `json:"address"`
-	Phone            string    `json:"phone"`
-	PaymentMethodId  int       `json:"paymentMethodId" binding:"required"`
-	OrderMethodId    int       `json:"orderMethodId" binding:"required"`
-	DeliveryFee      float64   `json:"-" swaggerignore:"true"`
-	AdminFee         float64   `json:"-" swaggerignore:"true"`
-	Tax              float64   `json:"-" swaggerignore:"true"`
-	TotalTransaction float64   `json:"-" swaggerignore:"true"`
+	NoInvoice        string     `json:"-" swaggerignore:"true"`
+	DateTransaction  time.Time  `json:"-" swaggerignore:"true"`
+	FullName         string     `json:"fullName"`
+	Email            string     `json:"email"`
+	Address          string     `json:"address"`
+	Phone            string     `json:"phone"`
+	PaymentMethodId  int        `json:"paymentMethodId" binding:"required"`
+	OrderMethodId    int        `json:"orderMethodId" binding:"required"`
+	DeliveryFee      float64    `json:"-" swaggerignore:"true"`
+	AdminFee         float64    `json:"-" swaggerignore:"true"`
+	Tax              float64    `json:"-" swaggerignore:"true"`
+	TotalTransaction float64    `json:"-" swaggerignore:"true"`
+	ScheduledAt      *time.Time `json:"scheduledAt"`
+	Latitude         *float64   `json:"latitude" example:"-6.2"`
+	Longitude        *float64   `json:"longitude" example:"106.816666"`
+	DeliveryZoneId   *int       `json:"-" swaggerignore:"true"`
 }
 
 func GetTotalDataTransactions(search string) (int, error) {
@@ -136,6 +151,71 @@ func GetListAllTransactions(page int, limit int, search string) ([]Transaction,
 	return transactions, message, nil
 }
 
+// GetListTransactionsCursor lists transactions newest first with keyset pagination on (date_transaction, id)
+func GetListTransactionsCursor(encodedCursor string, limit int, search string) ([]Transaction, utils.CursorPage, string, error) {
+	type transactionRow struct {
+		Transaction
+		SortKey string `db:"sort_key"`
+	}
+	transactions := []Transaction{}
+	message := ""
+
+	cursor, err := utils.DecodeCursor(encodedCursor, "date_desc")
+	if err != nil {
+		message = "Invalid cursor"
+		return transactions, utils.CursorPage{}, message, err
+	}
+
+	query := `SELECT 
+				t.id,
+				t.no_invoice,
+				t.date_transaction,
+				s.name AS status,
+				t.total_transaction,
+				t.date_transaction::text AS sort_key
+			FROM transactions t
+			JOIN status s ON t.status_id = s.id
+			WHERE true`
+	args := []any{}
+
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		query += fmt.Sprintf(` AND t.no_invoice ILIKE $%d`, len(args))
+	}
+
+	condition, orderBy := utils.KeysetCondition("t.date_transaction", "t.id", "timestamp", true, cursor, len(args)+1)
+	if cursor != nil {
+		query += ` AND ` + condition
+		args = append(args, cursor.Value, cursor.Id)
+	}
+
+	args = append(args, limit+1)
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, orderBy, len(args))
+
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		message = "Failed to fetch transactions from database"
+		return transactions, utils.CursorPage{}, message, err
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByName[transactionRow])
+	if err != nil {
+		message = "Failed to process transaction data from database"
+		return transactions, utils.CursorPage{}, message, err
+	}
+
+	result, cursors := utils.KeysetPage(result, limit, cursor, "date_desc", func(row transactionRow) (string, int) {
+		return row.SortKey, row.Id
+	})
+	for _, row := range result {
+		transactions = append(transactions, row.Transaction)
+	}
+
+	message = "Success get all transaction"
+	return transactions, cursors, message, nil
+}
+
 func GetDetailTransaction(id int) (TransactionDetail, string, error) {
 	transaction := TransactionDetail{}
 	message := ""
@@ -146,25 +226,36 @@ func GetDetailTransaction(id int) (TransactionDetail, string, error) {
 			t.user_id,
 			t.no_invoice,
 			t.date_transaction,
+			t.scheduled_at,
 			t.full_name,
 			t.email,
 			t.address,
 			t.phone,
 			pm.name AS payment_method,
 			om.name AS order_method,
+			dz.name AS delivery_zone,
 			s.name AS status,
 			t.delivery_fee,
 			t.admin_fee,
 			t.tax,
-			t.total_transaction
+			t.total_transaction,
+			COALESCE(r.total_refunded, 0) AS total_refunded,
+			t.total_transaction - COALESCE(r.total_refunded, 0) AS net_total
 		FROM 
 			transactions t
 		JOIN 
 			payment_methods pm ON t.payment_method_id = pm.id
 		JOIN
 			order_methods om ON t.order_method_id = om.id
+		LEFT JOIN
+			delivery_zones dz ON t.delivery_zone_id = dz.id
 		JOIN 
 			status s ON t.status_id = s.id
+		LEFT JOIN (
+			SELECT transaction_id, SUM(refund_amount) AS total_refunded
+			FROM refunds
+			GROUP BY transaction_id
+		) r ON r.transaction_id = t.id
 		WHERE t.id = $1`, id)
 	if err != nil {
 		message = "Failed to fetch transaction from database"
@@ -186,28 +277,55 @@ func GetDetailTransaction(id int) (TransactionDetail, string, error) {
 	return transaction, message, nil
 }
 
+// transactionItemModifiersColumn lists the modifiers copied onto the ordered product ti
+const transactionItemModifiersColumn = `
+			COALESCE(
+				(SELECT JSON_AGG(
+					JSON_BUILD_OBJECT('modifierOptionId', tim.modifier_option_id, 'groupName', tim.group_name, 'optionName', tim.option_name, 'price', tim.price)
+					ORDER BY tim.id
+				) FROM transaction_item_modifiers tim WHERE tim.transaction_item_id = ti.id),
+				'[]'
+			) AS modifiers`
+
+// transactionItemComponentsColumn lists the components copied onto the ordered bundle ti
+const transactionItemComponentsColumn = `
+			COALESCE(
+				(SELECT JSON_AGG(
+					JSON_BUILD_OBJECT('productId', tic.product_id, 'productName', tic.product_name, 'size', COALESCE(tic.size, ''), 'variant', COALESCE(tic.variant, ''), 'quantity', tic.quantity)
+					ORDER BY tic.id
+				) FROM transaction_item_components tic WHERE tic.transaction_item_id = ti.id),
+				'[]'
+			) AS components`
+
 func GetTransactionItems(transactionId int) ([]TransactionItems, string, error) {
 	transactionItems := []TransactionItems{}
 	message := ""
 
 	productRows, err := config.DB.Query(context.Background(),
 		`SELECT 
-			id,
-			transaction_id,
-			product_id,
-			product_name,
-			product_price,
-			discount_percent,
-			discount_price,
-			size,
-			size_cost,
-			variant,
-			variant_cost,
-			amount,
-			subtotal
-		FROM transaction_items
-		WHERE transaction_id = $1
-		ORDER BY id ASC`, transactionId)
+			ti.id,
+			ti.transaction_id,
+			ti.product_id,
+			ti.product_name,
+			ti.product_price,
+			ti.discount_percent,
+			ti.discount_price,
+			ti.size,
+			ti.size_cost,
+			ti.variant,
+			ti.variant_cost,
+			ti.modifiers_cost,
+			`+transactionItemModifiersColumn+`,
+			`+transactionItemComponentsColumn+`,
+			ti.amount,
+			ti.subtotal,
+			COALESCE(SUM(ri.amount), 0)::int AS refunded_amount,
+			COALESCE(SUM(ri.refund_amount), 0) AS refunded_total
+		FROM transaction_items ti
+		LEFT JOIN refund_items ri ON ri.transaction_item_id = ti.id
+		WHERE ti.transaction_id = $1
+		GROUP BY ti.id
+		ORDER BY ti.id ASC`, transactionId)
 	if err != nil {
 		message = "Failed to fetch ordered products from database"
 		return transactionItems, message, err
@@ -238,29 +356,39 @@ func CheckTransactionExists(id int) (bool, error) {
 	return exists, nil
 }
 
-func UpdateTransactionStatusById(transactionId int, statusId string, userId int) (bool, string, error) {
-	isSuccess := false
+// TransactionStatusChange is the status of a transaction before and after a status update
+type TransactionStatusChange struct {
+	PreviousStatus  string
+	Status          string
+	DateTransaction time.Time
+}
+
+func UpdateTransactionStatusById(transactionId int, statusId string, userId int) (TransactionStatusChange, string, error) {
+	change := TransactionStatusChange{}
 	message := ""
 
-	_, err := config.DB.Exec(
+	// the joined row is read before the update, so it still has the previous status
+	err := config.DB.QueryRow(
 		context.Background(),
-		`UPDATE transactions 
+		`UPDATE transactions t
 		 SET status_id  = $1,
 		     updated_by = $2,
 		     updated_at = NOW()
-		 WHERE id = $3`,
+		 FROM transactions previous
+		 LEFT JOIN status ps ON ps.id = previous.status_id
+		 WHERE t.id = $3 AND previous.id = t.id
+		 RETURNING COALESCE(ps.name, ''), (SELECT s.name FROM status s WHERE s.id = t.status_id), t.date_transaction`,
 		statusId,
 		userId,
 		transactionId,
-	)
+	).Scan(&change.PreviousStatus, &change.Status, &change.DateTransaction)
 	if err != nil {
 		message = "Internal server error while updating transaction status"
-		return isSuccess, message, err
+		return change, message, err
 	}
 
-	isSuccess = true
 	message = "Transaction status updated successfully"
-	return isSuccess, message, nil
+	return change, message, nil
 }
 
 func GetDeliveryFeeAndAdminFee(orderMethodId int, paymentMethodId int) (float64, float64, string, error) {
@@ -301,6 +429,13 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 	}
 	defer tx.Rollback(ctx)
 
+	// a scheduled order takes one order of its slot
+	if bodyCheckout.ScheduledAt != nil {
+		if message, err := reserveOrderSlot(ctx, tx, bodyCheckout.OrderMethodId, *bodyCheckout.ScheduledAt); err != nil {
+			return 0, message, err
+		}
+	}
+
 	// insert data to transactions
 	var transactionId int
 	insertTransaction := `INSERT INTO transactions (
@@ -318,9 +453,13 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 							tax,
 							total_transaction,
 							created_by,
-							updated_by)
+							updated_by,
+							scheduled_at,
+							delivery_zone_id,
+							delivery_latitude,
+							delivery_longitude)
 						VALUES 
-							($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+							($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
 						RETURNING 
 							id`
 
@@ -340,6 +479,10 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 		bodyCheckout.TotalTransaction,
 		userId,
 		userId,
+		bodyCheckout.ScheduledAt,
+		bodyCheckout.DeliveryZoneId,
+		bodyCheckout.Latitude,
+		bodyCheckout.Longitude,
 	).Scan(&transactionId)
 	if err != nil {
 		message = "Failed to insert transaction"
@@ -348,9 +491,28 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 
 	// insert data to transaction_items
 	for _, cart := range carts {
+		// claim the flash sale quota the cart price was based on
+		if cart.FlashSaleProductId != nil {
+			commandTag, err := tx.Exec(ctx,
+				`UPDATE flash_sale_products
+				SET sold_quantity = sold_quantity + $1, updated_at = NOW()
+				WHERE id = $2 AND (quantity_cap IS NULL OR sold_quantity + $1 <= quantity_cap)`,
+				cart.Amount, *cart.FlashSaleProductId,
+			)
+			if err != nil {
+				message = "Failed to update flash sale quota"
+				return 0, message, err
+			}
+			if commandTag.RowsAffected() == 0 {
+				message = "Flash sale quota exceeded"
+				return 0, message, fmt.Errorf("not enough flash sale quota left for %s", cart.ProductName)
+			}
+		}
+
 		queryOrdered := `INSERT INTO transaction_items (
 							transaction_id, 
 							product_id, 
+							sku_id, 
 							product_name, 
 							product_price, 
 							discount_percent, 
@@ -359,16 +521,21 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 							size_cost, 
 							variant, 
 							variant_cost, 
+							modifiers_cost, 
 							amount, 
 							subtotal, 
 							created_by, 
 							updated_by) 
 						VALUES 
-							($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`
+							($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
+						RETURNING 
+							id`
 
-		_, err := tx.Exec(ctx, queryOrdered,
+		var transactionItemId int
+		err := tx.QueryRow(ctx, queryOrdered,
 			transactionId,
 			cart.ProductId,
+			cart.SkuId,
 			cart.ProductName,
 			cart.ProductPrice,
 			cart.DiscountPercent,
@@ -377,24 +544,106 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 			cart.SizeCost,
 			cart.VariantName,
 			cart.VariantCost,
+			cart.ModifiersCost,
 			cart.Amount,
 			cart.Subtotal,
 			userId,
 			userId,
-		)
+		).Scan(&transactionItemId)
 		if err != nil {
 			message = "Failed to insert ordered product"
 			return 0, message, err
 		}
 
-		// update stock
-		_, err = tx.Exec(ctx,
-			`UPDATE products SET stock = stock - $1 WHERE id = $2`,
-			cart.Amount, cart.ProductId,
-		)
-		if err != nil {
-			message = "Failed to update stock of product"
-			return 0, message, err
+		// copy the chosen modifiers so the order keeps their names and prices
+		for _, modifier := range cart.Modifiers {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO transaction_item_modifiers (transaction_item_id, modifier_option_id, group_name, option_name, price, created_by, updated_by)
+				VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+				transactionItemId, modifier.ModifierOptionId, modifier.GroupName, modifier.OptionName, modifier.Price, userId,
+			)
+			if err != nil {
+				message = "Failed to insert modifiers of ordered product"
+				return 0, message, err
+			}
+		}
+
+		if cart.IsBundle {
+			// copy the components for the barista, then take their stock, the bundle stock follows by trigger
+			commandTag, err := tx.Exec(ctx,
+				`INSERT INTO transaction_item_components (transaction_item_id, product_id, sku_id, product_name, size, variant, quantity, created_by, updated_by)
+				SELECT $1, p.id, sk.id, p.name, s.name, v.name, bi.quantity, $3, $3
+				FROM product_bundle_items bi
+				JOIN products p ON p.id = bi.component_product_id
+				LEFT JOIN product_skus sk ON sk.product_id = bi.component_product_id
+					AND sk.size_id IS NOT DISTINCT FROM bi.size_id
+					AND sk.variant_id IS NOT DISTINCT FROM bi.variant_id
+				LEFT JOIN sizes s ON s.id = bi.size_id
+				LEFT JOIN variants v ON v.id = bi.variant_id
+				WHERE bi.bundle_product_id = $2
+				ORDER BY bi.sort_order, bi.id`,
+				transactionItemId, cart.ProductId, userId,
+			)
+			if err != nil {
+				message = "Failed to insert components of ordered bundle"
+				return 0, message, err
+			}
+			if commandTag.RowsAffected() == 0 {
+				message = "Bundle has no components"
+				return 0, message, fmt.Errorf("bundle %s has no components", cart.ProductName)
+			}
+
+			// a component listed twice in the same size and variant is taken once with the summed quantity
+			rows, err := tx.Query(ctx,
+				`SELECT
+					bi.component_product_id AS product_id,
+					sk.id AS sku_id,
+					p.deleted_at IS NOT NULL AS is_deleted,
+					SUM(bi.quantity)::int AS quantity
+				FROM product_bundle_items bi
+				JOIN products p ON p.id = bi.component_product_id
+				LEFT JOIN product_skus sk ON sk.product_id = bi.component_product_id
+					AND sk.size_id IS NOT DISTINCT FROM bi.size_id
+					AND sk.variant_id IS NOT DISTINCT FROM bi.variant_id
+				WHERE bi.bundle_product_id = $1
+				GROUP BY bi.component_product_id, bi.size_id, bi.variant_id, sk.id, p.deleted_at`,
+				cart.ProductId,
+			)
+			if err != nil {
+				message = "Failed to update stock of bundle components"
+				return 0, message, err
+			}
+			components, err := pgx.CollectRows(rows, pgx.RowToStructByName[bundleComponentStock])
+			if err != nil {
+				message = "Failed to update stock of bundle components"
+				return 0, message, err
+			}
+
+			for _, component := range components {
+				isTaken := false
+				if !component.IsDeleted {
+					isTaken, err = takeStock(ctx, tx, component.ProductId, component.SkuId, component.Quantity*cart.Amount)
+					if err != nil {
+						message = "Failed to update stock of bundle components"
+						return 0, message, err
+					}
+				}
+				if !isTaken {
+					message = "Not enough stock for bundle components"
+					return 0, message, fmt.Errorf("not enough component stock left for %s", cart.ProductName)
+				}
+			}
+		} else {
+			// update stock of the SKU in the cart, or of the product when it has no SKUs
+			isTaken, err := takeStock(ctx, tx, cart.ProductId, cart.SkuId, cart.Amount)
+			if err != nil {
+				message = "Failed to update stock of product"
+				return 0, message, err
+			}
+			if !isTaken {
+				message = "Not enough stock left"
+				return 0, message, fmt.Errorf("not enough stock left for %s", cart.ProductName)
+			}
 		}
 	}
 
@@ -413,3 +662,118 @@ func MakeTransaction(userId int, bodyCheckout TransactionRequest, carts []Cart)
 	message = "Checkout completed successfully"
 	return transactionId, message, nil
 }
+
+type TransactionExportFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	StatusId  int
+}
+
+type TransactionExportRow struct {
+	NoInvoice        string
+	DateTransaction  time.Time
+	FullName         string
+	Email            string
+	Phone            string
+	Address          string
+	PaymentMethod    string
+	OrderMethod      string
+	Status           string
+	DeliveryFee      float64
+	AdminFee         float64
+	Tax              float64
+	TotalTransaction float64
+	ProductName      string
+	Size             string
+	Variant          string
+	Amount           int
+	ProductPrice     float64
+	DiscountPrice    float64
+	Subtotal         float64
+}
+
+// StreamTransactionExport runs the export query and calls start before the first row, so a failing
+// query returns its error before anything is written to the client
+func StreamTransactionExport(filter TransactionExportFilter, start func() error, writeRow func(row TransactionExportRow) error) error {
+	query := `SELECT
+				t.no_invoice,
+				t.date_transaction,
+				t.full_name,
+				t.email,
+				t.phone,
+				t.address,
+				pm.name AS payment_method,
+				om.name AS order_method,
+				s.name AS status,
+				COALESCE(t.delivery_fee, 0),
+				COALESCE(t.admin_fee, 0),
+				COALESCE(t.tax, 0),
+				t.total_transaction,
+				ti.product_name,
+				COALESCE(ti.size, ''),
+				COALESCE(ti.variant, ''),
+				ti.amount,
+				ti.product_price,
+				COALESCE(ti.discount_price, 0),
+				ti.subtotal
+			FROM transactions t
+			JOIN transaction_items ti ON ti.transaction_id = t.id
+			JOIN payment_methods pm ON pm.id = t.payment_method_id
+			JOIN order_methods om ON om.id = t.order_method_id
+			JOIN status s ON s.id = t.status_id
+			WHERE t.date_transaction >= $1 AND t.date_transaction < $2`
+
+	args := []any{filter.StartDate, filter.EndDate}
+	if filter.StatusId > 0 {
+		query += ` AND t.status_id = $3`
+		args = append(args, filter.StatusId)
+	}
+	query += ` ORDER BY t.date_transaction ASC, t.id ASC, ti.id ASC`
+
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err = start(); err != nil {
+		return err
+	}
+
+	// rows are scanned one by one so the export never holds the whole result
+	for rows.Next() {
+		var row TransactionExportRow
+		err = rows.Scan(
+			&row.NoInvoice,
+			&row.DateTransaction,
+			&row.FullName,
+			&row.Email,
+			&row.Phone,
+			&row.Address,
+			&row.PaymentMethod,
+			&row.OrderMethod,
+			&row.Status,
+			&row.DeliveryFee,
+			&row.AdminFee,
+			&row.Tax,
+			&row.TotalTransaction,
+			&row.ProductName,
+			&row.Size,
+			&row.Variant,
+			&row.Amount,
+			&row.ProductPrice,
+			&row.DiscountPrice,
+			&row.Subtotal,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = writeRow(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
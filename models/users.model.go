@@ -2,10 +2,12 @@ package models
 
 import (
 	"backend-daily-greens/config"
+	"backend-daily-greens/lib"
 	"backend-daily-greens/utils"
 	"context"
 	"errors"
 	"mime/multipart"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -21,6 +23,7 @@ type User struct {
 	Email        string                `json:"email" form:"email" db:"email"`
 	Password     string                `json:"-" form:"-" db:"-"`
 	Role         string                `json:"role" form:"role" db:"role"`
+	DeletedAt    *time.Time            `json:"deletedAt" form:"-" db:"deleted_at"`
 }
 
 func GetTotalDataUsers(search string) (int, error) {
@@ -59,7 +62,8 @@ func GetListAllUser(page int, limit int, search string) ([]User, string, error)
 				COALESCE(profiles.phone_number, '') AS phone_number,
 				COALESCE(profiles.address, '') AS address,
 				users.email,
-				users.role
+				users.role,
+				users.deleted_at
 			FROM users
 			LEFT JOIN profiles ON users.id = profiles.user_id
 			WHERE profiles.full_name ILIKE $3
@@ -78,7 +82,8 @@ func GetListAllUser(page int, limit int, search string) ([]User, string, error)
 				COALESCE(profiles.phone_number, '') AS phone_number,
 				COALESCE(profiles.address, '') AS address,
 				users.email,
-				users.role
+				users.role,
+				users.deleted_at
 			FROM users
 			LEFT JOIN profiles ON users.id = profiles.user_id
 			ORDER BY users.id ASC
@@ -112,7 +117,8 @@ func GetDetailUser(id int) (User, string, error) {
 			COALESCE(profiles.phone_number, '') AS phone_number,
 			COALESCE(profiles.address, '') AS address,
 			users.email,
-			users.role
+			users.role,
+			users.deleted_at
 		FROM users
 		LEFT JOIN profiles ON users.id = profiles.user_id
 		WHERE users.id = $1`, id)
@@ -282,11 +288,80 @@ func UpdateDataUser(userId int, userIdFromToken int, bodyUpdate *User, imageUrl
 	return isSuccess, message, nil
 }
 
-func DeleteDataUser(userId int) (pgconn.CommandTag, error) {
-	commandTag, err := config.DB.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, userId)
+// DeleteDataUser soft deletes a user, the account can no longer log in but its orders and history stay intact
+func DeleteDataUser(userId int, deletedBy int) (pgconn.CommandTag, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE users
+		 SET deleted_at = NOW(),
+		     deleted_by = $2,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NULL`, userId, deletedBy)
+	if err != nil {
+		return commandTag, err
+	}
+
+	return commandTag, err
+}
+
+// RevokeUserTokens rejects the tokens a deleted user still holds, the key lives as long as a token does
+// so the middleware checks it in redis instead of looking the user up in postgres on every request
+func RevokeUserTokens(ctx context.Context, userId int) error {
+	return config.Rdb.Set(ctx, lib.RevokedUserKey(userId), time.Now().Format(time.RFC3339), lib.TokenLifetime).Err()
+}
+
+// ClearUserRevocation lets a restored user use tokens issued after the restore
+func ClearUserRevocation(ctx context.Context, userId int) error {
+	return config.Rdb.Del(ctx, lib.RevokedUserKey(userId)).Err()
+}
+
+func RestoreDataUser(userId int, restoredBy int) (pgconn.CommandTag, error) {
+	commandTag, err := config.DB.Exec(context.Background(),
+		`UPDATE users
+		 SET deleted_at = NULL,
+		     deleted_by = NULL,
+		     updated_by = $2,
+		     updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NOT NULL`, userId, restoredBy)
 	if err != nil {
 		return commandTag, err
 	}
 
 	return commandTag, err
 }
+
+// PurgeDeletedUsers permanently removes users soft deleted at least olderThanDays ago.
+// Users with orders, testimonies or records they created or updated are skipped.
+func PurgeDeletedUsers(olderThanDays int) (PurgeResult, string, error) {
+	result := PurgeResult{}
+	message := ""
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		message = "Failed to start database transaction"
+		return result, message, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := softDeletedIds(ctx, tx, "users", olderThanDays)
+	if err != nil {
+		message = "Failed to fetch deleted users"
+		return result, message, err
+	}
+
+	result, err = purgeRows(ctx, tx, "users", ids)
+	if err != nil {
+		message = "Internal server error while purging users"
+		return result, message, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		message = "Failed to commit transaction"
+		return result, message, err
+	}
+
+	message = "Deleted users purged successfully"
+	return result, message, nil
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func catalogFeedRoutes(r *gin.Engine) {
+	r.GET("/sitemap.xml", controllers.Sitemap)
+	r.GET("/product-feed", controllers.ProductFeed)
+}
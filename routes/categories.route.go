@@ -9,11 +9,15 @@ import (
 func categoriesRoutes(r *gin.Engine, admin *gin.RouterGroup) {
 	categories := admin.Group("/categories")
 	{
+		categories.GET("", controllers.ListCategoriesAdmin)
 		categories.GET("/:id", controllers.DetailCategory)
 		categories.POST("", controllers.CreateCategory)
 		categories.PATCH("/:id", controllers.UpdateCategory)
 		categories.DELETE("/:id", controllers.DeleteCategory)
+		categories.POST("/:id/restore", controllers.RestoreCategory)
+		categories.POST("/purge", controllers.PurgeCategories)
 	}
 
 	r.GET("/categories", controllers.ListCategories)
+	r.GET("/categories/tree", controllers.CategoryTree)
 }
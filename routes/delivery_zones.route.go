@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func deliveryZonesRoutes(admin *gin.RouterGroup) {
+	deliveryZones := admin.Group("/delivery-zones")
+	{
+		deliveryZones.GET("", controllers.ListDeliveryZones)
+		deliveryZones.GET("/:id", controllers.DetailDeliveryZone)
+		deliveryZones.POST("", controllers.CreateDeliveryZone)
+		deliveryZones.PUT("/:id", controllers.UpdateDeliveryZone)
+		deliveryZones.DELETE("/:id", controllers.DeleteDeliveryZone)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func favouritesRoutes(r *gin.RouterGroup) {
+	r.GET("", controllers.ListFavourites)
+	r.POST("/:productId", controllers.AddFavourite)
+	r.DELETE("/:productId", controllers.RemoveFavourite)
+}
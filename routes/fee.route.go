@@ -6,7 +6,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func feeRoutes(r *gin.Engine) {
+func feeRoutes(r *gin.Engine, admin *gin.RouterGroup) {
 	r.GET("/order-methods", controllers.GetAllOrderMethods)
+	r.GET("/order-methods/:id/slots", controllers.ListOrderSlots)
 	r.GET("/payment-methods", controllers.GetAllPaymentMethods)
+
+	admin.PUT("/order-methods/:id/slots", controllers.UpdateOrderMethodSlots)
 }
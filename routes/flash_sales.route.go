@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func flashSalesRoutes(admin *gin.RouterGroup) {
+	flashSales := admin.Group("/flash-sales")
+	{
+		flashSales.GET("", controllers.ListFlashSales)
+		flashSales.GET("/:id", controllers.DetailFlashSale)
+		flashSales.POST("", controllers.CreateFlashSale)
+		flashSales.PATCH("/:id", controllers.UpdateFlashSale)
+		flashSales.DELETE("/:id", controllers.DeleteFlashSale)
+	}
+}
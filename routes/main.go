@@ -14,11 +14,18 @@ func SetUpRoutes(r *gin.Engine) {
 	usersRoutes(admin)
 	categoriesRoutes(r, admin)
 	productsRoutes(r, admin)
+	flashSalesRoutes(admin)
+	modifierGroupsRoutes(admin)
 	transactionsRoutes(r, admin)
+	reportsRoutes(admin)
+	storeRoutes(r, admin)
+	deliveryZonesRoutes(admin)
 
 	// public
 	cartsRouter(r.Group("/carts", middlewares.Auth()))
 	profilesRoutes(r.Group("/profiles", middlewares.Auth()))
 	historiesRoutes(r.Group("/histories", middlewares.Auth()))
-	feeRoutes(r)
+	favouritesRoutes(r.Group("/favourites", middlewares.Auth()))
+	feeRoutes(r, admin)
+	catalogFeedRoutes(r)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func modifierGroupsRoutes(admin *gin.RouterGroup) {
+	modifierGroups := admin.Group("/modifier-groups")
+	{
+		modifierGroups.GET("", controllers.ListModifierGroups)
+		modifierGroups.GET("/:id", controllers.DetailModifierGroup)
+		modifierGroups.POST("", controllers.CreateModifierGroup)
+		modifierGroups.PATCH("/:id", controllers.UpdateModifierGroup)
+		modifierGroups.DELETE("/:id", controllers.DeleteModifierGroup)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"backend-daily-greens/controllers"
+	"backend-daily-greens/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,10 +11,24 @@ func productsRoutes(r *gin.Engine, admin *gin.RouterGroup) {
 	products := admin.Group("/products")
 	{
 		products.GET("", controllers.ListProductsAdmin)
+		products.GET("/export", controllers.ExportProducts)
+		products.POST("/import", controllers.ImportProducts)
+		products.POST("/recommendations/rebuild", controllers.RebuildRecommendations)
+		products.POST("/rankings/rebuild", controllers.RebuildRankings)
 		products.GET("/:id", controllers.DetailProductAdmin)
+		products.GET("/:id/price-history", controllers.ListProductPriceHistory)
+		products.GET("/:id/bundle-items", controllers.ListProductBundleItems)
+		products.PUT("/:id/bundle-items", controllers.UpdateProductBundleItems)
+		products.GET("/:id/skus", controllers.ListProductSkus)
+		products.PUT("/:id/skus", controllers.UpdateProductSkus)
+		products.GET("/:id/nutrition", controllers.ListProductNutrition)
+		products.PUT("/:id/nutrition", controllers.UpdateProductNutrition)
+		products.PUT("/:id/availability", controllers.UpdateProductAvailability)
 		products.POST("", controllers.CreateProduct)
 		products.PATCH("/:id", controllers.UpdateProduct)
 		products.DELETE("/:id", controllers.DeleteProduct)
+		products.POST("/:id/restore", controllers.RestoreProduct)
+		products.POST("/purge", controllers.PurgeProducts)
 
 		products.GET(":id/images", controllers.ListProductImages)
 		products.GET(":id/images/:imageId", controllers.DetailProductImage)
@@ -22,7 +37,10 @@ func productsRoutes(r *gin.Engine, admin *gin.RouterGroup) {
 		products.DELETE(":id/images/:imageId", controllers.DeleteProductImage)
 	}
 
-	r.GET("/products", controllers.ListProductsPublic)
-	r.GET("/products/:id", controllers.DetailProductPublic)
-	r.GET("/favourite-products", controllers.ListFavouriteProducts)
+	r.GET("/products", middlewares.OptionalAuth(), controllers.ListProductsPublic)
+	r.GET("/products/suggest", controllers.SuggestProducts)
+	r.GET("/products/trending", middlewares.OptionalAuth(), controllers.TrendingProducts)
+	r.GET("/products/best-sellers", middlewares.OptionalAuth(), controllers.BestSellerProducts)
+	r.GET("/products/:idOrSlug", middlewares.OptionalAuth(), controllers.DetailProductPublic)
+	r.GET("/favourite-products", middlewares.OptionalAuth(), controllers.ListFavouriteProducts)
 }
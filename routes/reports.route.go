@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func reportsRoutes(admin *gin.RouterGroup) {
+	reports := admin.Group("/reports")
+	{
+		reports.GET("/summary", controllers.SalesSummary)
+		reports.GET("/revenue", controllers.SalesByPeriod)
+		reports.GET("/top-products", controllers.TopProducts)
+		reports.GET("/top-categories", controllers.TopCategories)
+		reports.GET("/breakdown", controllers.SalesBreakdown)
+	}
+}
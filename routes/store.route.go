@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"backend-daily-greens/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func storeRoutes(r *gin.Engine, admin *gin.RouterGroup) {
+	r.GET("/store/status", controllers.GetStoreStatus)
+
+	store := admin.Group("/store")
+	{
+		store.GET("/hours", controllers.ListStoreHours)
+		store.PUT("/hours", controllers.UpdateStoreHours)
+		store.GET("/holidays", controllers.ListStoreHolidays)
+		store.GET("/holidays/:id", controllers.DetailStoreHoliday)
+		store.POST("/holidays", controllers.CreateStoreHoliday)
+		store.PATCH("/holidays/:id", controllers.UpdateStoreHoliday)
+		store.DELETE("/holidays/:id", controllers.DeleteStoreHoliday)
+	}
+}
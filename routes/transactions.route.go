@@ -11,8 +11,11 @@ func transactionsRoutes(r *gin.Engine, admin *gin.RouterGroup) {
 	transactions := admin.Group("/transactions")
 	{
 		transactions.GET("", controllers.ListTransactions)
+		transactions.GET("/export", controllers.ExportTransactions)
 		transactions.GET("/:id", controllers.DetailTransactions)
 		transactions.PATCH("/:id", controllers.UpdateTransactionStatus)
+		transactions.GET("/:id/refunds", controllers.ListRefunds)
+		transactions.POST("/:id/refunds", controllers.CreateRefund)
 	}
 
 	r.POST("/transactions", middlewares.Auth(), controllers.Checkout)
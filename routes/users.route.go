@@ -14,5 +14,7 @@ func usersRoutes(r *gin.RouterGroup) {
 		users.POST("", controllers.CreateUser)
 		users.PATCH("/:id", controllers.UpdateUser)
 		users.DELETE("/:id", controllers.DeleteUser)
+		users.POST("/:id/restore", controllers.RestoreUser)
+		users.POST("/purge", controllers.PurgeUsers)
 	}
 }
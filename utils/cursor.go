@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Cursor - Posisi keyset pagination. Value adalah sort key baris dalam bentuk text dari database,
+// Id sebagai tie breaker, Sort memastikan cursor hanya dipakai untuk urutan yang sama.
+type Cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	Id       int    `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// CursorPage - Cursor encoded untuk halaman berikutnya dan sebelumnya, kosong jika tidak ada
+type CursorPage struct {
+	Next string `json:"next"`
+	Prev string `json:"prev"`
+}
+
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor - Cursor kosong berarti halaman pertama dan menghasilkan nil
+func DecodeCursor(encoded string, sort string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor encoding")
+	}
+
+	cursor := Cursor{}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor payload")
+	}
+
+	if cursor.Sort != sort {
+		return nil, errors.New("cursor was created for a different sort order")
+	}
+
+	return &cursor, nil
+}
+
+// KeysetPage - Rows harus di-fetch dengan limit+1 dalam urutan arah cursor.
+// Baris ekstra dibuang, hasil backward dibalik ke urutan tampilan, lalu cursor next/prev dibuat.
+func KeysetPage[T any](rows []T, limit int, cursor *Cursor, sort string, key func(T) (string, int)) ([]T, CursorPage) {
+	page := CursorPage{}
+	backward := cursor != nil && cursor.Backward
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, page
+	}
+
+	makeCursor := func(row T, isBackward bool) string {
+		value, id := key(row)
+		return EncodeCursor(Cursor{Sort: sort, Value: value, Id: id, Backward: isBackward})
+	}
+
+	// halaman berikutnya ada jika masih ada sisa ke depan, atau kita datang dari sana
+	if (!backward && hasMore) || backward {
+		page.Next = makeCursor(rows[len(rows)-1], false)
+	}
+
+	// halaman sebelumnya ada jika bukan halaman pertama
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		page.Prev = makeCursor(rows[0], true)
+	}
+
+	return rows, page
+}
+
+// KeysetCondition - Kondisi WHERE dan ORDER BY keyset untuk sort key expr dengan tipe castType.
+// Param $valueParam berisi Cursor.Value dan $valueParam+1 berisi Cursor.Id.
+func KeysetCondition(expr string, idExpr string, castType string, desc bool, cursor *Cursor, valueParam int) (string, string) {
+	forwardDesc := desc
+	if cursor != nil && cursor.Backward {
+		forwardDesc = !desc
+	}
+
+	dir := "ASC"
+	op := ">"
+	if forwardDesc {
+		dir = "DESC"
+		op = "<"
+	}
+
+	orderBy := expr + " " + dir
+	if expr != idExpr {
+		orderBy += ", " + idExpr + " " + dir
+	}
+
+	if cursor == nil {
+		return "", orderBy
+	}
+
+	condition := fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d::int)", expr, idExpr, op, valueParam, castType, valueParam+1)
+	return condition, orderBy
+}
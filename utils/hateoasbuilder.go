@@ -58,6 +58,52 @@ func BuildHateoasPagination(ctx *gin.Context, page int, limit int, total int) li
 	}
 }
 
+// BuildHateoasCursorPagination - HATEOAS builder untuk keyset pagination, link next/prev memakai cursor
+// dan last selalu nil karena posisi halaman terakhir tidak diketahui tanpa count
+func BuildHateoasCursorPagination(ctx *gin.Context, limit int, cursors CursorPage) lib.HateoasLink {
+	rawQuery := ctx.Request.URL.Query()
+
+	makeURL := func(cursor string) string {
+		q := url.Values{}
+
+		for key, vals := range rawQuery {
+			if key == "page" || key == "limit" || key == "cursor" {
+				continue
+			}
+			for _, v := range vals {
+				q.Add(key, v)
+			}
+		}
+
+		q.Set("cursor", cursor)
+		q.Set("limit", fmt.Sprintf("%d", limit))
+
+		return fmt.Sprintf("%s://%s%s?%s",
+			getScheme(ctx),
+			ctx.Request.Host,
+			ctx.FullPath(),
+			q.Encode(),
+		)
+	}
+
+	return lib.HateoasLink{
+		Self: makeURL(rawQuery.Get("cursor")),
+		Next: func() any {
+			if cursors.Next != "" {
+				return makeURL(cursors.Next)
+			}
+			return nil
+		}(),
+		Prev: func() any {
+			if cursors.Prev != "" {
+				return makeURL(cursors.Prev)
+			}
+			return nil
+		}(),
+		Last: nil,
+	}
+}
+
 func getScheme(ctx *gin.Context) string {
 	if ctx.Request.TLS != nil {
 		return "https"
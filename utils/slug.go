@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify - Ubah teks menjadi slug URL, huruf kecil ASCII dan angka dipisah satu tanda hubung.
+// Karakter lain dibuang, hasil bisa kosong jika teks tidak punya huruf atau angka.
+func Slugify(text string) string {
+	var builder strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(text) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if pendingDash && builder.Len() > 0 {
+				builder.WriteByte('-')
+			}
+			builder.WriteRune(r)
+			pendingDash = false
+			continue
+		}
+		pendingDash = true
+	}
+	return builder.String()
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XlsxWriter - Minimal streaming xlsx writer dengan satu sheet, row ditulis langsung ke output
+type XlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+func NewXlsxWriter(w io.Writer, sheetName string) (*XlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	workbook := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, xmlEscape(sheetName))
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// sheet harus entry terakhir agar row bisa ditulis secara streaming
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &XlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *XlsxWriter) WriteRow(cells []any) error {
+	x.row++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.row)
+	for _, cell := range cells {
+		switch cell.(type) {
+		case int, int64, float64:
+			fmt.Fprintf(&b, `<c t="n"><v>%s</v></c>`, FormatExportCell(cell))
+		case time.Time:
+			fmt.Fprintf(&b, `<c t="inlineStr"><is><t>%s</t></is></c>`, FormatExportCell(cell))
+		default:
+			fmt.Fprintf(&b, `<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(FormatExportCell(cell)))
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+// FormatExportCell - Format cell export untuk csv dan xlsx, float tanpa notasi eksponen agar total besar tetap terbaca
+func FormatExportCell(cell any) string {
+	switch v := cell.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// EscapeCsvFormula - Prefix ' untuk cell yang diawali =, +, -, @, tab atau CR agar tidak dijalankan sebagai formula oleh Excel
+func EscapeCsvFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// UnescapeCsvFormula - Hapus prefix ' dari EscapeCsvFormula agar file export bisa di-import kembali
+func UnescapeCsvFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// FormatCsvCell - Format cell export untuk csv, teks yang bisa dibaca sebagai formula di-escape
+func FormatCsvCell(cell any) string {
+	if text, ok := cell.(string); ok {
+		return EscapeCsvFormula(text)
+	}
+	return FormatExportCell(cell)
+}
+
+func (x *XlsxWriter) Close() error {
+	_, err := io.WriteString(x.sheet, `</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
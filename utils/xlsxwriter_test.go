@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatExportCell(t *testing.T) {
+	tests := []struct {
+		name string
+		cell any
+		want string
+	}{
+		{"large float", 12500000.0, "12500000"},
+		{"very large float", 1.5e21, "1500000000000000000000"},
+		{"float with decimals", 18500.5, "18500.5"},
+		{"small float", 0.000015, "0.000015"},
+		{"negative float", -250000.0, "-250000"},
+		{"zero float", 0.0, "0"},
+		{"int", 42, "42"},
+		{"string", "Matcha Latte", "Matcha Latte"},
+		{"bool", true, "true"},
+		{"time", time.Date(2026, time.October, 18, 9, 5, 3, 0, time.UTC), "2026-10-18 09:05:03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatExportCell(tt.cell); got != tt.want {
+				t.Errorf("FormatExportCell() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCsvCell(t *testing.T) {
+	tests := []struct {
+		name string
+		cell any
+		want string
+	}{
+		{"plain text", "Jl. Sudirman 1", "Jl. Sudirman 1"},
+		{"empty text", "", ""},
+		{"formula", "=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"plus", "+6281234567", "'+6281234567"},
+		{"minus", "-1+2", "'-1+2"},
+		{"at", "@SUM(A1)", "'@SUM(A1)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"carriage return", "\r=1", "'\r=1"},
+		{"formula character later", "a=b", "a=b"},
+		{"negative number is not escaped", -250000.0, "-250000"},
+		{"int", -3, "-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCsvCell(tt.cell); got != tt.want {
+				t.Errorf("FormatCsvCell() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnescapeCsvFormula(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"escaped formula", "'=SUM(A1)", "=SUM(A1)"},
+		{"escaped minus", "'-5", "-5"},
+		{"apostrophe in text", "'s Morning Blend", "'s Morning Blend"},
+		{"lone apostrophe", "'", "'"},
+		{"plain text", "Matcha", "Matcha"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnescapeCsvFormula(tt.value); got != tt.want {
+				t.Errorf("UnescapeCsvFormula() = %q, want %q", got, tt.want)
+			}
+			if got := UnescapeCsvFormula(EscapeCsvFormula(tt.want)); got != tt.want {
+				t.Errorf("UnescapeCsvFormula(EscapeCsvFormula()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}